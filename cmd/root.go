@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/dotenv"
 	"git.cryptic.systems/volker.raschek/dcmerge/pkg/fetcher"
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/interpolation"
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/textdiff"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -35,32 +42,116 @@ func Execute(version string) error {
 		},
 	}
 
+	diffCmd := &cobra.Command{
+		Use:   "diff <from> <to> | diff <from-sources...> -- <to-sources...>",
+		Args:  diffArgs,
+		Short: "Print the semantic differences between two docker-compose configs",
+		Example: `dcmerge diff docker-compose.yml ./integration-test/docker-compose.yml
+dcmerge diff --format patch docker-compose.yml docker-compose.prod.yml
+dcmerge diff docker-compose.yml -- docker-compose.yml docker-compose.override.yml`,
+		RunE:         diff,
+		SilenceUsage: true,
+	}
+	diffCmd.Flags().String("format", diffFormatText, "Print the differences as text, json or patch (RFC 6902 JSON Patch)")
+	_ = diffCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{diffFormatText, diffFormatJSON, diffFormatPatch}, cobra.ShellCompDirectiveNoFileComp))
+
+	explainCmd := &cobra.Command{
+		Use:   "explain <path> <sources...>",
+		Args:  cobra.MinimumNArgs(2),
+		Short: "Explain which source contributed the values of a path",
+		Example: `dcmerge explain services.app.environment.LOG_LEVEL docker-compose.yml ./integration-test/docker-compose.yml
+dcmerge explain services.app docker-compose.yml ./integration-test/docker-compose.yml`,
+		RunE:         explain,
+		SilenceUsage: true,
+	}
+
+	fmtCmd := &cobra.Command{
+		Use:   "fmt <files...>",
+		Args:  cobra.MinimumNArgs(1),
+		Short: "Rewrite docker-compose files into their canonical form",
+		Example: `dcmerge fmt docker-compose.yml
+dcmerge fmt -w docker-compose.yml ./integration-test/docker-compose.yml
+dcmerge fmt -d docker-compose.yml`,
+		RunE:         format,
+		SilenceUsage: true,
+	}
+	fmtCmd.Flags().BoolP("diff", "d", false, "Print the difference to the canonical form instead of the formatted file")
+	fmtCmd.Flags().BoolP("write", "w", false, "Write the canonical form into the file instead on stdout")
+
+	merge3Cmd := &cobra.Command{
+		Use:   "merge3 <base> <ours> <theirs>",
+		Args:  cobra.ExactArgs(3),
+		Short: "Combine the changes of ours and theirs to their common ancestor base",
+		Example: `dcmerge merge3 upstream-1.0.0/docker-compose.yml docker-compose.yml upstream-2.0.0/docker-compose.yml
+dcmerge merge3 --prefer theirs upstream-1.0.0/docker-compose.yml docker-compose.yml upstream-2.0.0/docker-compose.yml`,
+		RunE:         merge3,
+		SilenceUsage: true,
+	}
+	merge3Cmd.Flags().StringP("output-file", "o", "", "Write instead on stdout into a file")
+	merge3Cmd.Flags().String("prefer", "", "Resolve conflicts by the values of ours or theirs instead of failing")
+	_ = merge3Cmd.RegisterFlagCompletionFunc("prefer", cobra.FixedCompletions([]string{string(dockerCompose.SideOurs), string(dockerCompose.SideTheirs)}, cobra.ShellCompDirectiveNoFileComp))
+
+	validateCmd := &cobra.Command{
+		Use:   "validate <sources...>",
+		Args:  cobra.MinimumNArgs(1),
+		Short: "Validate docker-compose files against the JSON schema of the compose specification",
+		Example: `dcmerge validate docker-compose.yml
+dcmerge validate docker-compose.yml https://git.example.local/user/repo/docker-compose.yml`,
+		RunE:         validate,
+		SilenceUsage: true,
+	}
+
 	rootCmd := &cobra.Command{
 		Use:   "dcmerge",
 		Args:  cobra.MinimumNArgs(1),
 		Short: "Merge docker-compose files from multiple resources",
 		Example: `dcmerge docker-compose.yml ./integration-test/docker-compose.yml
 dcmerge docker-compose.yml https://git.example.local/user/repo/docker-compose.yml`,
-		RunE:    run,
-		Version: version,
+		RunE:         run,
+		SilenceUsage: true,
+		Version:      version,
 	}
-	rootCmd.Flags().BoolP("existing-win", "f", false, "Protect existing attributes")
-	rootCmd.Flags().BoolP("last-win", "l", false, "Overwrite existing attributes")
+	rootCmd.Flags().Bool("anchors", false, "Declare repeated identical blocks once as anchor and reference them by aliases")
+	rootCmd.Flags().Bool("annotate", false, "Add the source, line and column of each value as comment")
+	rootCmd.PersistentFlags().Bool("compose-compatible", false, "Merge by the merge rules of the compose specification like docker compose")
+	rootCmd.PersistentFlags().StringArray("env-file", []string{}, "Read variables for the interpolation from an env file")
+	rootCmd.PersistentFlags().BoolP("existing-win", "f", false, "Protect existing attributes")
+	rootCmd.PersistentFlags().Bool("fix", false, "Declare missing configs, networks, secrets and volumes and drop references to missing services")
+	rootCmd.PersistentFlags().BoolP("last-win", "l", false, "Overwrite existing attributes")
+	rootCmd.PersistentFlags().String("lock-file", "", "Fail when a file changes an attribute of the paths declared in the lock file")
+	rootCmd.PersistentFlags().Bool("no-interpolate", false, "Keep variable expressions verbatim")
 	rootCmd.Flags().StringP("output-file", "o", "", "Write instead on stdout into a file")
+	rootCmd.PersistentFlags().String("policy", "", "Merge the attributes of the paths declared in the policy file by the declared strategy")
+	rootCmd.PersistentFlags().String("project-directory", "", "Read the .env file from this directory instead of the directory of the first local file")
+	rootCmd.PersistentFlags().String("rebase-directory", "", "Rewrite relative paths of local files to refer to this directory")
+	rootCmd.Flags().String("report", "", "Write a JSON report of the changes of each file into a file")
+	rootCmd.PersistentFlags().String("strategy", string(dockerCompose.StrategyDefault), "Merge the files by the registered strategy with this name")
+	_ = rootCmd.RegisterFlagCompletionFunc("strategy", completeStrategies)
+	rootCmd.PersistentFlags().Bool("strict", false, "Fail when multiple files declare different values for the same attribute")
+	rootCmd.PersistentFlags().Bool("validate", false, "Fail when a file or the merged config violates the JSON schema of the compose specification")
+	rootCmd.PersistentFlags().Bool("validate-references", false, "Fail when a service references an undeclared config, network, secret, service or volume")
+	rootCmd.PersistentFlags().Bool("validate-version", false, "Fail when a file declares attributes, which are not supported by its version")
+	rootCmd.PersistentFlags().String("version-policy", "", "Keep the highest version, drop the version or fail when the versions disagree (highest, drop, fail)")
+	_ = rootCmd.RegisterFlagCompletionFunc("version-policy", cobra.FixedCompletions([]string{string(dockerCompose.VersionPolicyHighest), string(dockerCompose.VersionPolicyDrop), string(dockerCompose.VersionPolicyFail)}, cobra.ShellCompDirectiveNoFileComp))
 	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(explainCmd)
+	rootCmd.AddCommand(fmtCmd)
+	rootCmd.AddCommand(merge3Cmd)
+	rootCmd.AddCommand(validateCmd)
 
 	return rootCmd.Execute()
 }
 
 func run(cmd *cobra.Command, args []string) error {
-	mergeExisting, err := cmd.Flags().GetBool("existing-win")
+	anchors, err := cmd.Flags().GetBool("anchors")
 	if err != nil {
-		return fmt.Errorf("failed to parse flag existing-win: %s", err)
+		return fmt.Errorf("failed to parse flag anchors: %s", err)
 	}
 
-	mergeLastWin, err := cmd.Flags().GetBool("last-win")
+	annotate, err := cmd.Flags().GetBool("annotate")
 	if err != nil {
-		return fmt.Errorf("failed to parse flag last-win: %s", err)
+		return fmt.Errorf("failed to parse flag annotate: %s", err)
 	}
 
 	outputFile, err := cmd.Flags().GetString("output-file")
@@ -68,23 +159,25 @@ func run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to parse flag output-file: %s", err)
 	}
 
-	dockerComposeConfig := dockerCompose.NewConfig()
+	reportFile, err := cmd.Flags().GetString("report")
+	if err != nil {
+		return fmt.Errorf("failed to parse flag report: %s", err)
+	}
 
-	dockerComposeConfigs, err := fetcher.Fetch(args...)
+	noInterpolate, err := cmd.Flags().GetBool("no-interpolate")
+	if err != nil {
+		return fmt.Errorf("failed to parse flag no-interpolate: %s", err)
+	}
+
+	result, err := fetchAndMerge(cmd, args, annotate || len(reportFile) > 0)
 	if err != nil {
 		return err
 	}
 
-	for _, config := range dockerComposeConfigs {
-		switch {
-		case mergeExisting && mergeLastWin:
-			return fmt.Errorf("neither --existing-win or --last-win can be specified - not booth")
-		case mergeExisting && !mergeLastWin:
-			dockerComposeConfig.MergeExistingWin(config)
-		case !mergeExisting && mergeLastWin:
-			dockerComposeConfig.MergeLastWin(config)
-		default:
-			dockerComposeConfig.Merge(config)
+	if len(reportFile) > 0 {
+		err = writeReport(reportFile, result.Report)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -103,14 +196,684 @@ func run(cmd *cobra.Command, args []string) error {
 		}
 		defer func() { _ = f.Close() }()
 
-		yamlEncoder := yaml.NewEncoder(f)
-		yamlEncoder.SetIndent(2)
-		return yamlEncoder.Encode(dockerComposeConfig)
+		return encode(f, result.Document, anchors, annotate, !noInterpolate)
+
+	default:
+		return encode(os.Stdout, result.Document, anchors, annotate, !noInterpolate)
+	}
+
+}
+
+// Formats of the differences printed by the diff command.
+const (
+	diffFormatJSON  string = "json"
+	diffFormatPatch string = "patch"
+	diffFormatText  string = "text"
+)
+
+// diffArgs validates the arguments of the diff command. Without a dash, exactly two sources are compared. With a
+// dash, the merged sources in front of the dash are compared with the merged sources after the dash.
+func diffArgs(cmd *cobra.Command, args []string) error {
+	dash := cmd.ArgsLenAtDash()
+	switch {
+	case dash < 0 && len(args) != 2:
+		return fmt.Errorf("accepts 2 arg(s) without dash, received %v", len(args))
+	case dash == 0 || dash == len(args):
+		return fmt.Errorf("requires sources in front of and after the dash")
+	default:
+		return nil
+	}
+}
+
+func diff(cmd *cobra.Command, args []string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return fmt.Errorf("failed to parse flag format: %s", err)
+	}
+
+	fromSources, toSources := args[:1], args[1:]
+	if dash := cmd.ArgsLenAtDash(); dash > 0 {
+		fromSources, toSources = args[:dash], args[dash:]
+	}
+
+	fromResult, err := fetchAndMerge(cmd, fromSources, false)
+	if err != nil {
+		return err
+	}
+
+	toResult, err := fetchAndMerge(cmd, toSources, false)
+	if err != nil {
+		return err
+	}
+
+	changes := dockerCompose.Diff(fromResult.Document.Config, toResult.Document.Config)
 
+	w := cmd.OutOrStdout()
+	switch format {
+	case diffFormatText:
+		fmt.Fprint(w, changes.String())
+		return nil
+	case diffFormatJSON:
+		jsonEncoder := json.NewEncoder(w)
+		jsonEncoder.SetIndent("", "  ")
+		return jsonEncoder.Encode(changes)
+	case diffFormatPatch:
+		jsonEncoder := json.NewEncoder(w)
+		jsonEncoder.SetIndent("", "  ")
+		return jsonEncoder.Encode(changes.JSONPatch())
 	default:
-		yamlEncoder := yaml.NewEncoder(os.Stdout)
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+func explain(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	result, err := fetchAndMerge(cmd, args[1:], true)
+	if err != nil {
+		return err
+	}
+
+	provenance := result.Document.Provenance()
+	paths := provenance.Lookup(path)
+	if len(paths) <= 0 {
+		return fmt.Errorf("no value found for path %s", path)
+	}
+
+	w := cmd.OutOrStdout()
+	for _, leafPath := range paths {
+		origin := provenance[leafPath]
+		fmt.Fprintf(w, "%s: %s\n", leafPath, origin.Value)
+		fmt.Fprintf(w, "  from %s\n", origin.String())
+		for i := len(origin.Overridden) - 1; i >= 0; i-- {
+			fmt.Fprintf(w, "  overrides %s from %s\n", origin.Overridden[i].Value, origin.Overridden[i].String())
+		}
+	}
+
+	return nil
+}
+
+func format(cmd *cobra.Command, args []string) error {
+	diff, err := cmd.Flags().GetBool("diff")
+	if err != nil {
+		return fmt.Errorf("failed to parse flag diff: %s", err)
+	}
+
+	write, err := cmd.Flags().GetBool("write")
+	if err != nil {
+		return fmt.Errorf("failed to parse flag write: %s", err)
+	}
+
+	for _, name := range args {
+		// #nosec G304
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		node := new(yaml.Node)
+		err = yaml.Unmarshal(b, node)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+
+		dockerCompose.Format(node)
+
+		buffer := new(bytes.Buffer)
+		yamlEncoder := yaml.NewEncoder(buffer)
 		yamlEncoder.SetIndent(2)
-		return yamlEncoder.Encode(dockerComposeConfig)
+		err = yamlEncoder.Encode(node)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case diff:
+			fmt.Fprint(cmd.OutOrStdout(), textdiff.Unified(name+".orig", name, string(b), buffer.String()))
+		case !write:
+			_, err = buffer.WriteTo(cmd.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if write && !bytes.Equal(b, buffer.Bytes()) {
+			fileInfo, err := os.Stat(name)
+			if err != nil {
+				return err
+			}
+
+			err = os.WriteFile(name, buffer.Bytes(), fileInfo.Mode().Perm())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validate(cmd *cobra.Command, args []string) error {
+	fetchOptions, err := fetchOptionsOf(cmd)
+	if err != nil {
+		return err
+	}
+
+	documents, err := fetcher.FetchDocuments(fetchOptions, args...)
+	if err != nil {
+		return err
+	}
+
+	schemaErrors, err := validateDocuments(documents)
+	if err != nil {
+		return err
+	}
+
+	if len(schemaErrors) > 0 {
+		fmt.Fprint(cmd.ErrOrStderr(), schemaErrors.String())
+		return fmt.Errorf("found %v schema errors", len(schemaErrors))
+	}
+
+	return nil
+}
+
+func merge3(cmd *cobra.Command, args []string) error {
+	outputFile, err := cmd.Flags().GetString("output-file")
+	if err != nil {
+		return fmt.Errorf("failed to parse flag output-file: %s", err)
+	}
+
+	prefer, err := cmd.Flags().GetString("prefer")
+	if err != nil {
+		return fmt.Errorf("failed to parse flag prefer: %s", err)
+	}
+
+	fetchOptions, err := fetchOptionsOf(cmd)
+	if err != nil {
+		return err
+	}
+
+	configs, err := fetcher.FetchWithOptions(fetchOptions, args...)
+	if err != nil {
+		return err
+	}
+
+	dockerComposeConfig, conflicts, err := dockerCompose.Merge3(configs[0], configs[1], configs[2], dockerCompose.Side(prefer))
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 && len(prefer) <= 0 {
+		fmt.Fprint(cmd.ErrOrStderr(), conflicts.String())
+		return fmt.Errorf("found %v conflicting attributes", len(conflicts))
+	}
+
+	if len(outputFile) <= 0 {
+		return encode(cmd.OutOrStdout(), dockerCompose.NewDocument(dockerComposeConfig), false, false, !fetchOptions.NoInterpolate)
+	}
+
+	// #nosec G301
+	err = os.MkdirAll(filepath.Dir(outputFile), 0755)
+	if err != nil {
+		return err
+	}
+
+	// #nosec G304
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	return encode(f, dockerCompose.NewDocument(dockerComposeConfig), false, false, !fetchOptions.NoInterpolate)
+}
+
+// fetchAndMerge fetches the docker-compose files of the passed sources and merges them by the strategy of the flags
+// into a single document. Sources prefixed by a strategy are merged by this strategy instead. The provenance and the
+// report of the result are only recorded, when trackChanges is true.
+func fetchAndMerge(cmd *cobra.Command, sources []string, trackChanges bool) (*dockerCompose.MergeResult, error) {
+	composeCompatible, err := cmd.Flags().GetBool("compose-compatible")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag compose-compatible: %s", err)
+	}
+
+	mergeExisting, err := cmd.Flags().GetBool("existing-win")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag existing-win: %s", err)
+	}
+
+	mergeLastWin, err := cmd.Flags().GetBool("last-win")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag last-win: %s", err)
+	}
+
+	fixReferences, err := cmd.Flags().GetBool("fix")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag fix: %s", err)
+	}
+
+	lockFile, err := cmd.Flags().GetString("lock-file")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag lock-file: %s", err)
+	}
+
+	policyFile, err := cmd.Flags().GetString("policy")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag policy: %s", err)
+	}
+
+	strategyName, err := cmd.Flags().GetString("strategy")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag strategy: %s", err)
+	}
+
+	strict, err := cmd.Flags().GetBool("strict")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag strict: %s", err)
+	}
+
+	validateSchema, err := cmd.Flags().GetBool("validate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag validate: %s", err)
+	}
+
+	validateReferences, err := cmd.Flags().GetBool("validate-references")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag validate-references: %s", err)
+	}
+
+	validateVersion, err := cmd.Flags().GetBool("validate-version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag validate-version: %s", err)
+	}
+
+	versionPolicyName, err := cmd.Flags().GetString("version-policy")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag version-policy: %s", err)
+	}
+
+	// The version policy is validated before fetching, which may take a while for remote docker-compose files.
+	var versionPolicy dockerCompose.VersionPolicy
+	if len(versionPolicyName) > 0 {
+		versionPolicy, err = dockerCompose.ParseVersionPolicy(versionPolicyName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	strategy := dockerCompose.Strategy(strategyName)
+	switch {
+	case mergeExisting && mergeLastWin:
+		return nil, fmt.Errorf("neither --existing-win or --last-win can be specified - not booth")
+	case (mergeExisting || mergeLastWin) && cmd.Flags().Changed("strategy"):
+		return nil, fmt.Errorf("--strategy can't be combined with --existing-win or --last-win")
+	case composeCompatible && (mergeExisting || mergeLastWin || cmd.Flags().Changed("strategy")):
+		return nil, fmt.Errorf("--compose-compatible can't be combined with --existing-win, --last-win or --strategy")
+	case composeCompatible:
+		strategy = dockerCompose.StrategyComposeCompatible
+	case mergeExisting && !mergeLastWin:
+		strategy = dockerCompose.StrategyExistingWin
+	case !mergeExisting && mergeLastWin:
+		strategy = dockerCompose.StrategyLastWin
+	}
+
+	_, err = dockerCompose.LookupStrategy(strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	sources, strategies := splitSourceStrategies(sources, strategy)
+
+	policy, err := readPolicy(policyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	locks, err := readLocks(lockFile)
+	if err != nil {
+		return nil, err
+	}
+
+	fetchOptions, err := fetchOptionsOf(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	merger := dockerCompose.NewMerger()
+	merger.Lock(locks...)
+	if trackChanges {
+		merger.TrackChanges()
+	}
+
+	documents, err := fetcher.FetchDocuments(fetchOptions, sources...)
+	if err != nil {
+		return nil, err
+	}
+
+	dockerComposeConfigs := make([]*dockerCompose.Config, 0, len(documents))
+	for _, document := range documents {
+		dockerComposeConfigs = append(dockerComposeConfigs, document.Config)
+	}
+
+	if validateSchema {
+		schemaErrors, err := validateDocuments(documents)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(schemaErrors) > 0 {
+			fmt.Fprint(cmd.ErrOrStderr(), schemaErrors.String())
+			return nil, fmt.Errorf("found %v schema errors", len(schemaErrors))
+		}
+	}
+
+	if validateVersion {
+		versionIssues := make(dockerCompose.VersionIssues, 0)
+		for _, document := range documents {
+			documentVersionIssues, err := document.VersionIssues()
+			if err != nil {
+				return nil, err
+			}
+			versionIssues = append(versionIssues, documentVersionIssues...)
+		}
+
+		if len(versionIssues) > 0 {
+			fmt.Fprint(cmd.ErrOrStderr(), versionIssues.String())
+			return nil, fmt.Errorf("found %v attributes not supported by the version", len(versionIssues))
+		}
+	}
+
+	if strict {
+		conflicts := dockerCompose.FindConflicts(sources, documents)
+		if len(conflicts) > 0 {
+			fmt.Fprint(cmd.ErrOrStderr(), conflicts.String())
+			return nil, fmt.Errorf("found %v conflicting attributes", len(conflicts))
+		}
+	}
+
+	// The documents are merged in the order of the arguments, each by the strategy of its source.
+	for i, document := range documents {
+		err = merger.MergeWithPolicy(document, policy, strategies[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := merger.Result()
+	dockerComposeConfig := result.Document.Config
+
+	if len(versionPolicy) > 0 {
+		dockerComposeConfig.Version, err = dockerCompose.MergeVersions(sources, dockerComposeConfigs, versionPolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fixReferences {
+		dockerComposeConfig.FixDanglingReferences()
+	}
+
+	if validateReferences {
+		danglingReferences := dockerComposeConfig.DanglingReferences()
+		if len(danglingReferences) > 0 {
+			fmt.Fprint(cmd.ErrOrStderr(), danglingReferences.String())
+			return nil, fmt.Errorf("found %v dangling references", len(danglingReferences))
+		}
+	}
+
+	if len(result.LockViolations) > 0 {
+		fmt.Fprint(cmd.ErrOrStderr(), result.LockViolations.String())
+		return nil, fmt.Errorf("found %v violated locked attributes", len(result.LockViolations))
+	}
+
+	if validateSchema {
+		schemaErrors, err := validateOutput(result.Document)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(schemaErrors) > 0 {
+			fmt.Fprint(cmd.ErrOrStderr(), schemaErrors.String())
+			return nil, fmt.Errorf("found %v schema errors in the merged config", len(schemaErrors))
+		}
+	}
+
+	return result, nil
+}
+
+// validateDocuments returns the schema errors of all passed documents in the order of the documents.
+func validateDocuments(documents []*dockerCompose.Document) (dockerCompose.SchemaErrors, error) {
+	schemaErrors := make(dockerCompose.SchemaErrors, 0)
+	for _, document := range documents {
+		documentSchemaErrors, err := document.ValidateSchema()
+		if err != nil {
+			return nil, err
+		}
+		schemaErrors = append(schemaErrors, documentSchemaErrors...)
+	}
+	return schemaErrors, nil
+}
+
+// validateOutput returns the schema errors of the merged document. The lines and columns refer to the merged config
+// written by dcmerge without anchors and annotations.
+func validateOutput(document *dockerCompose.Document) (dockerCompose.SchemaErrors, error) {
+	buffer := new(bytes.Buffer)
+	err := encode(buffer, document, false, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	node := new(yaml.Node)
+	err = yaml.Unmarshal(buffer.Bytes(), node)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaErrors, err := dockerCompose.ValidateSchema(node)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, schemaError := range schemaErrors {
+		schemaError.Source = "<merged>"
+	}
+
+	return schemaErrors, nil
+}
+
+// strategyPrefixes maps the short names of the builtin strategies, which can prefix a source, to the strategies.
+var strategyPrefixes = map[string]dockerCompose.Strategy{
+	"existingwin": dockerCompose.StrategyExistingWin,
+	"lastwin":     dockerCompose.StrategyLastWin,
+}
+
+// splitSourceStrategies returns the sources without their strategy prefix and the strategy of each source. A source is
+// prefixed by the name of a registered strategy or the short name of a builtin strategy followed by a colon, for
+// example lastwin:docker-compose.override.yml. Sources without prefix are merged by the passed strategy. Prefixes, which
+// are no strategy, for example the scheme of a URL, are part of the source.
+func splitSourceStrategies(sources []string, strategy dockerCompose.Strategy) ([]string, []dockerCompose.Strategy) {
+	sourceNames := make([]string, 0, len(sources))
+	strategies := make([]dockerCompose.Strategy, 0, len(sources))
+	for _, source := range sources {
+		prefix, sourceName, found := strings.Cut(source, ":")
+		prefixStrategy, present := strategyPrefixes[prefix]
+		if !present {
+			prefixStrategy = dockerCompose.Strategy(prefix)
+			_, err := dockerCompose.LookupStrategy(prefixStrategy)
+			present = err == nil
+		}
+
+		switch {
+		case found && present:
+			sourceNames = append(sourceNames, sourceName)
+			strategies = append(strategies, prefixStrategy)
+		default:
+			sourceNames = append(sourceNames, source)
+			strategies = append(strategies, strategy)
+		}
+	}
+
+	return sourceNames, strategies
+}
+
+// completeStrategies returns the names of all registered merge strategies for the shell completion.
+func completeStrategies(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names := make([]string, 0)
+	for _, strategy := range dockerCompose.Strategies() {
+		names = append(names, string(strategy))
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// fetchOptionsOf returns the options to fetch the docker-compose files by the flags of the command.
+func fetchOptionsOf(cmd *cobra.Command) (*fetcher.Options, error) {
+	envFiles, err := cmd.Flags().GetStringArray("env-file")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag env-file: %s", err)
+	}
+
+	noInterpolate, err := cmd.Flags().GetBool("no-interpolate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag no-interpolate: %s", err)
+	}
+
+	projectDirectory, err := cmd.Flags().GetString("project-directory")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag project-directory: %s", err)
+	}
+
+	rebaseDirectory, err := cmd.Flags().GetString("rebase-directory")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag rebase-directory: %s", err)
+	}
+
+	fetchOptions, err := newFetchOptions(envFiles, noInterpolate)
+	if err != nil {
+		return nil, err
+	}
+	fetchOptions.ProjectDirectory = projectDirectory
+	fetchOptions.RebaseDirectory = rebaseDirectory
+
+	return fetchOptions, nil
+}
+
+// newFetchOptions returns the options to fetch the docker-compose files. The variables of the process environment take
+// precedence over the variables of the env files. Later env files overwrite the variables of earlier ones. The .env
+// file of the project directory is read by the fetcher with the lowest precedence. Variable expressions of the env files
+// refer to the variables declared before in the same env file or to the variables of the process environment.
+func newFetchOptions(envFiles []string, noInterpolate bool) (*fetcher.Options, error) {
+	fetchOptions := fetcher.NewOptions()
+	fetchOptions.NoInterpolate = noInterpolate
+
+	for i := len(envFiles) - 1; i >= 0; i-- {
+		variables, err := dotenv.ReadWithLookup(envFiles[i], os.LookupEnv)
+		if err != nil {
+			return nil, err
+		}
+
+		for key, value := range variables {
+			if _, present := fetchOptions.Environment[key]; !present {
+				fetchOptions.Environment[key] = value
+			}
+		}
+	}
+
+	return fetchOptions, nil
+}
+
+// writeReport writes the report as JSON into the file.
+func writeReport(name string, report *dockerCompose.Report) error {
+	// #nosec G301
+	err := os.MkdirAll(filepath.Dir(name), 0755)
+	if err != nil {
+		return err
+	}
+
+	// #nosec G304
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	jsonEncoder := json.NewEncoder(f)
+	jsonEncoder.SetIndent("", "  ")
+	return jsonEncoder.Encode(report)
+}
+
+// readPolicy returns the policy of the passed file. When no file is passed, no policy is returned and all attributes are
+// merged by the strategy of the flags.
+func readPolicy(name string) (*dockerCompose.Policy, error) {
+	if len(name) <= 0 {
+		return nil, nil
+	}
+
+	// #nosec G304
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := dockerCompose.NewPolicy()
+	err = yaml.Unmarshal(b, policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", name, err)
+	}
+
+	return policy, nil
+}
+
+// readLocks returns the paths of the locked attributes of the passed file. The file declares the paths as YAML list.
+// When no file is passed, no paths are returned.
+func readLocks(name string) ([]string, error) {
+	if len(name) <= 0 {
+		return nil, nil
+	}
+
+	// #nosec G304
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	locks := make([]string, 0)
+	err = yaml.Unmarshal(b, &locks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lock file %s: %w", name, err)
+	}
+
+	return locks, nil
+}
+
+// encode writes the config of the docker-compose document as YAML into the writer. When anchors is true, repeated
+// identical blocks are declared once as anchor and referenced by aliases. When annotate is true, the origin of each
+// value is added as comment. When escape is true, each $ of the interpolated values is escaped as $$ like docker
+// compose config does, so that literal $ are not interpolated again by docker compose.
+func encode(w io.Writer, document *dockerCompose.Document, anchors bool, annotate bool, escape bool) error {
+	node := new(yaml.Node)
+	err := node.Encode(document.Config)
+	if err != nil {
+		return err
+	}
+
+	if annotate {
+		dockerCompose.AnnotateProvenance(node, document.Provenance())
+	}
+
+	if anchors {
+		err = dockerCompose.AnchorRepeatedNodes(node, document.Anchors())
+		if err != nil {
+			return err
+		}
+	}
+
+	// The values are escaped at last, because the provenance and the anchors refer to the interpolated values.
+	if escape {
+		interpolation.EscapeNode(node)
 	}
 
+	yamlEncoder := yaml.NewEncoder(w)
+	yamlEncoder.SetIndent(2)
+	return yamlEncoder.Encode(node)
 }
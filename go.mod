@@ -3,6 +3,7 @@ module git.cryptic.systems/volker.raschek/dcmerge
 go 1.20
 
 require (
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
 	github.com/spf13/cobra v1.10.2
 	github.com/stretchr/testify v1.12.1
 	gopkg.in/yaml.v3 v3.0.1
@@ -1,9 +1,16 @@
 package main
 
-import "git.cryptic.systems/volker.raschek/dcmerge/cmd"
+import (
+	"os"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/cmd"
+)
 
 var version string
 
 func main() {
-	_ = cmd.Execute(version)
+	err := cmd.Execute(version)
+	if err != nil {
+		os.Exit(1)
+	}
 }
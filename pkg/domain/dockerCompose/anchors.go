@@ -0,0 +1,221 @@
+package dockerCompose
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// minAnchorNodeSize is the minimal number of entries of a mapping or sequence to be declared as anchor. Smaller nodes
+// are more readable without an alias.
+const minAnchorNodeSize int = 2
+
+var regExpInvalidAnchorCharacters = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Anchors maps the canonical YAML representation of a mapping or sequence to the name of its anchor.
+type Anchors map[string]string
+
+// Clone returns a copy of the anchors.
+func (a Anchors) Clone() Anchors {
+	if a == nil {
+		return nil
+	}
+
+	anchors := make(Anchors, len(a))
+	for key, name := range a {
+		anchors[key] = name
+	}
+	return anchors
+}
+
+// collectAnchors returns the anchors of all mappings and sequences of the passed node. When the same node is anchored
+// multiple times, the first name wins.
+func collectAnchors(node *yaml.Node) (Anchors, error) {
+	anchors := make(Anchors)
+
+	var walk func(node *yaml.Node) error
+	walk = func(node *yaml.Node) error {
+		if len(node.Anchor) > 0 && (node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode) {
+			key, err := canonicalNodeKey(node)
+			if err != nil {
+				return err
+			}
+
+			if _, present := anchors[key]; !present {
+				anchors[key] = node.Anchor
+			}
+		}
+
+		for _, child := range node.Content {
+			err := walk(child)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	err := walk(node)
+	if err != nil {
+		return nil, err
+	}
+
+	return anchors, nil
+}
+
+// mergeAnchors adds all anchors which are not already known.
+func mergeAnchors(a Anchors, anchors Anchors) Anchors {
+	switch {
+	case len(anchors) <= 0:
+		return a
+	case a == nil:
+		a = make(Anchors)
+	}
+
+	for key, name := range anchors {
+		if _, present := a[key]; !present {
+			a[key] = name
+		}
+	}
+
+	return a
+}
+
+// canonicalNodeKey returns the canonical YAML representation of the passed node. Aliases and merge keys are resolved
+// and the keys of mappings are sorted, therefore equal nodes have the same representation.
+func canonicalNodeKey(node *yaml.Node) (string, error) {
+	var v interface{}
+	err := node.Decode(&v)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// isAnchorCandidate returns true if the node is a mapping or sequence, which is big enough to be declared as anchor.
+func isAnchorCandidate(node *yaml.Node) bool {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return len(node.Content)/2 >= minAnchorNodeSize
+	case yaml.SequenceNode:
+		return len(node.Content) >= minAnchorNodeSize
+	default:
+		return false
+	}
+}
+
+// AnchorRepeatedNodes declares repeated identical mappings and sequences of the passed node once as anchor and replaces
+// the repetitions by an alias. The names of the anchors are taken from the passed anchors if the node was already
+// anchored in a source, otherwise the name is derived from the key of the first occurrence.
+func AnchorRepeatedNodes(node *yaml.Node, anchors Anchors) error {
+	occurrences := make(map[string]int)
+
+	var count func(node *yaml.Node, root bool) error
+	count = func(node *yaml.Node, root bool) error {
+		if !root && isAnchorCandidate(node) {
+			key, err := canonicalNodeKey(node)
+			if err != nil {
+				return err
+			}
+
+			occurrences[key]++
+			if occurrences[key] > 1 {
+				return nil
+			}
+		}
+
+		for _, child := range node.Content {
+			err := count(child, node.Kind == yaml.DocumentNode)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	err := count(node, true)
+	if err != nil {
+		return err
+	}
+
+	anchorNodes := make(map[string]*yaml.Node)
+	usedNames := make(map[string]struct{})
+
+	var replace func(node *yaml.Node, keyName string, root bool) error
+	replace = func(node *yaml.Node, keyName string, root bool) error {
+		if !root && isAnchorCandidate(node) {
+			key, err := canonicalNodeKey(node)
+			if err != nil {
+				return err
+			}
+
+			if occurrences[key] > 1 {
+				anchorNode, present := anchorNodes[key]
+				if present {
+					*node = yaml.Node{
+						Kind:  yaml.AliasNode,
+						Value: anchorNode.Anchor,
+						Alias: anchorNode,
+					}
+					return nil
+				}
+
+				node.Anchor = anchorName(anchors[key], keyName, usedNames)
+				usedNames[node.Anchor] = struct{}{}
+				anchorNodes[key] = node
+			}
+		}
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				err := replace(node.Content[i+1], node.Content[i].Value, false)
+				if err != nil {
+					return err
+				}
+			}
+		default:
+			for _, child := range node.Content {
+				err := replace(child, keyName, node.Kind == yaml.DocumentNode)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	return replace(node, "", true)
+}
+
+// anchorName returns a unique name for an anchor. The preferred name is used if not already taken, otherwise the name
+// is derived from the key name.
+func anchorName(preferredName string, keyName string, usedNames map[string]struct{}) string {
+	name := preferredName
+	if _, used := usedNames[name]; used || len(name) <= 0 {
+		name = regExpInvalidAnchorCharacters.ReplaceAllString(strings.TrimPrefix(keyName, extensionPrefix), "-")
+	}
+
+	if len(name) <= 0 {
+		name = "anchor"
+	}
+
+	uniqueName := name
+	for i := 2; ; i++ {
+		if _, used := usedNames[uniqueName]; !used {
+			return uniqueName
+		}
+		uniqueName = fmt.Sprintf("%s-%d", name, i)
+	}
+}
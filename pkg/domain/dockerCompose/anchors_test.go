@@ -0,0 +1,84 @@
+package dockerCompose_test
+
+import (
+	"bytes"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAnchorRepeatedNodes(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		s              string
+		expectedString string
+	}{
+		{
+			s: `x-environment: &env
+  - TZ=UTC
+  - LANG=C
+services:
+  app:
+    environment: *env
+    image: library/app:latest
+  db:
+    environment: *env
+    image: library/db:latest
+`,
+			expectedString: `services:
+  app:
+    environment: &env
+      - TZ=UTC
+      - LANG=C
+    image: library/app:latest
+  db:
+    environment: *env
+    image: library/db:latest
+x-environment: *env
+`,
+		},
+		{
+			s: `services:
+  app:
+    cap_add:
+    - NET_ADMIN
+    - SYS_TIME
+    image: library/app:latest
+  db:
+    cap_add:
+    - NET_ADMIN
+    - SYS_TIME
+    image: library/db:latest
+`,
+			expectedString: `services:
+  app:
+    cap_add: &cap_add
+      - NET_ADMIN
+      - SYS_TIME
+    image: library/app:latest
+  db:
+    cap_add: *cap_add
+    image: library/db:latest
+`,
+		},
+	}
+
+	for i, testCase := range testCases {
+		document := dockerCompose.NewDocument(dockerCompose.NewConfig())
+		require.NoError(yaml.Unmarshal([]byte(testCase.s), document), "TestCase %v", i)
+
+		node := new(yaml.Node)
+		require.NoError(node.Encode(document.Config), "TestCase %v", i)
+		require.NoError(dockerCompose.AnchorRepeatedNodes(node, document.Anchors()), "TestCase %v", i)
+
+		actualBytesBuffer := new(bytes.Buffer)
+		yamlEncoder := yaml.NewEncoder(actualBytesBuffer)
+		yamlEncoder.SetIndent(2)
+		require.NoError(yamlEncoder.Encode(node), "TestCase %v", i)
+		require.NoError(yamlEncoder.Close(), "TestCase %v", i)
+		require.Equal(testCase.expectedString, actualBytesBuffer.String(), "TestCase %v", i)
+	}
+}
@@ -0,0 +1,49 @@
+package dockerCompose
+
+// Cloneable is implemented by all objects of a docker-compose file, which can be deep copied.
+type Cloneable[T any] interface {
+	Clone() T
+}
+
+// CloneSlice returns a deep copy of the passed slice of cloneables. A nil slice stays nil.
+func CloneSlice[C Cloneable[C]](slice []C) []C {
+	if slice == nil {
+		return nil
+	}
+
+	clonedSlice := make([]C, len(slice))
+	for i := range slice {
+		clonedSlice[i] = slice[i].Clone()
+	}
+	return clonedSlice
+}
+
+// CloneStringMap returns a deep copy of the passed string map of cloneables. A nil map stays nil.
+func CloneStringMap[C Cloneable[C]](m map[string]C) map[string]C {
+	if m == nil {
+		return nil
+	}
+
+	clonedMap := make(map[string]C, len(m))
+	for key, value := range m {
+		clonedMap[key] = value.Clone()
+	}
+	return clonedMap
+}
+
+// cloneSlice returns a copy of the passed slice of values. A nil slice stays nil.
+func cloneSlice[K any](slice []K) []K {
+	if slice == nil {
+		return nil
+	}
+	return append(make([]K, 0, len(slice)), slice...)
+}
+
+// clonePointer returns a pointer to a copy of the value of the passed pointer. A nil pointer stays nil.
+func clonePointer[K any](p *K) *K {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
@@ -0,0 +1,132 @@
+package dockerCompose_test
+
+import (
+	"sync"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const cloneTestConfig = `networks:
+  proxy:
+    ipam:
+      config:
+      - subnet: 10.0.0.0/24
+services:
+  app:
+    build: ./app
+    depends_on:
+      db:
+        condition: service_healthy
+    deploy:
+      resources:
+        limits:
+          memory: 512M
+    environment:
+    - LOG_LEVEL=info
+    image: example.local/app:1.0.0
+    networks:
+      proxy:
+        aliases:
+        - app
+    ulimits:
+      nofile:
+        hard: 2048
+        soft: 1024
+    x-owner:
+      team: a
+volumes:
+  data: {}
+x-defaults:
+  tags:
+  - a
+`
+
+func TestConfig_Clone(t *testing.T) {
+	require := require.New(t)
+
+	config := dockerCompose.NewConfig()
+	require.NoError(yaml.Unmarshal([]byte(cloneTestConfig), config))
+
+	clonedConfig := config.Clone()
+	require.True(config.Equal(clonedConfig))
+
+	clonedConfig.Services["app"].Build.Context = "./other"
+	clonedConfig.Services["app"].DependsOnContainer.DependsOn["db"].Condition = dockerCompose.ServiceDependsOnConditionServiceStarted
+	clonedConfig.Services["app"].Deploy.Resources.Limits.Memory = "1G"
+	clonedConfig.Services["app"].Environments[0] = "LOG_LEVEL=debug"
+	clonedConfig.Services["app"].Networks["proxy"].Aliases[0] = "other"
+	clonedConfig.Services["app"].ULimits.NoFile.Hard = 4096
+	clonedConfig.Services["app"].Extensions["x-owner"].(map[string]interface{})["team"] = "b"
+	clonedConfig.Networks["proxy"].IPAM.Configs[0].Subnet = "10.0.1.0/24"
+	clonedConfig.Extensions["x-defaults"].(map[string]interface{})["tags"].([]interface{})[0] = "b"
+	delete(clonedConfig.Volumes, "data")
+
+	expectedConfig := dockerCompose.NewConfig()
+	require.NoError(yaml.Unmarshal([]byte(cloneTestConfig), expectedConfig))
+	require.True(expectedConfig.Equal(config))
+	require.Equal(expectedConfig.Extensions, config.Extensions)
+	require.Equal(expectedConfig.Services["app"].Extensions, config.Services["app"].Extensions)
+}
+
+func TestConfig_MergedLastWin(t *testing.T) {
+	require := require.New(t)
+
+	base := dockerCompose.NewConfig()
+	require.NoError(yaml.Unmarshal([]byte(cloneTestConfig), base))
+
+	overlays := make([]*dockerCompose.Config, 0)
+	for _, overlay := range []string{
+		`services:
+  app:
+    environment:
+    - LOG_LEVEL=debug
+    image: example.local/app:2.0.0
+`,
+		`services:
+  app:
+    deploy:
+      resources:
+        limits:
+          memory: 1G
+  db:
+    image: example.local/db:1.0.0
+`,
+	} {
+		config := dockerCompose.NewConfig()
+		require.NoError(yaml.Unmarshal([]byte(overlay), config))
+		overlays = append(overlays, config)
+	}
+
+	expectedBase := base.Clone()
+	expectedOverlays := []*dockerCompose.Config{overlays[0].Clone(), overlays[1].Clone()}
+
+	mergedConfigs := make([]*dockerCompose.Config, len(overlays))
+	wg := new(sync.WaitGroup)
+	for i := range overlays {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mergedConfigs[i] = base.MergedLastWin(overlays[i])
+		}(i)
+	}
+	wg.Wait()
+
+	require.True(expectedBase.Equal(base))
+	for i := range overlays {
+		require.True(expectedOverlays[i].Equal(overlays[i]), "Overlay %v", i)
+	}
+
+	require.Equal("example.local/app:2.0.0", mergedConfigs[0].Services["app"].Image)
+	require.Equal([]string{"LOG_LEVEL=debug"}, mergedConfigs[0].Services["app"].Environments)
+	require.Equal("512M", mergedConfigs[0].Services["app"].Deploy.Resources.Limits.Memory)
+	require.Equal("example.local/app:1.0.0", mergedConfigs[1].Services["app"].Image)
+	require.Equal("1G", mergedConfigs[1].Services["app"].Deploy.Resources.Limits.Memory)
+	require.True(mergedConfigs[1].ExistsService("db"))
+
+	// Merged configs don't share any objects with the overlays.
+	mergedConfigs[1].Services["db"].Image = "example.local/db:2.0.0"
+	require.Equal("example.local/db:1.0.0", overlays[1].Services["db"].Image)
+}
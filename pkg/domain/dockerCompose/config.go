@@ -1,9 +1,14 @@
 package dockerCompose
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
+	"unicode"
 
 	"gopkg.in/yaml.v3"
 )
@@ -17,11 +22,49 @@ const (
 )
 
 type Config struct {
-	Networks map[string]*Network `json:"networks,omitempty" yaml:"networks,omitempty"`
-	Secrets  map[string]*Secret  `json:"secrets,omitempty" yaml:"secrets,omitempty"`
-	Services map[string]*Service `json:"services,omitempty" yaml:"services,omitempty"`
-	Version  string              `json:"version,omitempty" yaml:"version,omitempty"`
-	Volumes  map[string]*Volume  `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+	Configs  map[string]*ConfigObject `json:"configs,omitempty" yaml:"configs,omitempty"`
+	Include  []*Include               `json:"include,omitempty" yaml:"include,omitempty"`
+	Networks map[string]*Network      `json:"networks,omitempty" yaml:"networks,omitempty"`
+	Secrets  map[string]*Secret       `json:"secrets,omitempty" yaml:"secrets,omitempty"`
+	Services map[string]*Service      `json:"services,omitempty" yaml:"services,omitempty"`
+	Version  string                   `json:"version,omitempty" yaml:"version,omitempty"`
+	Volumes  map[string]*Volume       `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+
+	Extensions Extensions `json:"-" yaml:",inline"`
+
+	base            string
+	mergeDirectives []*mergeDirective
+}
+
+// Base returns the directory or URL, which relative paths of the config refer to. It's empty, when the config was not
+// read from a file or URL.
+func (c *Config) Base() string {
+	return c.base
+}
+
+// SetBase sets the directory or URL, which relative paths of the config refer to.
+func (c *Config) SetBase(base string) {
+	c.base = base
+}
+
+// Clone returns a deep copy of the config, including the merge directives.
+func (c *Config) Clone() *Config {
+	if c == nil {
+		return nil
+	}
+
+	return &Config{
+		Configs:         CloneStringMap(c.Configs),
+		Include:         CloneSlice(c.Include),
+		Networks:        CloneStringMap(c.Networks),
+		Secrets:         CloneStringMap(c.Secrets),
+		Services:        CloneStringMap(c.Services),
+		Version:         c.Version,
+		Volumes:         CloneStringMap(c.Volumes),
+		Extensions:      c.Extensions.Clone(),
+		base:            c.base,
+		mergeDirectives: cloneSlice(c.mergeDirectives),
+	}
 }
 
 // Equal returns true if the passed equalable is equal
@@ -31,20 +74,12 @@ func (c *Config) Equal(equalable Equalable) bool {
 		return false
 	}
 
-	switch {
-	case c == nil && config == nil:
-		return true
-	case c != nil && config == nil:
-		fallthrough
-	case c == nil && config != nil:
-		return false
-	default:
-		return EqualStringMap(c.Networks, config.Networks) &&
-			EqualStringMap(c.Secrets, config.Secrets) &&
-			EqualStringMap(c.Services, config.Services) &&
-			c.Version == config.Version &&
-			EqualStringMap(c.Volumes, config.Volumes)
-	}
+	return equalObject(c, config)
+}
+
+// ExistsConfig returns true if a config with the passed named exists.
+func (c *Config) ExistsConfig(name string) bool {
+	return ExistsInMap(c.Configs, name)
 }
 
 // ExistsNetwork returns true if a network with the passed named exists.
@@ -67,47 +102,91 @@ func (c *Config) ExistsVolume(name string) bool {
 	return ExistsInMap(c.Volumes, name)
 }
 
+// Merged returns a new config, to which only missing networks, secrets, services and volumes of the passed config are
+// added. Neither the config nor the passed config are modified.
+func (c *Config) Merged(config *Config) *Config {
+	mergedConfig := c.cloneOrNew()
+	mergedConfig.Merge(config)
+	return mergedConfig
+}
+
+// MergedExistingWin returns a new config, into which the passed config is merged without overwriting existing
+// properties. Neither the config nor the passed config are modified.
+func (c *Config) MergedExistingWin(config *Config) *Config {
+	mergedConfig := c.cloneOrNew()
+	mergedConfig.MergeExistingWin(config)
+	return mergedConfig
+}
+
+// MergedLastWin returns a new config, into which the passed config is merged by overwriting existing properties.
+// Neither the config nor the passed config are modified.
+func (c *Config) MergedLastWin(config *Config) *Config {
+	mergedConfig := c.cloneOrNew()
+	mergedConfig.MergeLastWin(config)
+	return mergedConfig
+}
+
+// cloneOrNew returns a deep copy of the config or a new config, when the config is nil.
+func (c *Config) cloneOrNew() *Config {
+	if c == nil {
+		return NewConfig()
+	}
+	return c.Clone()
+}
+
 // Merge adds only a missing network, secret, service and volume.
 func (c *Config) Merge(config *Config) {
-	for name, network := range config.Networks {
-		if !c.ExistsNetwork(name) {
-			if c.Networks == nil {
-				c.Networks = make(map[string]*Network)
-			}
-			c.Networks[name] = network
-		}
-	}
+	c.mergeConfig(config, nil, StrategyDefault, nil)
+}
 
-	for name, secret := range config.Secrets {
-		if !c.ExistsSecret(name) {
-			if c.Secrets == nil {
-				c.Secrets = make(map[string]*Secret)
-			}
-			c.Secrets[name] = secret
-		}
-	}
+// MergeExistingWin merges a config without overwriting already existing properties.
+func (c *Config) MergeExistingWin(config *Config) {
+	c.mergeConfig(config, nil, StrategyExistingWin, nil)
+}
 
-	for name, service := range config.Services {
-		if !c.ExistsService(name) {
-			if c.Services == nil {
-				c.Services = make(map[string]*Service)
-			}
-			c.Services[name] = service
-		}
+// MergeLastWin merges a config and overwrite already existing properties
+func (c *Config) MergeLastWin(config *Config) {
+	c.mergeConfig(config, nil, StrategyLastWin, nil)
+}
+
+// MergeComposeCompatible merges a config by the merge rules of the compose specification like docker compose merges
+// multiple files.
+func (c *Config) MergeComposeCompatible(config *Config) {
+	c.mergeConfig(config, nil, StrategyComposeCompatible, nil)
+}
+
+// ExtendedService returns the service of the passed name expanded by the base service like the extends attribute of the
+// compose specification. The attributes of the service overwrite the attributes of a copy of the base service like by
+// the last-win merge. Attributes of the service tagged by !reset are removed from and attributes tagged by !override
+// replace the attributes of the base service. Neither the service nor the base service are modified.
+func (c *Config) ExtendedService(name string, baseService *Service) *Service {
+	service := c.Services[name]
+
+	baseConfig := NewConfig()
+	baseConfig.Services[name] = baseService.Clone()
+	if baseConfig.Services[name] == nil {
+		baseConfig.Services[name] = NewService()
 	}
 
-	for name, volume := range config.Volumes {
-		if !c.ExistsVolume(name) {
-			if c.Volumes == nil {
-				c.Volumes = make(map[string]*Volume)
-			}
-			c.Volumes[name] = volume
+	serviceConfig := NewConfig()
+	serviceConfig.Services[name] = service
+	for _, mergeDirective := range c.mergeDirectives {
+		if len(mergeDirective.path) >= 2 && mergeDirective.path[0] == "services" && mergeDirective.path[1] == name {
+			serviceConfig.mergeDirectives = append(serviceConfig.mergeDirectives, mergeDirective)
 		}
 	}
+
+	baseConfig.MergeLastWin(serviceConfig)
+
+	extendedService := baseConfig.Services[name]
+	extendedService.Extends = nil
+	return extendedService
 }
 
-// MergeLastWin merges a config and overwrite already existing properties
-func (c *Config) MergeExistingWin(config *Config) {
+// mergeConfig merges the passed config by the strategies of the policy and the fallback strategy for all attributes
+// without rule. Attributes tagged by !reset are removed before and attributes tagged by !override are replaced after
+// the merge. The decisions of the merge are recorded by the tracker, unless the tracker is nil.
+func (c *Config) mergeConfig(config *Config, policy *Policy, fallback Strategy, tracker *mergeTracker) {
 	switch {
 	case c == nil && config == nil:
 		fallthrough
@@ -123,176 +202,176 @@ func (c *Config) MergeExistingWin(config *Config) {
 	// 	fallthrough
 
 	default:
-		c.mergeExistingWinNetworks(config.Networks)
-		c.mergeExistingWinSecrets(config.Secrets)
-		c.mergeExistingWinServices(config.Services)
-		c.mergeExistingWinVersion(config.Version)
-		c.mergeExistingWinVolumes(config.Volumes)
+		mc := &mergeContext{fallback: fallback, policy: policy, tracker: tracker}
+		c.applyResets(config, mc)
+		mergeStruct(reflect.ValueOf(c).Elem(), reflect.ValueOf(config).Elem(), nil, mc)
+		c.applyOverrides(config, mc)
 	}
 }
 
-// MergeLastWin merges a config and overwrite already existing properties
-func (c *Config) MergeLastWin(config *Config) {
-	switch {
-	case c == nil && config == nil:
-		fallthrough
-	case c != nil && config == nil:
-		return
+// UnmarshalYAML implements the UnmarshalYAML interface to customize the behavior when being unmarshaled into a YAML
+// document. Unknown fields are dropped, only extension fields prefixed with x- are kept. Attributes tagged by !reset or
+// !override are recorded and applied when the config is merged into another config.
+func (c *Config) UnmarshalYAML(value *yaml.Node) error {
+	c.mergeDirectives = append(c.mergeDirectives, collectMergeDirectives(value)...)
+
+	type config Config
+	err := value.Decode((*config)(c))
+	if err != nil {
+		return err
+	}
 
-	// WARN: It's not possible to change the memory pointer c *Config
-	// to a new initialized config without returning the Config
-	// it self.
-	//
-	// case c == nil && config != nil:
-	// 	c = NewConfig()
-	// 	fallthrough
+	pruneExtensions(reflect.ValueOf(c))
 
-	default:
-		c.mergeLastWinNetworks(config.Networks)
-		c.mergeLastWinSecrets(config.Secrets)
-		c.mergeLastWinServices(config.Services)
-		c.mergeLastWinVersion(config.Version)
-		c.mergeLastWinVolumes(config.Volumes)
-	}
+	return nil
 }
 
-func (c *Config) mergeExistingWinVersion(version string) {
-	if len(c.Version) <= 0 {
-		c.Version = version
+func NewConfig() *Config {
+	return &Config{
+		Configs:  make(map[string]*ConfigObject),
+		Services: make(map[string]*Service),
+		Networks: make(map[string]*Network),
+		Secrets:  make(map[string]*Secret),
+		Volumes:  make(map[string]*Volume),
 	}
 }
 
-func (c *Config) mergeExistingWinNetworks(networks map[string]*Network) {
-	for networkName, network := range networks {
-		if network == nil {
-			continue
-		}
+// ConfigObject is a config of the top-level attribute configs, which can be mounted into the containers of services.
+type ConfigObject struct {
+	External bool   `json:"external,omitempty" yaml:"external,omitempty" merge:"overwrite"`
+	File     string `json:"file,omitempty" yaml:"file,omitempty"`
 
-		if c.ExistsNetwork(networkName) {
-			c.Networks[networkName].MergeExistingWin(network)
-		} else {
-			c.Networks[networkName] = network
-		}
-	}
+	Extensions Extensions `json:"-" yaml:",inline"`
 }
 
-func (c *Config) mergeExistingWinSecrets(secrets map[string]*Secret) {
-	for secretName, secret := range secrets {
-		if secret == nil {
-			continue
-		}
+// Clone returns a deep copy of the config object.
+func (co *ConfigObject) Clone() *ConfigObject {
+	if co == nil {
+		return nil
+	}
 
-		if c.ExistsSecret(secretName) {
-			c.Secrets[secretName].MergeExistingWin(secret)
-		} else {
-			c.Secrets[secretName] = secret
-		}
+	return &ConfigObject{
+		External:   co.External,
+		File:       co.File,
+		Extensions: co.Extensions.Clone(),
 	}
 }
 
-func (c *Config) mergeExistingWinServices(services map[string]*Service) {
-	for serviceName, service := range services {
-		if service == nil {
-			continue
-		}
-
-		if c.ExistsService(serviceName) {
-			c.Services[serviceName].MergeExistingWin(service)
-		} else {
-			c.Services[serviceName] = service
-		}
+// Equal returns true if the passed equalable is equal
+func (co *ConfigObject) Equal(equalable Equalable) bool {
+	configObject, ok := equalable.(*ConfigObject)
+	if !ok {
+		return false
 	}
+
+	return equalObject(co, configObject)
 }
 
-func (c *Config) mergeExistingWinVolumes(volumes map[string]*Volume) {
-	for volumeName, volume := range volumes {
-		if volume == nil {
-			continue
-		}
+// MergeExistingWin merges adds or overwrite the attributes of the passed config object
+// with the existing one.
+func (co *ConfigObject) MergeExistingWin(configObject *ConfigObject) {
+	mergeObject(co, configObject, StrategyExistingWin)
+}
 
-		if c.ExistsVolume(volumeName) {
-			c.Volumes[volumeName].MergeExistingWin(volume)
-		} else {
-			c.Volumes[volumeName] = volume
-		}
-	}
+// MergeLastWin merges adds or overwrite the attributes of the passed config object
+// with the existing one.
+func (co *ConfigObject) MergeLastWin(configObject *ConfigObject) {
+	mergeObject(co, configObject, StrategyLastWin)
 }
 
-func (c *Config) mergeLastWinVersion(version string) {
-	if c.Version != version {
-		c.Version = version
-	}
+func NewConfigObject() *ConfigObject {
+	return &ConfigObject{}
 }
 
-func (c *Config) mergeLastWinNetworks(networks map[string]*Network) {
-	for networkName, network := range networks {
-		if network == nil {
-			continue
-		}
+// Include references other docker-compose files, whose resources are added to the including docker-compose file.
+type Include struct {
+	EnvFile          []string `json:"env_file,omitempty" yaml:"env_file,omitempty"`
+	Path             []string `json:"path,omitempty" yaml:"path,omitempty"`
+	ProjectDirectory string   `json:"project_directory,omitempty" yaml:"project_directory,omitempty"`
 
-		if c.ExistsNetwork(networkName) {
-			c.Networks[networkName].MergeLastWin(network)
-		} else {
-			c.Networks[networkName] = network
-		}
-	}
+	Extensions Extensions `json:"-" yaml:",inline"`
 }
 
-func (c *Config) mergeLastWinSecrets(secrets map[string]*Secret) {
-	for secretName, secret := range secrets {
-		if secret == nil {
-			continue
-		}
+// Clone returns a deep copy of the include.
+func (i *Include) Clone() *Include {
+	if i == nil {
+		return nil
+	}
 
-		if c.ExistsNetwork(secretName) {
-			c.Secrets[secretName].MergeLastWin(secret)
-		} else {
-			c.Secrets[secretName] = secret
-		}
+	return &Include{
+		EnvFile:          cloneSlice(i.EnvFile),
+		Path:             cloneSlice(i.Path),
+		ProjectDirectory: i.ProjectDirectory,
+		Extensions:       i.Extensions.Clone(),
 	}
 }
 
-func (c *Config) mergeLastWinServices(services map[string]*Service) {
-	for serviceName, service := range services {
-		if service == nil {
-			continue
-		}
-
-		if c.ExistsService(serviceName) {
-			c.Services[serviceName].MergeLastWin(service)
-		} else {
-			c.Services[serviceName] = service
-		}
+// Equal returns true if the passed equalable is equal
+func (i *Include) Equal(equalable Equalable) bool {
+	include, ok := equalable.(*Include)
+	if !ok {
+		return false
 	}
+
+	return equalObject(i, include)
 }
 
-func (c *Config) mergeLastWinVolumes(volumes map[string]*Volume) {
-	for volumeName, volume := range volumes {
-		if volume == nil {
-			continue
-		}
+// UnmarshalYAML implements the UnmarshalYAML interface to customize the behavior when being unmarshaled into a YAML
+// document. The short syntax of include is only the path of the docker-compose file, the long syntax accepts for path
+// and env_file a single string or a list of strings.
+func (i *Include) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		i.Path = []string{value.Value}
+		return nil
+	}
 
-		if c.ExistsNetwork(volumeName) {
-			c.Volumes[volumeName].MergeLastWin(volume)
-		} else {
-			c.Volumes[volumeName] = volume
-		}
+	include := struct {
+		EnvFile          stringOrSlice `yaml:"env_file"`
+		Path             stringOrSlice `yaml:"path"`
+		ProjectDirectory string        `yaml:"project_directory"`
+		Extensions       Extensions    `yaml:",inline"`
+	}{}
+
+	err := value.Decode(&include)
+	if err != nil {
+		return err
 	}
+
+	i.EnvFile = include.EnvFile
+	i.Path = include.Path
+	i.ProjectDirectory = include.ProjectDirectory
+	i.Extensions = include.Extensions
+
+	return nil
 }
 
-func NewConfig() *Config {
-	return &Config{
-		Services: make(map[string]*Service),
-		Networks: make(map[string]*Network),
-		Secrets:  make(map[string]*Secret),
-		Volumes:  make(map[string]*Volume),
+func NewInclude() *Include {
+	return &Include{
+		EnvFile: make([]string, 0),
+		Path:    make([]string, 0),
 	}
 }
 
 type Network struct {
-	External bool         `json:"external,omitempty" yaml:"external,omitempty"`
+	External bool         `json:"external,omitempty" yaml:"external,omitempty" merge:"overwrite"`
 	Driver   string       `json:"driver,omitempty" yaml:"driver,omitempty"`
 	IPAM     *NetworkIPAM `json:"ipam,omitempty" yaml:"ipam,omitempty"`
+
+	Extensions Extensions `json:"-" yaml:",inline"`
+}
+
+// Clone returns a deep copy of the network.
+func (n *Network) Clone() *Network {
+	if n == nil {
+		return nil
+	}
+
+	return &Network{
+		External:   n.External,
+		Driver:     n.Driver,
+		IPAM:       n.IPAM.Clone(),
+		Extensions: n.Extensions.Clone(),
+	}
 }
 
 // Equal returns true if the passed equalable is equal
@@ -302,70 +381,15 @@ func (n *Network) Equal(equalable Equalable) bool {
 		return false
 	}
 
-	switch {
-	case n == nil && network == nil:
-		return true
-	case n != nil && network == nil:
-		fallthrough
-	case n == nil && network != nil:
-		return false
-	default:
-		return n.External == network.External &&
-			n.Driver == network.Driver &&
-			n.IPAM.Equal(network.IPAM)
-	}
+	return equalObject(n, network)
 }
 
 func (n *Network) MergeExistingWin(network *Network) {
-	switch {
-	case n == nil && network == nil:
-		fallthrough
-	case n != nil && network == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer n *Network
-	// to a new initialized network without returning the Network
-	// it self.
-	//
-	// case n == nil && network != nil:
-	// 	c = NewCNetwork()
-	// 	fallthrough
-
-	default:
-		n.mergeExistingWinIPAM(network.IPAM)
-	}
+	mergeObject(n, network, StrategyExistingWin)
 }
 
 func (n *Network) MergeLastWin(network *Network) {
-	switch {
-	case n == nil && network == nil:
-		fallthrough
-	case n != nil && network == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer n *Network
-	// to a new initialized network without returning the Network
-	// it self.
-	//
-	// case n == nil && network != nil:
-	// 	c = NewCNetwork()
-	// 	fallthrough
-
-	default:
-		n.mergeLastWinIPAM(network.IPAM)
-	}
-}
-
-func (n *Network) mergeExistingWinIPAM(networkIPAM *NetworkIPAM) {
-	if !n.IPAM.Equal(networkIPAM) {
-		n.IPAM.MergeExistingWin(networkIPAM)
-	}
-}
-
-func (n *Network) mergeLastWinIPAM(networkIPAM *NetworkIPAM) {
-	if !n.IPAM.Equal(networkIPAM) {
-		n.IPAM.MergeLastWin(networkIPAM)
-	}
+	mergeObject(n, network, StrategyLastWin)
 }
 
 func NewNetwork() *Network {
@@ -377,6 +401,20 @@ func NewNetwork() *Network {
 
 type NetworkIPAM struct {
 	Configs []*NetworkIPAMConfig `json:"config,omitempty" yaml:"config,omitempty"`
+
+	Extensions Extensions `json:"-" yaml:",inline"`
+}
+
+// Clone returns a deep copy of the network IPAM.
+func (nIPAM *NetworkIPAM) Clone() *NetworkIPAM {
+	if nIPAM == nil {
+		return nil
+	}
+
+	return &NetworkIPAM{
+		Configs:    CloneSlice(nIPAM.Configs),
+		Extensions: nIPAM.Extensions.Clone(),
+	}
 }
 
 // Equal returns true if the passed equalable is equal
@@ -386,72 +424,15 @@ func (nIPAM *NetworkIPAM) Equal(equalable Equalable) bool {
 		return false
 	}
 
-	switch {
-	case nIPAM == nil && networkIPAM == nil:
-		return true
-	case nIPAM != nil && networkIPAM == nil:
-		fallthrough
-	case nIPAM == nil && networkIPAM != nil:
-		return false
-	default:
-		return Equal(nIPAM.Configs, networkIPAM.Configs)
-	}
+	return equalObject(nIPAM, networkIPAM)
 }
 
 func (nIPAM *NetworkIPAM) MergeExistingWin(networkIPAM *NetworkIPAM) {
-	switch {
-	case nIPAM == nil && networkIPAM == nil:
-		fallthrough
-	case nIPAM != nil && networkIPAM == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer n *NetworkIPAM
-	// to a new initialized networkIPAM without returning the NetworkIPAM
-	// it self.
-	//
-	// case nIPAM == nil && networkIPAM != nil:
-	// 	c = NewNetworkIPAM()
-	// 	fallthrough
-
-	default:
-		nIPAM.mergeExistingWinConfig(networkIPAM.Configs)
-	}
+	mergeObject(nIPAM, networkIPAM, StrategyExistingWin)
 }
 
 func (nIPAM *NetworkIPAM) MergeLastWin(networkIPAM *NetworkIPAM) {
-	switch {
-	case nIPAM == nil && networkIPAM == nil:
-		fallthrough
-	case nIPAM != nil && networkIPAM == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer n *NetworkIPAM
-	// to a new initialized networkIPAM without returning the NetworkIPAM
-	// it self.
-	//
-	// case nIPAM == nil && networkIPAM != nil:
-	// 	c = NewNetworkIPAM()
-	// 	fallthrough
-
-	default:
-		nIPAM.mergeLastWinConfig(networkIPAM.Configs)
-	}
-}
-
-func (nIPAM *NetworkIPAM) mergeExistingWinConfig(networkIPAMConfigs []*NetworkIPAMConfig) {
-	for _, networkIPAMConfig := range networkIPAMConfigs {
-		if !existsInSlice(nIPAM.Configs, networkIPAMConfig) {
-			nIPAM.Configs = append(nIPAM.Configs, networkIPAMConfig)
-		}
-	}
-}
-
-func (nIPAM *NetworkIPAM) mergeLastWinConfig(networkIPAMConfigs []*NetworkIPAMConfig) {
-	for _, networkIPAMConfig := range networkIPAMConfigs {
-		if !existsInSlice(nIPAM.Configs, networkIPAMConfig) {
-			nIPAM.Configs = append(nIPAM.Configs, networkIPAMConfig)
-		}
-	}
+	mergeObject(nIPAM, networkIPAM, StrategyLastWin)
 }
 
 func NewNetworkIPAM() *NetworkIPAM {
@@ -462,6 +443,20 @@ func NewNetworkIPAM() *NetworkIPAM {
 
 type NetworkIPAMConfig struct {
 	Subnet string `json:"subnet,omitempty" yaml:"subnet,omitempty"`
+
+	Extensions Extensions `json:"-" yaml:",inline"`
+}
+
+// Clone returns a deep copy of the network IPAM config.
+func (nIPAMConfig *NetworkIPAMConfig) Clone() *NetworkIPAMConfig {
+	if nIPAMConfig == nil {
+		return nil
+	}
+
+	return &NetworkIPAMConfig{
+		Subnet:     nIPAMConfig.Subnet,
+		Extensions: nIPAMConfig.Extensions.Clone(),
+	}
 }
 
 // Equal returns true if the passed equalable is equal
@@ -471,16 +466,7 @@ func (nIPAMConfig *NetworkIPAMConfig) Equal(equalable Equalable) bool {
 		return false
 	}
 
-	switch {
-	case nIPAMConfig == nil && networkIPAMConfig == nil:
-		return true
-	case nIPAMConfig != nil && networkIPAMConfig == nil:
-		fallthrough
-	case nIPAMConfig == nil && networkIPAMConfig != nil:
-		return false
-	default:
-		return nIPAMConfig.Subnet == networkIPAMConfig.Subnet
-	}
+	return equalObject(nIPAMConfig, networkIPAMConfig)
 }
 
 func NewNetworkIPAMConfig() *NetworkIPAMConfig {
@@ -488,7 +474,23 @@ func NewNetworkIPAMConfig() *NetworkIPAMConfig {
 }
 
 type Secret struct {
-	File string `json:"file,omitempty" yaml:"file,omitempty"`
+	External bool   `json:"external,omitempty" yaml:"external,omitempty" merge:"overwrite"`
+	File     string `json:"file,omitempty" yaml:"file,omitempty"`
+
+	Extensions Extensions `json:"-" yaml:",inline"`
+}
+
+// Clone returns a deep copy of the secret.
+func (s *Secret) Clone() *Secret {
+	if s == nil {
+		return nil
+	}
+
+	return &Secret{
+		External:   s.External,
+		File:       s.File,
+		Extensions: s.Extensions.Clone(),
+	}
 }
 
 // Equal returns true if the passed equalable is equal
@@ -498,32 +500,19 @@ func (s *Secret) Equal(equalable Equalable) bool {
 		return false
 	}
 
-	switch {
-	case s == nil && secret == nil:
-		return true
-	case s != nil && secret == nil:
-		fallthrough
-	case s == nil && secret != nil:
-		return false
-	default:
-		return s.File == secret.File
-	}
+	return equalObject(s, secret)
 }
 
 // MergeExistingWin merges adds or overwrite the attributes of the passed secret
 // with the existing one.
 func (s *Secret) MergeExistingWin(secret *Secret) {
-	if len(s.File) <= 0 {
-		s.File = secret.File
-	}
+	mergeObject(s, secret, StrategyExistingWin)
 }
 
 // MergeLastWin merges adds or overwrite the attributes of the passed secret
 // with the existing one.
 func (s *Secret) MergeLastWin(secret *Secret) {
-	if !s.Equal(secret) {
-		s.File = secret.File
-	}
+	mergeObject(s, secret, StrategyLastWin)
 }
 
 func NewSecret() *Secret {
@@ -531,20 +520,28 @@ func NewSecret() *Secret {
 }
 
 type Service struct {
-	Command            []string                   `json:"command,omitempty" yaml:"command,omitempty"`
+	Build              *ServiceBuild              `json:"build,omitempty" yaml:"build,omitempty"`
+	Command            []string                   `json:"command,omitempty" yaml:"command,omitempty" merge:"replace"`
 	CapabilitiesAdd    []string                   `json:"cap_add,omitempty" yaml:"cap_add,omitempty"`
 	CapabilitiesDrop   []string                   `json:"cap_drop,omitempty" yaml:"cap_drop,omitempty"`
+	Configs            []string                   `json:"configs,omitempty" yaml:"configs,omitempty"`
 	DependsOnContainer *DependsOnContainer        `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
 	Deploy             *ServiceDeploy             `json:"deploy,omitempty" yaml:"deploy,omitempty"`
-	Environments       []string                   `json:"environment,omitempty" yaml:"environment,omitempty"`
+	Entrypoint         []string                   `json:"entrypoint,omitempty" yaml:"entrypoint,omitempty" merge:"replace"`
+	EnvFile            []string                   `json:"env_file,omitempty" yaml:"env_file,omitempty"`
+	Environments       []string                   `json:"environment,omitempty" yaml:"environment,omitempty" merge:"keyed=name"`
+	Extends            *ServiceExtends            `json:"extends,omitempty" yaml:"extends,omitempty" merge:"replace"`
 	ExtraHosts         []string                   `json:"extra_hosts,omitempty" yaml:"extra_hosts,omitempty"`
 	Image              string                     `json:"image,omitempty" yaml:"image,omitempty"`
-	Labels             []string                   `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Labels             []string                   `json:"labels,omitempty" yaml:"labels,omitempty" merge:"keyed=name"`
+	NetworkMode        string                     `json:"network_mode,omitempty" yaml:"network_mode,omitempty"`
 	Networks           map[string]*ServiceNetwork `json:"networks,omitempty" yaml:"networks,omitempty"`
-	Ports              []Port                     `json:"ports,omitempty" yaml:"ports,omitempty"`
+	Ports              []Port                     `json:"ports,omitempty" yaml:"ports,omitempty" merge:"keyed=published" compose:"keyed=mapping"`
 	Secrets            []string                   `json:"secrets,omitempty" yaml:"secrets,omitempty"`
 	ULimits            *ServiceULimits            `json:"ulimits,omitempty" yaml:"ulimits,omitempty"`
-	Volumes            []string                   `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+	Volumes            []string                   `json:"volumes,omitempty" yaml:"volumes,omitempty" merge:"keyed=target"`
+
+	Extensions Extensions `json:"-" yaml:",inline"`
 }
 
 // ExistsEnvironment returns true if the passed name of environment variable is
@@ -594,8 +591,8 @@ func (s *Service) ExistsPort(port string) bool {
 // ExistsVolume returns true if the volume definition is already present.
 func (s *Service) ExistsVolume(src string, dest string, perm string) bool {
 	for _, volume := range s.Volumes {
-		s, d, p := splitStringInVolume(volume)
-		if s == src && d == dest && p == perm {
+		s, d, p, err := splitStringInVolume(volume)
+		if err == nil && s == src && d == dest && p == perm {
 			return true
 		}
 	}
@@ -606,8 +603,8 @@ func (s *Service) ExistsVolume(src string, dest string, perm string) bool {
 // ExistsDestinationVolume returns true if the volume definition is already present.
 func (s *Service) ExistsDestinationVolume(dest string) bool {
 	for _, volume := range s.Volumes {
-		_, d, _ := splitStringInVolume(volume)
-		if d == dest {
+		_, d, _, err := splitStringInVolume(volume)
+		if err == nil && d == dest {
 			return true
 		}
 	}
@@ -618,8 +615,8 @@ func (s *Service) ExistsDestinationVolume(dest string) bool {
 // ExistsSourceVolume returns true if the volume definition is already present.
 func (s *Service) ExistsSourceVolume(src string) bool {
 	for _, volume := range s.Volumes {
-		s, _, _ := splitStringInVolume(volume)
-		if s == src {
+		s, _, _, err := splitStringInVolume(volume)
+		if err == nil && s == src {
 			return true
 		}
 	}
@@ -627,533 +624,55 @@ func (s *Service) ExistsSourceVolume(src string) bool {
 	return false
 }
 
-// Equal returns true if the passed equalable is equal
-func (s *Service) Equal(equalable Equalable) bool {
-	service, ok := equalable.(*Service)
-	if !ok {
-		return false
+// Clone returns a deep copy of the service.
+func (s *Service) Clone() *Service {
+	if s == nil {
+		return nil
 	}
 
-	switch {
-	case s == nil && service == nil:
-		return true
-	case s != nil && service == nil:
-		fallthrough
-	case s == nil && service != nil:
-		return false
-	default:
-		return equalSlice(s.Command, service.Command) &&
-			equalSlice(s.CapabilitiesAdd, service.CapabilitiesAdd) &&
-			equalSlice(s.CapabilitiesDrop, service.CapabilitiesDrop) &&
-			s.DependsOnContainer.Equal(service.DependsOnContainer) &&
-			s.Deploy.Equal(service.Deploy) &&
-			equalSlice(s.Environments, service.Environments) &&
-			equalSlice(s.ExtraHosts, service.ExtraHosts) &&
-			s.Image == service.Image &&
-			equalSlice(s.Labels, service.Labels) &&
-			EqualStringMap(s.Networks, service.Networks) &&
-			equalSlice(s.Ports, service.Ports) &&
-			equalSlice(s.Secrets, service.Secrets) &&
-			s.ULimits.Equal(service.ULimits) &&
-			equalSlice(s.Volumes, service.Volumes)
+	return &Service{
+		Build:              s.Build.Clone(),
+		Command:            cloneSlice(s.Command),
+		CapabilitiesAdd:    cloneSlice(s.CapabilitiesAdd),
+		CapabilitiesDrop:   cloneSlice(s.CapabilitiesDrop),
+		Configs:            cloneSlice(s.Configs),
+		DependsOnContainer: s.DependsOnContainer.Clone(),
+		Deploy:             s.Deploy.Clone(),
+		Entrypoint:         cloneSlice(s.Entrypoint),
+		EnvFile:            cloneSlice(s.EnvFile),
+		Environments:       cloneSlice(s.Environments),
+		Extends:            s.Extends.Clone(),
+		ExtraHosts:         cloneSlice(s.ExtraHosts),
+		Image:              s.Image,
+		Labels:             cloneSlice(s.Labels),
+		NetworkMode:        s.NetworkMode,
+		Networks:           CloneStringMap(s.Networks),
+		Ports:              cloneSlice(s.Ports),
+		Secrets:            cloneSlice(s.Secrets),
+		ULimits:            s.ULimits.Clone(),
+		Volumes:            cloneSlice(s.Volumes),
+		Extensions:         s.Extensions.Clone(),
 	}
 }
 
-func (s *Service) MergeExistingWin(service *Service) {
-	switch {
-	case s == nil && service == nil:
-		fallthrough
-	case s != nil && service == nil:
-		return
+// Equal returns true if the passed equalable is equal
+func (s *Service) Equal(equalable Equalable) bool {
+	service, ok := equalable.(*Service)
+	if !ok {
+		return false
+	}
 
-	// WARN: It's not possible to change the memory pointer s *Service
-	// to a new initialized service without returning the Service
-	// it self.
-	//
-	// case s == nil && service != nil:
-	// 	s = NewService()
-	// 	fallthrough
+	return equalObject(s, service)
+}
 
-	default:
-		s.mergeExistingWinCommand(service.Command)
-		s.mergeExistingWinCapabilitiesAdd(service.CapabilitiesAdd)
-		s.mergeExistingWinCapabilitiesDrop(service.CapabilitiesDrop)
-		s.mergeExistingWinDependsOnContainer(service.DependsOnContainer)
-		s.mergeExistingWinDeploy(service.Deploy)
-		s.mergeExistingWinEnvironments(service.Environments)
-		s.mergeExistingWinExtraHosts(service.ExtraHosts)
-		s.mergeExistingWinImage(service.Image)
-		s.mergeExistingWinLabels(service.Labels)
-		s.mergeExistingWinNetworks(service.Networks)
-		s.mergeExistingWinPorts(service.Ports)
-		s.mergeExistingWinSecrets(service.Secrets)
-		s.mergeExistingWinULimits(service.ULimits)
-		s.mergeExistingWinVolumes(service.Volumes)
-	}
+func (s *Service) MergeExistingWin(service *Service) {
+	mergeObject(s, service, StrategyExistingWin)
 }
 
 // MergeLastWin merges adds or overwrite the attributes of the passed secret
 // with the existing one.
 func (s *Service) MergeLastWin(service *Service) {
-	switch {
-	case s == nil && service == nil:
-		fallthrough
-	case s != nil && service == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer s *Service
-	// to a new initialized service without returning the Service
-	// it self.
-	//
-	// case s == nil && service != nil:
-	// 	s = NewService()
-	// 	fallthrough
-
-	default:
-		s.mergeLastWinCommand(service.Command)
-		s.mergeLastWinCapabilitiesAdd(service.CapabilitiesAdd)
-		s.mergeLastWinCapabilitiesDrop(service.CapabilitiesDrop)
-		s.mergeLastWinDependsOnContainer(service.DependsOnContainer)
-		s.mergeLastWinDeploy(service.Deploy)
-		s.mergeLastWinEnvironments(service.Environments)
-		s.mergeLastWinExtraHosts(service.ExtraHosts)
-		s.mergeLastWinImage(service.Image)
-		s.mergeLastWinLabels(service.Labels)
-		s.mergeLastWinNetworks(service.Networks)
-		s.mergeLastWinPorts(service.Ports)
-		s.mergeLastWinSecrets(service.Secrets)
-		s.mergeLastWinULimits(service.ULimits)
-		s.mergeLastWinVolumes(service.Volumes)
-	}
-}
-
-func (s *Service) mergeExistingWinCommand(command []string) {
-	if len(s.Command) > 0 {
-		return
-	}
-	s.Command = command
-}
-
-func (s *Service) mergeExistingWinCapabilitiesAdd(capabilitiesAdd []string) {
-	for _, capabilityAdd := range capabilitiesAdd {
-		if !existsInSlice(s.CapabilitiesAdd, capabilityAdd) && len(capabilityAdd) > 0 {
-			s.CapabilitiesAdd = append(s.CapabilitiesAdd, capabilityAdd)
-		}
-	}
-}
-
-func (s *Service) mergeExistingWinCapabilitiesDrop(capabilitiesDrop []string) {
-	for _, capabilityDrop := range capabilitiesDrop {
-		if !existsInSlice(s.CapabilitiesAdd, capabilityDrop) && len(capabilityDrop) > 0 {
-			s.CapabilitiesDrop = append(s.CapabilitiesDrop, capabilityDrop)
-		}
-	}
-}
-
-func (s *Service) mergeExistingWinDependsOnContainer(dependsOnContainer *DependsOnContainer) {
-	switch {
-	case s.DependsOnContainer != nil && dependsOnContainer == nil:
-		fallthrough
-	case s.DependsOnContainer == nil && dependsOnContainer == nil:
-		return
-	case s.DependsOnContainer == nil && dependsOnContainer != nil:
-		s.DependsOnContainer = dependsOnContainer
-	default:
-		for name, depOn := range dependsOnContainer.DependsOn {
-			if !ExistsInMap(s.DependsOnContainer.DependsOn, name) && depOn != nil {
-				if s.DependsOnContainer.DependsOn == nil {
-					s.DependsOnContainer.DependsOn = make(map[string]*ServiceDependsOn)
-				}
-				s.DependsOnContainer.DependsOn[name] = depOn
-			}
-		}
-	}
-}
-
-func (s *Service) mergeExistingWinDeploy(deploy *ServiceDeploy) {
-	switch {
-	case s.Deploy == nil && deploy != nil:
-		s.Deploy = deploy
-	case s.Deploy != nil && deploy == nil:
-		fallthrough
-	case s.Deploy == nil && deploy == nil:
-		return
-	default:
-		s.Deploy.MergeExistingWin(deploy)
-	}
-}
-
-func (s *Service) mergeExistingWinEnvironments(environments []string) {
-	switch {
-	case s.Environments == nil && environments != nil:
-		s.Environments = environments
-	case s.Environments != nil && environments == nil:
-		fallthrough
-	case s.Environments == nil && environments == nil:
-		return
-	default:
-		for _, environment := range environments {
-			if len(environment) <= 0 {
-				continue
-			}
-
-			key, value := splitStringInKeyValue(environment, environmentDelimiter)
-			if !s.ExistsEnvironment(key) {
-				s.SetEnvironment(key, value)
-			}
-		}
-	}
-}
-
-func (s *Service) mergeExistingWinImage(image string) {
-	switch {
-	case len(s.Image) == 0 && len(image) != 0:
-		s.Image = image
-	case len(s.Image) != 0 && len(image) == 0:
-		fallthrough
-	case len(s.Image) == 0 && len(image) == 0:
-		fallthrough
-	default:
-		return
-	}
-}
-
-func (s *Service) mergeExistingWinExtraHosts(extraHosts []string) {
-	for _, extraHost := range extraHosts {
-		if !existsInSlice(s.ExtraHosts, extraHost) && len(extraHost) > 0 {
-			s.ExtraHosts = append(s.ExtraHosts, extraHost)
-		}
-	}
-}
-
-func (s *Service) mergeExistingWinLabels(labels []string) {
-	switch {
-	case s.Labels == nil && labels != nil:
-		s.Labels = labels
-	case s.Labels != nil && labels == nil:
-		fallthrough
-	case s.Labels == nil && labels == nil:
-		return
-	default:
-		for _, label := range labels {
-			if len(label) <= 0 {
-				continue
-			}
-
-			key, value := splitStringInKeyValue(label, labelDelimiter)
-			if !s.ExistsLabel(key) {
-				s.SetLabel(key, value)
-			}
-		}
-	}
-}
-
-func (s *Service) mergeExistingWinNetworks(networks map[string]*ServiceNetwork) {
-	switch {
-	case s.Networks == nil && networks != nil:
-		s.Networks = networks
-	case s.Networks != nil && networks == nil:
-		fallthrough
-	case s.Networks == nil && networks == nil:
-		return
-	default:
-		for name, network := range networks {
-			if _, exists := s.Networks[name]; exists {
-				s.Networks[name].MergeExistingWin(network)
-			} else {
-				s.Networks[name] = network
-			}
-		}
-	}
-}
-
-func (s *Service) mergeExistingWinPorts(ports []Port) {
-	switch {
-	case s.Ports == nil && ports != nil:
-		s.Ports = ports
-	case s.Ports != nil && ports == nil:
-		fallthrough
-	case s.Ports == nil && ports == nil:
-		return
-	default:
-	LOOP:
-		for i := range ports {
-			if len(ports[i]) <= 0 {
-				continue LOOP
-			}
-
-			newPort := Port(ports[i])
-
-			for j := range s.Ports {
-				existingPort := Port(s.Ports[j])
-				switch {
-				case newPort.existsSrcIP() && existingPort.existsSrcIP() &&
-					newPort.getSrc() == existingPort.getSrc():
-					continue LOOP
-				case !newPort.existsSrcIP() && existingPort.existsSrcIP() &&
-					newPort.getSrcPort() == existingPort.getSrcPort():
-					continue LOOP
-				case newPort.existsSrcIP() && !existingPort.existsSrcIP() &&
-					newPort.getSrcPort() == existingPort.getSrcPort():
-					continue LOOP
-				case !newPort.existsSrcIP() && !existingPort.existsSrcIP() &&
-					newPort.getSrcPort() == existingPort.getSrcPort():
-					continue LOOP
-				}
-			}
-
-			s.Ports = append(s.Ports, ports[i])
-		}
-	}
-}
-
-func (s *Service) mergeExistingWinSecrets(secrets []string) {
-	for _, secret := range secrets {
-		if !existsInSlice(s.Secrets, secret) && len(secret) > 0 {
-			s.Secrets = append(s.Secrets, secret)
-		}
-	}
-}
-
-func (s *Service) mergeExistingWinULimits(uLimits *ServiceULimits) {
-	switch {
-	case s.ULimits == nil && uLimits != nil:
-		s.ULimits = uLimits
-	case s.ULimits != nil && uLimits == nil:
-		fallthrough
-	case s.ULimits == nil && uLimits == nil:
-		return
-	default:
-		s.ULimits.MergeExistingWin(uLimits)
-	}
-}
-
-func (s *Service) mergeExistingWinVolumes(volumes []string) {
-	switch {
-	case s.Volumes == nil && volumes != nil:
-		s.Volumes = volumes
-	case s.Volumes != nil && volumes == nil:
-		fallthrough
-	case s.Volumes == nil && volumes == nil:
-		return
-	default:
-		for _, volume := range volumes {
-			src, dest, perm := splitStringInVolume(volume)
-			if !s.ExistsDestinationVolume(dest) {
-				s.SetVolume(src, dest, perm)
-			}
-		}
-	}
-}
-
-func (s *Service) mergeLastWinCommand(command []string) {
-	if len(command) > 0 {
-		s.Command = command
-	}
-}
-
-func (s *Service) mergeLastWinCapabilitiesAdd(capabilitiesAdd []string) {
-	for _, capabilityAdd := range capabilitiesAdd {
-		if len(capabilityAdd) <= 0 {
-			continue
-		}
-
-		if !existsInSlice(s.CapabilitiesAdd, capabilityAdd) {
-			s.CapabilitiesAdd = append(s.CapabilitiesAdd, capabilityAdd)
-		}
-	}
-}
-
-func (s *Service) mergeLastWinCapabilitiesDrop(capabilitiesDrop []string) {
-	for _, capabilityDrop := range capabilitiesDrop {
-		if len(capabilityDrop) <= 0 {
-			continue
-		}
-
-		if !existsInSlice(s.CapabilitiesDrop, capabilityDrop) {
-			s.CapabilitiesDrop = append(s.CapabilitiesDrop, capabilityDrop)
-		}
-	}
-}
-
-func (s *Service) mergeLastWinDependsOnContainer(dependsOnContainer *DependsOnContainer) {
-	switch {
-	case s.DependsOnContainer != nil && dependsOnContainer == nil:
-		fallthrough
-	case s.DependsOnContainer == nil && dependsOnContainer == nil:
-		return
-	case s.DependsOnContainer == nil && dependsOnContainer != nil:
-		s.DependsOnContainer = dependsOnContainer
-	default:
-		for name, depOn := range dependsOnContainer.DependsOn {
-			if s.DependsOnContainer.DependsOn == nil {
-				s.DependsOnContainer.DependsOn = make(map[string]*ServiceDependsOn)
-			}
-			s.DependsOnContainer.DependsOn[name] = depOn
-		}
-	}
-}
-
-func (s *Service) mergeLastWinDeploy(deploy *ServiceDeploy) {
-	switch {
-	case s.Deploy == nil && deploy != nil:
-		s.Deploy = deploy
-	case s.Deploy != nil && deploy == nil:
-		fallthrough
-	case s.Deploy == nil && deploy == nil:
-		return
-	default:
-		s.Deploy.MergeLastWin(deploy)
-	}
-}
-
-func (s *Service) mergeLastWinEnvironments(environments []string) {
-	switch {
-	case s.Environments == nil && environments != nil:
-		s.Environments = environments
-	case s.Environments != nil && environments == nil:
-		fallthrough
-	case s.Environments == nil && environments == nil:
-		return
-	default:
-		for _, environment := range environments {
-			if len(environment) <= 0 {
-				continue
-			}
-
-			key, value := splitStringInKeyValue(environment, environmentDelimiter)
-			s.SetEnvironment(key, value)
-		}
-	}
-}
-
-func (s *Service) mergeLastWinImage(image string) {
-	switch {
-	case len(s.Image) == 0 && len(image) != 0:
-		s.Image = image
-	case len(s.Image) != 0 && len(image) == 0:
-		fallthrough
-	case len(s.Image) == 0 && len(image) == 0:
-		return
-	default:
-		if s.Image != image {
-			s.Image = image
-		}
-	}
-}
-
-func (s *Service) mergeLastWinExtraHosts(extraHosts []string) {
-	for _, extraHost := range extraHosts {
-		if len(extraHost) <= 0 {
-			continue
-		}
-
-		if !existsInSlice(s.ExtraHosts, extraHost) {
-			s.ExtraHosts = append(s.ExtraHosts, extraHost)
-		}
-	}
-}
-
-func (s *Service) mergeLastWinLabels(labels []string) {
-	switch {
-	case s.Labels == nil && labels != nil:
-		s.Labels = labels
-	case s.Labels != nil && labels == nil:
-		fallthrough
-	case s.Labels == nil && labels == nil:
-		return
-	default:
-		for _, label := range labels {
-			if len(label) <= 0 {
-				continue
-			}
-
-			key, value := splitStringInKeyValue(label, labelDelimiter)
-			s.SetLabel(key, value)
-		}
-	}
-}
-
-func (s *Service) mergeLastWinNetworks(networks map[string]*ServiceNetwork) {
-	switch {
-	case s.Networks == nil && networks != nil:
-		s.Networks = networks
-	case s.Networks != nil && networks == nil:
-		fallthrough
-	case s.Networks == nil && networks == nil:
-		return
-	default:
-		for name, network := range networks {
-			if _, exists := s.Networks[name]; exists {
-				s.Networks[name].MergeLastWin(network)
-			} else {
-				s.Networks[name] = network
-			}
-		}
-	}
-}
-
-func (s *Service) mergeLastWinPorts(ports []Port) {
-	switch {
-	case s.Ports == nil && ports != nil:
-		s.Ports = ports
-	case s.Ports != nil && ports == nil:
-		fallthrough
-	case s.Ports == nil && ports == nil:
-		return
-	default:
-		for i := range ports {
-			if len(ports[i]) <= 0 {
-				continue
-			}
-			s.SetPort(string(ports[i]))
-		}
-	}
-}
-
-func (s *Service) mergeLastWinSecrets(secrets []string) {
-	for _, secret := range secrets {
-		if len(secret) <= 0 {
-			continue
-		}
-
-		if !existsInSlice(s.Secrets, secret) {
-			s.Secrets = append(s.Secrets, secret)
-		}
-	}
-}
-
-func (s *Service) mergeLastWinULimits(uLimits *ServiceULimits) {
-	switch {
-	case s.ULimits == nil && uLimits != nil:
-		s.ULimits = uLimits
-	case s.ULimits != nil && uLimits == nil:
-		fallthrough
-	case s.ULimits == nil && uLimits == nil:
-		return
-	default:
-		s.ULimits.MergeLastWin(uLimits)
-	}
-}
-
-func (s *Service) mergeLastWinVolumes(volumes []string) {
-	switch {
-	case s.Volumes == nil && volumes != nil:
-		s.Volumes = volumes
-	case s.Volumes != nil && volumes == nil:
-		fallthrough
-	case s.Volumes == nil && volumes == nil:
-		return
-	default:
-		for _, volume := range volumes {
-			if len(volume) <= 0 {
-				continue
-			}
-
-			src, dest, perm := splitStringInVolume(volume)
-			s.SetVolume(src, dest, perm)
-		}
-	}
+	mergeObject(s, service, StrategyLastWin)
 }
 
 // RemoveEnvironment remove all found environment variable from the internal
@@ -1225,14 +744,11 @@ func (s *Service) RemovePortBySrc(src string) {
 func (s *Service) RemoveVolume(dest string) {
 	volumes := make([]string, 0)
 	for _, volume := range s.Volumes {
-		srcPath, destPath, perm := splitStringInVolume(volume)
-
-		switch {
-		case destPath == dest && len(perm) <= 0:
-			s.Volumes = append(s.Volumes, fmt.Sprintf("%s%s%s", srcPath, volumeDelimiter, destPath))
-		case destPath == dest && len(perm) > 0:
-			s.Volumes = append(s.Volumes, fmt.Sprintf("%s%s%s%s%s", srcPath, volumeDelimiter, destPath, volumeDelimiter, perm))
+		_, destPath, _, err := splitStringInVolume(volume)
+		if err == nil && destPath == dest {
+			continue
 		}
+		volumes = append(volumes, volume)
 	}
 	s.Volumes = volumes
 }
@@ -1264,9 +780,12 @@ func (s *Service) SetPort(port string) {
 // SetVolume add or overwrite an existing volume.
 func (s *Service) SetVolume(src string, dest string, perm string) {
 	s.RemoveVolume(dest)
-	if len(perm) <= 0 {
+	switch {
+	case len(src) <= 0:
+		s.Volumes = append(s.Volumes, dest)
+	case len(perm) <= 0:
 		s.Volumes = append(s.Volumes, fmt.Sprintf("%s%s%s", src, volumeDelimiter, dest))
-	} else {
+	default:
 		s.Volumes = append(s.Volumes, fmt.Sprintf("%s%s%s%s%s", src, volumeDelimiter, dest, volumeDelimiter, perm))
 	}
 }
@@ -1277,12 +796,23 @@ const (
 	ServiceDependsOnConditionServiceStarted               string = "service_started"
 )
 
-// DependsOnContainer is a wrapper to handle different YAML type formats of DependsOn.
+// DependsOnContainer is a wrapper to handle different YAML type formats of DependsOn. The short syntax is decoded into
+// dependencies with the condition service_started and derived again from the dependencies, when it is marshaled.
 type DependsOnContainer struct {
-	Slice     []string
 	DependsOn map[string]*ServiceDependsOn
 }
 
+// Clone returns a deep copy of the dependencies.
+func (sdoc *DependsOnContainer) Clone() *DependsOnContainer {
+	if sdoc == nil {
+		return nil
+	}
+
+	return &DependsOnContainer{
+		DependsOn: CloneStringMap(sdoc.DependsOn),
+	}
+}
+
 // Equal returns true if the passed equalable is equal
 func (sdoc *DependsOnContainer) Equal(equalable Equalable) bool {
 	serviceDependsOnContainer, ok := equalable.(*DependsOnContainer)
@@ -1290,32 +820,32 @@ func (sdoc *DependsOnContainer) Equal(equalable Equalable) bool {
 		return false
 	}
 
-	switch {
-	case sdoc == nil && serviceDependsOnContainer == nil:
-		return true
-	case sdoc != nil && serviceDependsOnContainer == nil:
-		fallthrough
-	case sdoc == nil && serviceDependsOnContainer != nil:
-		return false
-	default:
-		return equalSlice(sdoc.Slice, serviceDependsOnContainer.Slice) &&
-			EqualStringMap(sdoc.DependsOn, serviceDependsOnContainer.DependsOn)
-	}
+	return equalObject(sdoc, serviceDependsOnContainer)
+}
+
+// MarshalJSON implements the json.Marshaler interface. The dependencies are marshaled in the long syntax of the YAML
+// document, so that the paths of the YAML document refer to the same attributes of the JSON representation.
+func (sdoc *DependsOnContainer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sdoc.DependsOn)
 }
 
 // MarshalYAML implements the MarshalYAML interface to customize the behavior when being marshaled into a YAML document.
+// The dependencies are only collapsed into the short syntax, when no attribute gets lost. Otherwise the long syntax is
+// used.
 func (sdoc *DependsOnContainer) MarshalYAML() (interface{}, error) {
 	var foundAnotherCondition = false
 	var dependencyNames = make([]string, 0)
 
 	for dependencyName, dependencyDefinition := range sdoc.DependsOn {
-		if dependencyDefinition.Condition == ServiceDependsOnConditionServiceStarted {
+		if dependencyDefinition.isShortSyntax() {
 			dependencyNames = append(dependencyNames, dependencyName)
 			continue
 		}
 		foundAnotherCondition = true
 	}
 
+	sort.Strings(dependencyNames)
+
 	switch {
 	case foundAnotherCondition:
 		return sdoc.DependsOn, nil
@@ -1333,13 +863,10 @@ func (sdoc *DependsOnContainer) UnmarshalYAML(value *yaml.Node) error {
 		sdoc.DependsOn = make(map[string]*ServiceDependsOn)
 	}
 
-	if sdoc.Slice == nil {
-		sdoc.Slice = make([]string, 0)
-	}
-
-	if err := value.Decode(&sdoc.Slice); err == nil {
-		for _, s := range sdoc.Slice {
-			sdoc.DependsOn[s] = &ServiceDependsOn{
+	dependencyNames := make([]string, 0)
+	if err := value.Decode(&dependencyNames); err == nil {
+		for _, dependencyName := range dependencyNames {
+			sdoc.DependsOn[dependencyName] = &ServiceDependsOn{
 				Condition: ServiceDependsOnConditionServiceStarted,
 			}
 		}
@@ -1353,11 +880,66 @@ func (sdoc *DependsOnContainer) UnmarshalYAML(value *yaml.Node) error {
 	return nil
 }
 
+// UnmarshalYAML implements the UnmarshalYAML interface to customize the behavior when being unmarshaled into a YAML
+// document. The attribute env_file can be declared as single string or as list of strings. The attributes command and
+// entrypoint can be declared as string, which is split into its words like by a shell. The strings are replaced by
+// lists in a copy of the node, the passed node is kept as declared.
+func (s *Service) UnmarshalYAML(value *yaml.Node) error {
+	copiedValue := *value
+	copiedValue.Content = append([]*yaml.Node(nil), value.Content...)
+	value = &copiedValue
+
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		keyNode, valueNode := value.Content[i], value.Content[i+1]
+		if valueNode.Kind != yaml.ScalarNode || valueNode.Tag == "!!null" {
+			continue
+		}
+
+		switch keyNode.Value {
+		case "command", "entrypoint":
+			words, err := splitShellWords(valueNode.Value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", keyNode.Value, err)
+			}
+
+			sequenceNode := &yaml.Node{
+				Kind:    yaml.SequenceNode,
+				Tag:     "!!seq",
+				Content: make([]*yaml.Node, 0, len(words)),
+				Line:    valueNode.Line,
+				Column:  valueNode.Column,
+			}
+			for _, word := range words {
+				sequenceNode.Content = append(sequenceNode.Content, &yaml.Node{
+					Kind:   yaml.ScalarNode,
+					Tag:    "!!str",
+					Value:  word,
+					Line:   valueNode.Line,
+					Column: valueNode.Column,
+				})
+			}
+			value.Content[i+1] = sequenceNode
+		case "env_file":
+			value.Content[i+1] = &yaml.Node{
+				Kind:    yaml.SequenceNode,
+				Tag:     "!!seq",
+				Content: []*yaml.Node{valueNode},
+				Line:    valueNode.Line,
+				Column:  valueNode.Column,
+			}
+		}
+	}
+
+	type service Service
+	return value.Decode((*service)(s))
+}
+
 // NewService returns an empty initialized Service.
 func NewService() *Service {
 	return &Service{
 		CapabilitiesAdd:  make([]string, 0),
 		CapabilitiesDrop: make([]string, 0),
+		Configs:          make([]string, 0),
 		Deploy:           new(ServiceDeploy),
 		Environments:     make([]string, 0),
 		ExtraHosts:       make([]string, 0),
@@ -1370,9 +952,94 @@ func NewService() *Service {
 	}
 }
 
+type ServiceBuild struct {
+	Context    string `json:"context,omitempty" yaml:"context,omitempty"`
+	Dockerfile string `json:"dockerfile,omitempty" yaml:"dockerfile,omitempty"`
+	Target     string `json:"target,omitempty" yaml:"target,omitempty"`
+
+	Extensions Extensions `json:"-" yaml:",inline"`
+}
+
+// Clone returns a deep copy of the build configuration.
+func (sb *ServiceBuild) Clone() *ServiceBuild {
+	if sb == nil {
+		return nil
+	}
+
+	return &ServiceBuild{
+		Context:    sb.Context,
+		Dockerfile: sb.Dockerfile,
+		Target:     sb.Target,
+		Extensions: sb.Extensions.Clone(),
+	}
+}
+
+// Equal returns true if the passed equalable is equal
+func (sb *ServiceBuild) Equal(equalable Equalable) bool {
+	serviceBuild, ok := equalable.(*ServiceBuild)
+	if !ok {
+		return false
+	}
+
+	return equalObject(sb, serviceBuild)
+}
+
+// MergeExistingWin adds only attributes of the passed serviceBuild if they are undefined.
+func (sb *ServiceBuild) MergeExistingWin(serviceBuild *ServiceBuild) {
+	mergeObject(sb, serviceBuild, StrategyExistingWin)
+}
+
+// MergeLastWin merges adds or overwrite the attributes of the passed serviceBuild with the existing one.
+func (sb *ServiceBuild) MergeLastWin(serviceBuild *ServiceBuild) {
+	mergeObject(sb, serviceBuild, StrategyLastWin)
+}
+
+// MarshalYAML implements the MarshalYAML interface to customize the behavior when being marshaled into a YAML document.
+// The build is collapsed into the short syntax, when only the context is declared.
+func (sb *ServiceBuild) MarshalYAML() (interface{}, error) {
+	if len(sb.Dockerfile) <= 0 && len(sb.Target) <= 0 && len(sb.Extensions) <= 0 {
+		return sb.Context, nil
+	}
+
+	type serviceBuild ServiceBuild
+	return (*serviceBuild)(sb), nil
+}
+
+// UnmarshalYAML implements the UnmarshalYAML interface to customize the behavior when being unmarshaled into a YAML
+// document. The short syntax of build only declares the context.
+func (sb *ServiceBuild) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&sb.Context)
+	}
+
+	type serviceBuild ServiceBuild
+	return value.Decode((*serviceBuild)(sb))
+}
+
+func NewServiceBuild() *ServiceBuild {
+	return &ServiceBuild{}
+}
+
 type ServiceDependsOn struct {
-	Condition string `yaml:"condition,omitempty"`
-	Restart   string `yaml:"restart,omitempty"`
+	Condition string `json:"condition,omitempty" yaml:"condition,omitempty"`
+	Required  *bool  `json:"required,omitempty" yaml:"required,omitempty"`
+	Restart   *bool  `json:"restart,omitempty" yaml:"restart,omitempty"`
+
+	Extensions Extensions `json:"-" yaml:",inline"`
+}
+
+// Clone returns a deep copy of the dependency.
+func (sdo *ServiceDependsOn) Clone() *ServiceDependsOn {
+	if sdo == nil {
+		return nil
+	}
+
+	return &ServiceDependsOn{
+		Condition:  sdo.Condition,
+		Required:   clonePointer(sdo.Required),
+		Restart:    clonePointer(sdo.Restart),
+		Extensions: sdo.Extensions.Clone(),
+	}
 }
 
 // Equal returns true if the passed equalable is equal
@@ -1382,21 +1049,51 @@ func (sdo *ServiceDependsOn) Equal(equalable Equalable) bool {
 		return false
 	}
 
-	switch {
-	case sdo == nil && serviceDependsOn == nil:
-		return true
-	case sdo != nil && serviceDependsOn == nil:
-		fallthrough
-	case sdo == nil && serviceDependsOn != nil:
-		return false
-	default:
-		return sdo.Condition == serviceDependsOn.Condition &&
-			sdo.Restart == serviceDependsOn.Restart
+	return equalObject(sdo, serviceDependsOn)
+}
+
+// MergeExistingWin adds only the attributes of the passed serviceDependsOn if they are undefined.
+func (sdo *ServiceDependsOn) MergeExistingWin(serviceDependsOn *ServiceDependsOn) {
+	mergeObject(sdo, serviceDependsOn, StrategyExistingWin)
+}
+
+// MergeLastWin merges adds or overwrite the attributes of the passed
+// serviceDependsOn with the existing one.
+func (sdo *ServiceDependsOn) MergeLastWin(serviceDependsOn *ServiceDependsOn) {
+	mergeObject(sdo, serviceDependsOn, StrategyLastWin)
+}
+
+// isShortSyntax returns true if the dependency can be declared by the short syntax without losing any attribute.
+func (sdo *ServiceDependsOn) isShortSyntax() bool {
+	return sdo != nil &&
+		(len(sdo.Condition) <= 0 || sdo.Condition == ServiceDependsOnConditionServiceStarted) &&
+		(sdo.Required == nil || *sdo.Required) &&
+		(sdo.Restart == nil || !*sdo.Restart) &&
+		len(sdo.Extensions) <= 0
+}
+
+func NewServiceDependsOn() *ServiceDependsOn {
+	return &ServiceDependsOn{
+		Condition: ServiceDependsOnConditionServiceStarted,
 	}
 }
 
 type ServiceDeploy struct {
-	Resources *ServiceDeployResources `json:"resources" yaml:"resources"`
+	Resources *ServiceDeployResources `json:"resources,omitempty" yaml:"resources,omitempty"`
+
+	Extensions Extensions `json:"-" yaml:",inline"`
+}
+
+// Clone returns a deep copy of the deployment.
+func (sd *ServiceDeploy) Clone() *ServiceDeploy {
+	if sd == nil {
+		return nil
+	}
+
+	return &ServiceDeploy{
+		Resources:  sd.Resources.Clone(),
+		Extensions: sd.Extensions.Clone(),
+	}
 }
 
 // Equal returns true if the passed equalable is equal
@@ -1406,86 +1103,19 @@ func (sd *ServiceDeploy) Equal(equalable Equalable) bool {
 		return false
 	}
 
-	switch {
-	case sd == nil && serviceDeploy == nil:
-		return true
-	case sd != nil && serviceDeploy == nil:
-		fallthrough
-	case sd == nil && serviceDeploy != nil:
-		return false
-	default:
-		return sd.Resources.Equal(serviceDeploy.Resources)
-	}
+	return equalObject(sd, serviceDeploy)
 }
 
 // MergeExistingWin merges adds or overwrite the attributes of the passed
 // serviceDeploy with the existing one.
 func (sd *ServiceDeploy) MergeExistingWin(serviceDeploy *ServiceDeploy) {
-	switch {
-	case sd == nil && serviceDeploy == nil:
-		fallthrough
-	case sd != nil && serviceDeploy == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer sd *ServiceDeploy
-	// to a new initialized serviceDeploy without returning the ServiceDeploy
-	// it self.
-	//
-	// case sd == nil && serviceDeploy != nil:
-	// 	sd = NewServiceDeploy()
-	// 	fallthrough
-
-	default:
-		sd.mergeExistingWinDeployResources(serviceDeploy.Resources)
-	}
+	mergeObject(sd, serviceDeploy, StrategyExistingWin)
 }
 
 // MergeLastWin merges adds or overwrite the attributes of the passed
 // serviceDeploy with the existing one.
 func (sd *ServiceDeploy) MergeLastWin(serviceDeploy *ServiceDeploy) {
-	switch {
-	case sd == nil && serviceDeploy == nil:
-		fallthrough
-	case sd != nil && serviceDeploy == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer sd *ServiceDeploy
-	// to a new initialized serviceDeploy without returning the ServiceDeploy
-	// it self.
-	//
-	// case sd == nil && serviceDeploy != nil:
-	// 	sd = NewServiceDeploy()
-	// 	fallthrough
-
-	default:
-		sd.mergeLastWinDeployResources(serviceDeploy.Resources)
-	}
-}
-
-func (sd *ServiceDeploy) mergeExistingWinDeployResources(resources *ServiceDeployResources) {
-	switch {
-	case sd.Resources == nil && resources != nil:
-		sd.Resources = resources
-	case sd.Resources != nil && resources == nil:
-		fallthrough
-	case sd.Resources == nil && resources == nil:
-		return
-	default:
-		sd.Resources.MergeExistingWin(resources)
-	}
-}
-
-func (sd *ServiceDeploy) mergeLastWinDeployResources(resources *ServiceDeployResources) {
-	switch {
-	case sd.Resources == nil && resources != nil:
-		sd.Resources = resources
-	case sd.Resources != nil && resources == nil:
-		fallthrough
-	case sd.Resources == nil && resources == nil:
-		return
-	default:
-		sd.Resources.MergeLastWin(resources)
-	}
+	mergeObject(sd, serviceDeploy, StrategyLastWin)
 }
 
 func NewServiceDeploy() *ServiceDeploy {
@@ -1497,6 +1127,21 @@ func NewServiceDeploy() *ServiceDeploy {
 type ServiceDeployResources struct {
 	Limits       *ServiceDeployResourcesLimits `json:"limits,omitempty" yaml:"limits,omitempty"`
 	Reservations *ServiceDeployResourcesLimits `json:"reservations,omitempty" yaml:"reservations,omitempty"`
+
+	Extensions Extensions `json:"-" yaml:",inline"`
+}
+
+// Clone returns a deep copy of the resources.
+func (sdr *ServiceDeployResources) Clone() *ServiceDeployResources {
+	if sdr == nil {
+		return nil
+	}
+
+	return &ServiceDeployResources{
+		Limits:       sdr.Limits.Clone(),
+		Reservations: sdr.Reservations.Clone(),
+		Extensions:   sdr.Extensions.Clone(),
+	}
 }
 
 // Equal returns true if the passed equalable is equal
@@ -1506,123 +1151,46 @@ func (sdr *ServiceDeployResources) Equal(equalable Equalable) bool {
 		return false
 	}
 
-	switch {
-	case sdr == nil && serviceDeployResources == nil:
-		return true
-	case sdr != nil && serviceDeployResources == nil:
-		fallthrough
-	case sdr == nil && serviceDeployResources != nil:
-		return false
-	default:
-		return sdr.Limits.Equal(serviceDeployResources.Limits) &&
-			sdr.Reservations.Equal(serviceDeployResources.Reservations)
-	}
+	return equalObject(sdr, serviceDeployResources)
 }
 
 // MergeExistingWin adds only attributes of the passed serviceDeployResources if
 // they are not already exists.
 func (sdr *ServiceDeployResources) MergeExistingWin(serviceDeployResources *ServiceDeployResources) {
-	switch {
-	case sdr == nil && serviceDeployResources == nil:
-		fallthrough
-	case sdr != nil && serviceDeployResources == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer sdr *ServiceDeployResources
-	// to a new initialized serviceDeployResources without returning the
-	// serviceDeployResources it self.
-	case sdr == nil && serviceDeployResources != nil:
-		sdr = NewServiceDeployResources()
-		fallthrough
-	default:
-		sdr.mergeExistingWinLimits(serviceDeployResources.Limits)
-		sdr.mergeExistingWinReservations(serviceDeployResources.Reservations)
-	}
+	mergeObject(sdr, serviceDeployResources, StrategyExistingWin)
 }
 
 // MergeLastWin merges adds or overwrite the attributes of the passed
 // serviceDeployResources with the existing one.
 func (sdr *ServiceDeployResources) MergeLastWin(serviceDeployResources *ServiceDeployResources) {
-	switch {
-	case sdr == nil && serviceDeployResources == nil:
-		fallthrough
-	case sdr != nil && serviceDeployResources == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer sdr *ServiceDeployResources
-	// to a new initialized serviceDeployResources without returning the
-	// serviceDeployResources it self.
-	case sdr == nil && serviceDeployResources != nil:
-		sdr = NewServiceDeployResources()
-		fallthrough
-	default:
-		sdr.mergeLastWinLimits(serviceDeployResources.Limits)
-		sdr.mergeLastWinReservations(serviceDeployResources.Reservations)
-	}
-}
-
-func (sdr *ServiceDeployResources) mergeExistingWinLimits(limits *ServiceDeployResourcesLimits) {
-	switch {
-	case sdr.Limits == nil && limits != nil:
-		sdr.Limits = limits
-	case sdr.Limits != nil && limits == nil:
-		fallthrough
-	case sdr.Limits == nil && limits == nil:
-		return
-	default:
-		sdr.Limits.MergeExistingWin(limits)
-	}
+	mergeObject(sdr, serviceDeployResources, StrategyLastWin)
 }
 
-func (sdr *ServiceDeployResources) mergeExistingWinReservations(reservations *ServiceDeployResourcesLimits) {
-	switch {
-	case sdr.Reservations == nil && reservations != nil:
-		sdr.Reservations = reservations
-	case sdr.Reservations != nil && reservations == nil:
-		fallthrough
-	case sdr.Reservations == nil && reservations == nil:
-		return
-	default:
-		sdr.Reservations.MergeExistingWin(reservations)
+func NewServiceDeployResources() *ServiceDeployResources {
+	return &ServiceDeployResources{
+		Limits:       new(ServiceDeployResourcesLimits),
+		Reservations: new(ServiceDeployResourcesLimits),
 	}
 }
 
-func (sdr *ServiceDeployResources) mergeLastWinLimits(limits *ServiceDeployResourcesLimits) {
-	switch {
-	case sdr.Limits == nil && limits != nil:
-		sdr.Limits = limits
-	case sdr.Limits != nil && limits == nil:
-		fallthrough
-	case sdr.Limits == nil && limits == nil:
-		return
-	default:
-		sdr.Limits.MergeLastWin(limits)
-	}
-}
+type ServiceDeployResourcesLimits struct {
+	CPUs   string `json:"cpus,omitempty" yaml:"cpus,omitempty"`
+	Memory string `json:"memory,omitempty" yaml:"memory,omitempty"`
 
-func (sdr *ServiceDeployResources) mergeLastWinReservations(reservations *ServiceDeployResourcesLimits) {
-	switch {
-	case sdr.Reservations == nil && reservations != nil:
-		sdr.Reservations = reservations
-	case sdr.Reservations != nil && reservations == nil:
-		fallthrough
-	case sdr.Reservations == nil && reservations == nil:
-		return
-	default:
-		sdr.Reservations.MergeLastWin(reservations)
-	}
+	Extensions Extensions `json:"-" yaml:",inline"`
 }
 
-func NewServiceDeployResources() *ServiceDeployResources {
-	return &ServiceDeployResources{
-		Limits:       new(ServiceDeployResourcesLimits),
-		Reservations: new(ServiceDeployResourcesLimits),
+// Clone returns a deep copy of the limits.
+func (sdrl *ServiceDeployResourcesLimits) Clone() *ServiceDeployResourcesLimits {
+	if sdrl == nil {
+		return nil
 	}
-}
 
-type ServiceDeployResourcesLimits struct {
-	CPUs   string `json:"cpus,omitempty" yaml:"cpus,omitempty"`
-	Memory string `json:"memory,omitempty" yaml:"memory,omitempty"`
+	return &ServiceDeployResourcesLimits{
+		CPUs:       sdrl.CPUs,
+		Memory:     sdrl.Memory,
+		Extensions: sdrl.Extensions.Clone(),
+	}
 }
 
 // Equal returns true if the passed equalable is equal
@@ -1632,95 +1200,88 @@ func (sdrl *ServiceDeployResourcesLimits) Equal(equalable Equalable) bool {
 		return false
 	}
 
-	switch {
-	case sdrl == nil && serviceDeployResourcesLimits == nil:
-		return true
-	case sdrl != nil && serviceDeployResourcesLimits == nil:
-		fallthrough
-	case sdrl == nil && serviceDeployResourcesLimits != nil:
-		return false
-	default:
-		return sdrl.CPUs == serviceDeployResourcesLimits.CPUs &&
-			sdrl.Memory == serviceDeployResourcesLimits.Memory
-	}
+	return equalObject(sdrl, serviceDeployResourcesLimits)
 }
 
 // MergeExistingWin adds only attributes of the passed serviceDeployResourcesLimits
 // if they are not already exists.
 func (sdrl *ServiceDeployResourcesLimits) MergeExistingWin(serviceDeployResourcesLimits *ServiceDeployResourcesLimits) {
-	switch {
-	case sdrl == nil && serviceDeployResourcesLimits == nil:
-		fallthrough
-	case sdrl != nil && serviceDeployResourcesLimits == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer sdrl *ServiceDeployResourcesLimits
-	// to a new initialized serviceDeployResourcesLimits without returning the
-	// serviceDeployResourcesLimits it self.
-	//
-	// case sdrl == nil && serviceDeployResourcesLimits != nil:
-	// 	sdrl = NewServiceDeployResourcesLimits()
-	// 	fallthrough
-
-	default:
-		sdrl.mergeExistingWinCPUs(serviceDeployResourcesLimits.CPUs)
-		sdrl.mergeExistingWinMemory(serviceDeployResourcesLimits.Memory)
-	}
+	mergeObject(sdrl, serviceDeployResourcesLimits, StrategyExistingWin)
 }
 
 // MergeLastWin merges adds or overwrite the attributes of the passed
 // serviceDeployResourcesLimits with the existing one.
 func (sdrl *ServiceDeployResourcesLimits) MergeLastWin(serviceDeployResourcesLimits *ServiceDeployResourcesLimits) {
-	switch {
-	case sdrl == nil && serviceDeployResourcesLimits == nil:
-		fallthrough
-	case sdrl != nil && serviceDeployResourcesLimits == nil:
-		return
+	mergeObject(sdrl, serviceDeployResourcesLimits, StrategyLastWin)
+}
 
-	// WARN: It's not possible to change the memory pointer sdrl *ServiceDeployResourcesLimits
-	// to a new initialized serviceDeployResourcesLimits without returning the
-	// serviceDeployResourcesLimits it self.
-	//
-	// case sdrl == nil && serviceDeployResourcesLimits != nil:
-	// 	sdrl = NewServiceDeployResourcesLimits()
-	// 	fallthrough
+func NewServiceDeployResourcesLimits() *ServiceDeployResourcesLimits {
+	return &ServiceDeployResourcesLimits{}
+}
 
-	default:
-		sdrl.mergeLastWinCPUs(serviceDeployResourcesLimits.CPUs)
-		sdrl.mergeLastWinMemory(serviceDeployResourcesLimits.Memory)
-	}
+// ServiceExtends references the service, which is used as base of the extending service. When File is empty, the
+// service is looked up in the same docker-compose file.
+type ServiceExtends struct {
+	File    string `json:"file,omitempty" yaml:"file,omitempty"`
+	Service string `json:"service,omitempty" yaml:"service,omitempty"`
+
+	Extensions Extensions `json:"-" yaml:",inline"`
 }
 
-func (sdrl *ServiceDeployResourcesLimits) mergeExistingWinCPUs(cpus string) {
-	if len(sdrl.CPUs) <= 0 {
-		sdrl.CPUs = cpus
+// Clone returns a deep copy of the extends reference.
+func (se *ServiceExtends) Clone() *ServiceExtends {
+	if se == nil {
+		return nil
 	}
-}
 
-func (sdrl *ServiceDeployResourcesLimits) mergeExistingWinMemory(memory string) {
-	if len(sdrl.Memory) <= 0 {
-		sdrl.Memory = memory
+	return &ServiceExtends{
+		File:       se.File,
+		Service:    se.Service,
+		Extensions: se.Extensions.Clone(),
 	}
 }
 
-func (sdrl *ServiceDeployResourcesLimits) mergeLastWinCPUs(cpus string) {
-	if sdrl.CPUs != cpus {
-		sdrl.CPUs = cpus
+// Equal returns true if the passed equalable is equal
+func (se *ServiceExtends) Equal(equalable Equalable) bool {
+	serviceExtends, ok := equalable.(*ServiceExtends)
+	if !ok {
+		return false
 	}
+
+	return equalObject(se, serviceExtends)
 }
 
-func (sdrl *ServiceDeployResourcesLimits) mergeLastWinMemory(memory string) {
-	if sdrl.Memory != memory {
-		sdrl.Memory = memory
+// UnmarshalYAML implements the UnmarshalYAML interface to customize the behavior when being unmarshaled into a YAML
+// document. The short syntax of extends only references a service name of the same docker-compose file.
+func (se *ServiceExtends) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&se.Service)
 	}
+
+	type serviceExtends ServiceExtends
+	return value.Decode((*serviceExtends)(se))
 }
 
-func NewServiceDeployResourcesLimits() *ServiceDeployResourcesLimits {
-	return &ServiceDeployResourcesLimits{}
+func NewServiceExtends() *ServiceExtends {
+	return &ServiceExtends{}
 }
 
 type ServiceNetwork struct {
 	Aliases []string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+
+	Extensions Extensions `json:"-" yaml:",inline"`
+}
+
+// Clone returns a deep copy of the service network.
+func (sn *ServiceNetwork) Clone() *ServiceNetwork {
+	if sn == nil {
+		return nil
+	}
+
+	return &ServiceNetwork{
+		Aliases:    cloneSlice(sn.Aliases),
+		Extensions: sn.Extensions.Clone(),
+	}
 }
 
 // Equal returns true if the passed equalable is equal
@@ -1730,80 +1291,19 @@ func (sn *ServiceNetwork) Equal(equalable Equalable) bool {
 		return false
 	}
 
-	switch {
-	case sn == nil && serviceNetwork == nil:
-		return true
-	case sn != nil && serviceNetwork == nil:
-		fallthrough
-	case sn == nil && serviceNetwork != nil:
-		return false
-	default:
-		return equalSlice(sn.Aliases, serviceNetwork.Aliases)
-	}
+	return equalObject(sn, serviceNetwork)
 }
 
 // MergeExistingWin adds only attributes of the passed
 // serviceNetwork if they are undefined.
 func (sn *ServiceNetwork) MergeExistingWin(serviceNetwork *ServiceNetwork) {
-	switch {
-	case sn == nil && serviceNetwork == nil:
-		fallthrough
-	case sn != nil && serviceNetwork == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer sn *ServiceNetwork to a new
-	// initialized ServiceNetwork without returning the serviceNetwork it self.
-	//
-	// case l == nil && serviceULimits != nil:
-	// 	l = NewServiceULimits()
-	// 	fallthrough
-
-	case sn == nil && serviceNetwork != nil:
-		sn = NewServiceNetwork()
-		fallthrough
-	default:
-		sn.mergeExistingWinAliases(serviceNetwork.Aliases)
-	}
+	mergeObject(sn, serviceNetwork, StrategyExistingWin)
 }
 
 // MergeLastWin merges adds or overwrite the attributes of the passed
 // serviceNetwork with the existing one.
 func (sn *ServiceNetwork) MergeLastWin(serviceNetwork *ServiceNetwork) {
-	switch {
-	case sn == nil && serviceNetwork == nil:
-		fallthrough
-	case sn != nil && serviceNetwork == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer sn *ServiceNetwork to a new
-	// initialized ServiceNetwork without returning the serviceNetwork it self.
-	//
-	// case l == nil && serviceULimits != nil:
-	// 	l = NewServiceULimits()
-	// 	fallthrough
-
-	case sn == nil && serviceNetwork != nil:
-		sn = NewServiceNetwork()
-		fallthrough
-	default:
-		sn.mergeLastWinAliases(serviceNetwork.Aliases)
-	}
-}
-
-func (sn *ServiceNetwork) mergeExistingWinAliases(aliases []string) {
-	for _, alias := range aliases {
-		if !existsInSlice(sn.Aliases, alias) && len(alias) > 0 {
-			sn.Aliases = append(sn.Aliases, alias)
-		}
-	}
-}
-
-func (sn *ServiceNetwork) mergeLastWinAliases(aliases []string) {
-	for _, alias := range aliases {
-		if !existsInSlice(sn.Aliases, alias) && len(alias) > 0 {
-			sn.Aliases = append(sn.Aliases, alias)
-		}
-	}
+	mergeObject(sn, serviceNetwork, StrategyLastWin)
 }
 
 func NewServiceNetwork() *ServiceNetwork {
@@ -1814,7 +1314,22 @@ func NewServiceNetwork() *ServiceNetwork {
 
 type ServiceULimits struct {
 	NProc  uint                  `json:"nproc,omitempty" yaml:"nproc,omitempty"`
-	NoFile *ServiceULimitsNoFile `json:"nofile,omitempty" yaml:"nofile,omitempty"`
+	NoFile *ServiceULimitsNoFile `json:"nofile,omitempty" yaml:"nofile,omitempty" compose:"replace"`
+
+	Extensions Extensions `json:"-" yaml:",inline"`
+}
+
+// Clone returns a deep copy of the ulimits.
+func (l *ServiceULimits) Clone() *ServiceULimits {
+	if l == nil {
+		return nil
+	}
+
+	return &ServiceULimits{
+		NProc:      l.NProc,
+		NoFile:     l.NoFile.Clone(),
+		Extensions: l.Extensions.Clone(),
+	}
 }
 
 // Equal returns true if the passed equalable is equal
@@ -1824,86 +1339,19 @@ func (l *ServiceULimits) Equal(equalable Equalable) bool {
 		return false
 	}
 
-	switch {
-	case l == nil && serviceULimits == nil:
-		return true
-	case l != nil && serviceULimits == nil:
-		fallthrough
-	case l == nil && serviceULimits != nil:
-		return false
-	default:
-		return l.NProc == serviceULimits.NProc &&
-			l.NoFile.Equal(serviceULimits.NoFile)
-	}
+	return equalObject(l, serviceULimits)
 }
 
 // MergeExistingWin adds only the attributes of the passed ServiceULimits they are
 // undefined.
 func (l *ServiceULimits) MergeExistingWin(serviceULimits *ServiceULimits) {
-	switch {
-	case l == nil && serviceULimits == nil:
-		fallthrough
-	case l != nil && serviceULimits == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer l *ServiceULimits to a new
-	// initialized ServiceULimits without returning the serviceULimits it self.
-	//
-	// case l == nil && serviceULimits != nil:
-	// 	l = NewServiceULimits()
-	// 	fallthrough
-
-	default:
-		l.mergeExistingWinNProc(serviceULimits.NProc)
-		l.mergeExistingWinNoFile(serviceULimits.NoFile)
-	}
+	mergeObject(l, serviceULimits, StrategyExistingWin)
 }
 
 // MergeLastWin merges adds or overwrite the attributes of the passed
 // ServiceULimits with the existing one.
 func (l *ServiceULimits) MergeLastWin(serviceULimits *ServiceULimits) {
-	switch {
-	case l == nil && serviceULimits == nil:
-		fallthrough
-	case l != nil && serviceULimits == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer l *ServiceULimits to a new
-	// initialized ServiceULimits without returning the serviceULimits it self.
-	//
-	// case l == nil && serviceULimits != nil:
-	// 	l = NewServiceULimits()
-	// 	fallthrough
-
-	default:
-		l.mergeLastWinNProc(serviceULimits.NProc)
-		l.mergeLastWinNoFile(serviceULimits.NoFile)
-	}
-}
-
-func (l *ServiceULimits) mergeExistingWinNProc(nproc uint) {
-	if l.NProc != nproc {
-		return
-	}
-	l.NProc = nproc
-}
-
-func (l *ServiceULimits) mergeExistingWinNoFile(noFile *ServiceULimitsNoFile) {
-	if !l.NoFile.Equal(noFile) {
-		l.NoFile.MergeExistingWin(noFile)
-	}
-}
-
-func (l *ServiceULimits) mergeLastWinNProc(nproc uint) {
-	if l.NProc != nproc {
-		l.NProc = nproc
-	}
-}
-
-func (l *ServiceULimits) mergeLastWinNoFile(noFile *ServiceULimitsNoFile) {
-	if !l.NoFile.Equal(noFile) {
-		l.NoFile.MergeLastWin(noFile)
-	}
+	mergeObject(l, serviceULimits, StrategyLastWin)
 }
 
 func NewServiceULimits() *ServiceULimits {
@@ -1915,6 +1363,21 @@ func NewServiceULimits() *ServiceULimits {
 type ServiceULimitsNoFile struct {
 	Hard uint `json:"hard" yaml:"hard"`
 	Soft uint `json:"soft" yaml:"soft"`
+
+	Extensions Extensions `json:"-" yaml:",inline"`
+}
+
+// Clone returns a deep copy of the nofile ulimit.
+func (nf *ServiceULimitsNoFile) Clone() *ServiceULimitsNoFile {
+	if nf == nil {
+		return nil
+	}
+
+	return &ServiceULimitsNoFile{
+		Hard:       nf.Hard,
+		Soft:       nf.Soft,
+		Extensions: nf.Extensions.Clone(),
+	}
 }
 
 // Equal returns true if the passed equalable is equal
@@ -1924,97 +1387,41 @@ func (nf *ServiceULimitsNoFile) Equal(equalable Equalable) bool {
 		return false
 	}
 
-	switch {
-	case nf == nil && serviceULimitsNoFile == nil:
-		return true
-	case nf != nil && serviceULimitsNoFile == nil:
-		fallthrough
-	case nf == nil && serviceULimitsNoFile != nil:
-		return false
-	default:
-		return nf.Hard == serviceULimitsNoFile.Hard &&
-			nf.Soft == serviceULimitsNoFile.Soft
-	}
+	return equalObject(nf, serviceULimitsNoFile)
 }
 
 // MergeExistingWin adds only the attributes of the passed ServiceULimits they are
 // undefined.
 func (nf *ServiceULimitsNoFile) MergeExistingWin(serviceULimitsNoFile *ServiceULimitsNoFile) {
-	switch {
-	case nf == nil && serviceULimitsNoFile == nil:
-		fallthrough
-	case nf != nil && serviceULimitsNoFile == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer nf *ServiceULimitsNoFile
-	// to a new initialized ServiceULimitsNoFile without returning the serviceULimitsNoFile
-	// it self.
-	//
-	// case nf == nil && serviceULimitsNoFile != nil:
-	// 	nf = NewServiceULimitsNoFile()
-	// 	fallthrough
-
-	default:
-		nf.mergeExistingWinHard(serviceULimitsNoFile.Hard)
-		nf.mergeExistingWinSoft(serviceULimitsNoFile.Soft)
-	}
+	mergeObject(nf, serviceULimitsNoFile, StrategyExistingWin)
 }
 
 // MergeLastWin merges adds or overwrite the attributes of the passed
 // ServiceULimitsNoFile with the existing one.
 func (nf *ServiceULimitsNoFile) MergeLastWin(serviceULimitsNoFile *ServiceULimitsNoFile) {
-	switch {
-	case nf == nil && serviceULimitsNoFile == nil:
-		fallthrough
-	case nf != nil && serviceULimitsNoFile == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer nf *ServiceULimitsNoFile
-	// to a new initialized ServiceULimitsNoFile without returning the serviceULimitsNoFile
-	// it self.
-	//
-	// case nf == nil && serviceULimitsNoFile != nil:
-	// 	nf = NewServiceULimitsNoFile()
-	// 	fallthrough
-
-	default:
-		nf.mergeLastWinHard(serviceULimitsNoFile.Hard)
-		nf.mergeLastWinSoft(serviceULimitsNoFile.Soft)
-	}
+	mergeObject(nf, serviceULimitsNoFile, StrategyLastWin)
 }
 
-func (nf *ServiceULimitsNoFile) mergeExistingWinHard(hard uint) {
-	if nf.Hard != hard {
-		return
-	}
-	nf.Hard = hard
+func NewServiceULimitsNoFile() *ServiceULimitsNoFile {
+	return &ServiceULimitsNoFile{}
 }
 
-func (nf *ServiceULimitsNoFile) mergeExistingWinSoft(soft uint) {
-	if nf.Soft != soft {
-		return
-	}
-	nf.Soft = soft
-}
+type Volume struct {
+	External bool `json:"external,omitempty" yaml:"external,omitempty" merge:"overwrite"`
 
-func (nf *ServiceULimitsNoFile) mergeLastWinHard(hard uint) {
-	if nf.Hard != hard {
-		nf.Hard = hard
-	}
+	Extensions Extensions `json:"-" yaml:",inline"`
 }
 
-func (nf *ServiceULimitsNoFile) mergeLastWinSoft(soft uint) {
-	if nf.Soft != soft {
-		nf.Soft = soft
+// Clone returns a deep copy of the volume.
+func (v *Volume) Clone() *Volume {
+	if v == nil {
+		return nil
 	}
-}
-
-func NewServiceULimitsNoFile() *ServiceULimitsNoFile {
-	return &ServiceULimitsNoFile{}
-}
 
-type Volume struct {
-	External bool `json:"external,omitempty" yaml:"external,omitempty"`
+	return &Volume{
+		External:   v.External,
+		Extensions: v.Extensions.Clone(),
+	}
 }
 
 // Equal returns true if the passed equalable is equal
@@ -2024,69 +1431,17 @@ func (v *Volume) Equal(equalable Equalable) bool {
 		return false
 	}
 
-	switch {
-	case v == nil && volume == nil:
-		return true
-	case v != nil && volume == nil:
-		fallthrough
-	case v == nil && volume != nil:
-		return false
-	default:
-		return v.External == volume.External
-	}
+	return equalObject(v, volume)
 }
 
 // MergeExistingWin adds only the attributes of the passed Volume they are
 // undefined.
 func (v *Volume) MergeExistingWin(volume *Volume) {
-	switch {
-	case v == nil && volume == nil:
-		fallthrough
-	case v != nil && volume == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer v *Volume to a new
-	// initialized Volume without returning the volume it self.
-	//
-	// case v == nil && volume != nil:
-	// 	v = NewVolume()
-	// 	fallthrough
-
-	default:
-		v.mergeExistingWinExternal(volume.External)
-	}
+	mergeObject(v, volume, StrategyExistingWin)
 }
 
 func (v *Volume) MergeLastWin(volume *Volume) {
-	switch {
-	case v == nil && volume == nil:
-		fallthrough
-	case v != nil && volume == nil:
-		return
-
-	// WARN: It's not possible to change the memory pointer v *Volume to a new
-	// initialized Volume without returning the volume it self.
-	//
-	// case v == nil && volume != nil:
-	// 	v = NewVolume()
-	// 	fallthrough
-
-	default:
-		v.mergeLastWinExternal(volume.External)
-	}
-}
-
-func (v *Volume) mergeExistingWinExternal(_ bool) {
-	if v.External {
-		return
-	}
-	v.External = true
-}
-
-func (v *Volume) mergeLastWinExternal(external bool) {
-	if v.External != external {
-		v.External = external
-	}
+	mergeObject(v, volume, StrategyLastWin)
 }
 
 func NewVolume() *Volume {
@@ -2160,19 +1515,128 @@ func splitStringInPortMapping(s string) (string, string, string) {
 	return src, dst, p.getProtocol()
 }
 
-func splitStringInVolume(s string) (string, string, string) {
-	parts := strings.Split(s, volumeDelimiter)
-	src := parts[0]
-	dest := parts[1]
-	if len(parts) == 3 && len(parts[2]) > 0 {
-		perm := parts[2]
-		return src, dest, perm
+var ErrInvalidVolume error = errors.New("invalid volume")
+
+// splitStringInVolume splits the short syntax of a volume into its source, target and access mode. The source of an
+// anonymous volume like /data is empty.
+func splitStringInVolume(s string) (string, string, string, error) {
+	parts := splitOutsideExpressions(s, volumeDelimiter)
+	switch {
+	case len(parts) == 1 && len(parts[0]) > 0:
+		return "", parts[0], "", nil
+	case len(parts) == 2 && len(parts[1]) > 0:
+		return parts[0], parts[1], "", nil
+	case len(parts) == 3 && len(parts[1]) > 0:
+		return parts[0], parts[1], parts[2], nil
+	default:
+		return "", "", "", fmt.Errorf("%w: %s", ErrInvalidVolume, s)
+	}
+}
+
+// splitOutsideExpressions splits the string by the separator like strings.Split, but ignores separators inside of
+// variable expressions like ${HOST_PATH:-./data}.
+func splitOutsideExpressions(s, sep string) []string {
+	parts := make([]string, 0)
+
+	depth := 0
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "$$"):
+			i++
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+		case s[i] == '{' && depth > 0:
+			depth++
+		case s[i] == '}' && depth > 0:
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], sep):
+			parts = append(parts, s[start:i])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+var ErrUnterminatedQuote error = errors.New("unterminated quote")
+
+// splitShellWords splits the string into words like a shell. Words are separated by whitespaces, which are preserved
+// inside of single or double quotes or when escaped by a backslash.
+//
+//	// Example
+//	words, _ := splitShellWords(`sh -c "echo 'hello world'"`)
+//	// Output: ["sh" "-c" "echo 'hello world'"]
+func splitShellWords(s string) ([]string, error) {
+	words := make([]string, 0)
+
+	var (
+		word    strings.Builder
+		inWord  bool
+		escaped bool
+		quote   rune
+	)
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("%w: %s", ErrUnterminatedQuote, s)
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// stringOrSlice is a helper type to decode attributes, which can be declared as single string or as list of strings.
+type stringOrSlice []string
+
+// UnmarshalYAML implements the UnmarshalYAML interface to customize the behavior when being unmarshaled into a YAML
+// document.
+func (s *stringOrSlice) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*s = []string{value.Value}
+		return nil
 	}
-	return src, dest, ""
+
+	return value.Decode((*[]string)(s))
 }
 
+// variableExpression matches a variable expression like $PORT, ${PORT} or ${PORT:-8080}, which is kept verbatim when
+// the interpolation is disabled. Expressions can be nested once, for example ${PORT:-${DEFAULT_PORT}}.
+const variableExpression string = `\$(\{([^{}]|\{[^{}]*\})*\}|[A-Za-z_][A-Za-z0-9_]*)`
+
 var (
-	regExpPort = regexp.MustCompile(`^((?<srcIP>([\d]{1,3}\.){3}[\d]{1,3}):)?(?<srcPort>[\d]{1,5}):((?<dstIP>([\d]{1,3}\.){3}[\d]{1,3}):)?(?<dstPort>[\d]{1,5})(\/(?<protocol>[a-z]*))?$`)
+	regExpPort = regexp.MustCompile(`^((?<srcIP>([\d]{1,3}\.){3}[\d]{1,3}|` + variableExpression + `):)?(?<srcPort>[\d]{1,5}|` + variableExpression + `):((?<dstIP>([\d]{1,3}\.){3}[\d]{1,3}|` + variableExpression + `):)?(?<dstPort>[\d]{1,5}|` + variableExpression + `)(\/(?<protocol>[a-z]*|` + variableExpression + `))?$`)
 )
 
 type Port string
@@ -2298,3 +1762,27 @@ func (p Port) getSrcPort() string {
 
 	return matches[i]
 }
+
+// normalize returns the port in the short syntax without the default source ip 0.0.0.0 and the default protocol tcp.
+// Ports, which can not be parsed, are returned unchanged.
+//
+//	// Example
+//	p := Port("0.0.0.0:80:8080/tcp").normalize()
+//	// Output: "80:8080"
+func (p Port) normalize() Port {
+	if !p.existsSrcPort() || !p.existsDstPort() {
+		return p
+	}
+
+	src := p.getSrc()
+	if p.getSrcIP() == "0.0.0.0" {
+		src = p.getSrcPort()
+	}
+
+	port := fmt.Sprintf("%s%s%s", src, portDelimiter, p.getDst())
+	if p.existsProtocol() && p.getProtocol() != "tcp" {
+		port = fmt.Sprintf("%s/%s", port, p.getProtocol())
+	}
+
+	return Port(port)
+}
@@ -39,6 +39,18 @@ func Test_splitStringInPortMapping(t *testing.T) {
 			expectedDst:      "10.11.12.13:53",
 			expectedProtocol: "tcp",
 		},
+		{
+			s:                "${HOST_PORT:-8080}:80",
+			expectedSrc:      "${HOST_PORT:-8080}",
+			expectedDst:      "80",
+			expectedProtocol: "",
+		},
+		{
+			s:                "${HOST_IP}:$HOST_PORT:${PORT:-${DEFAULT_PORT}}/${PROTOCOL}",
+			expectedSrc:      "${HOST_IP}:$HOST_PORT",
+			expectedDst:      "${PORT:-${DEFAULT_PORT}}",
+			expectedProtocol: "${PROTOCOL}",
+		},
 	}
 
 	for i, testCase := range testCases {
@@ -49,6 +61,112 @@ func Test_splitStringInPortMapping(t *testing.T) {
 	}
 }
 
+func Test_splitStringInVolume(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		s            string
+		expectedSrc  string
+		expectedDst  string
+		expectedPerm string
+		expectedErr  error
+	}{
+		{
+			s:            "/data",
+			expectedSrc:  "",
+			expectedDst:  "/data",
+			expectedPerm: "",
+		},
+		{
+			s:            "./data:/data",
+			expectedSrc:  "./data",
+			expectedDst:  "/data",
+			expectedPerm: "",
+		},
+		{
+			s:            "./data:/data:ro",
+			expectedSrc:  "./data",
+			expectedDst:  "/data",
+			expectedPerm: "ro",
+		},
+		{
+			s:            "${DATA_DIR:-./data}:/data:ro",
+			expectedSrc:  "${DATA_DIR:-./data}",
+			expectedDst:  "/data",
+			expectedPerm: "ro",
+		},
+		{
+			s:            "${DATA_DIR:-${HOME:-/root}/data}:${DATA_MOUNT:?required}",
+			expectedSrc:  "${DATA_DIR:-${HOME:-/root}/data}",
+			expectedDst:  "${DATA_MOUNT:?required}",
+			expectedPerm: "",
+		},
+		{
+			s:            "$$HOME/data:/data",
+			expectedSrc:  "$$HOME/data",
+			expectedDst:  "/data",
+			expectedPerm: "",
+		},
+		{
+			s:           "",
+			expectedErr: ErrInvalidVolume,
+		},
+		{
+			s:           "./data:",
+			expectedErr: ErrInvalidVolume,
+		},
+		{
+			s:           "./data:/data:ro:z",
+			expectedErr: ErrInvalidVolume,
+		},
+	}
+
+	for i, testCase := range testCases {
+		actualSrc, actualDst, actualPerm, err := splitStringInVolume(testCase.s)
+		require.ErrorIs(err, testCase.expectedErr, "TestCase %v", i)
+		require.Equal(testCase.expectedSrc, actualSrc, "TestCase %v", i)
+		require.Equal(testCase.expectedDst, actualDst, "TestCase %v", i)
+		require.Equal(testCase.expectedPerm, actualPerm, "TestCase %v", i)
+	}
+}
+
+func Test_splitShellWords(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		s             string
+		expectedWords []string
+		expectedErr   error
+	}{
+		{
+			s:             "",
+			expectedWords: []string{},
+		},
+		{
+			s:             "  serve   --port 8080 ",
+			expectedWords: []string{"serve", "--port", "8080"},
+		},
+		{
+			s:             `sh -c "echo 'hello world'"`,
+			expectedWords: []string{"sh", "-c", "echo 'hello world'"},
+		},
+		{
+			s:             `echo "" 'a \b' a\ b "\"quoted\""`,
+			expectedWords: []string{"echo", "", `a \b`, "a b", `"quoted"`},
+		},
+		{
+			s:           `sh -c "echo`,
+			expectedErr: ErrUnterminatedQuote,
+		},
+	}
+
+	for i, testCase := range testCases {
+		actualWords, err := splitShellWords(testCase.s)
+		require.ErrorIs(err, testCase.expectedErr, "TestCase %v", i)
+		require.Equal(testCase.expectedWords, actualWords, "TestCase %v", i)
+	}
+}
+
 func TestPort_DstIP(t *testing.T) {
 	require := require.New(t)
 
@@ -14,12 +14,37 @@ import (
 //go:embed test/assets/merge
 var testAssetsMerge embed.FS
 
+//go:embed test/assets/mergeComposeCompatible
+var testAssetsMergeComposeCompatible embed.FS
+
+//go:embed test/assets/mergeExistingWin
+var testAssetsMergeExistingWin embed.FS
+
+//go:embed test/assets/mergeLastWin
+var testAssetsMergeLastWin embed.FS
+
 func TestConfig_Merge(t *testing.T) {
-	require := require.New(t)
+	testConfigMerge(t, testAssetsMerge, "test/assets/merge", (*dockerCompose.Config).Merge)
+}
 
-	testAssetPath := "test/assets/merge"
+func TestConfig_MergeComposeCompatible(t *testing.T) {
+	testConfigMerge(t, testAssetsMergeComposeCompatible, "test/assets/mergeComposeCompatible", (*dockerCompose.Config).MergeComposeCompatible)
+}
+
+func TestConfig_MergeExistingWin(t *testing.T) {
+	testConfigMerge(t, testAssetsMergeExistingWin, "test/assets/mergeExistingWin", (*dockerCompose.Config).MergeExistingWin)
+}
 
-	testAssetMergeDirEntries, err := testAssetsMerge.ReadDir(testAssetPath)
+func TestConfig_MergeLastWin(t *testing.T) {
+	testConfigMerge(t, testAssetsMergeLastWin, "test/assets/mergeLastWin", (*dockerCompose.Config).MergeLastWin)
+}
+
+// testConfigMerge merges the docker-compose files of each testcase directory by the passed merge function and compares
+// the result with the expected result of the testcase.
+func testConfigMerge(t *testing.T, testAssets embed.FS, testAssetPath string, mergeFunc func(*dockerCompose.Config, *dockerCompose.Config)) {
+	require := require.New(t)
+
+	testAssetMergeDirEntries, err := testAssets.ReadDir(testAssetPath)
 	require.NoError(err)
 
 	// iterate over testcase directories
@@ -30,7 +55,7 @@ func TestConfig_Merge(t *testing.T) {
 
 		// iterate over files in testcase directories
 		testCaseAssetPath := testAssetPath + "/" + mergeDirEntry.Name()
-		testCaseDirEntries, err := testAssetsMerge.ReadDir(testCaseAssetPath)
+		testCaseDirEntries, err := testAssets.ReadDir(testCaseAssetPath)
 		require.NoError(err)
 
 		expectedDockerComposeConfig := &dockerCompose.Config{}
@@ -41,7 +66,7 @@ func TestConfig_Merge(t *testing.T) {
 			}
 
 			dockerComposeConfigFile := testAssetPath + "/" + mergeDirEntry.Name() + "/" + testCaseDirEntry.Name()
-			b, err := testAssetsMerge.ReadFile(dockerComposeConfigFile)
+			b, err := testAssets.ReadFile(dockerComposeConfigFile)
 			require.NoError(err)
 			yamlDecoder := yaml.NewDecoder(bytes.NewReader(b))
 
@@ -59,7 +84,7 @@ func TestConfig_Merge(t *testing.T) {
 
 		actualDockerComposeConfig := &dockerCompose.Config{}
 		for _, dockerComposeConfig := range dockerComposeConfigs {
-			actualDockerComposeConfig.Merge(dockerComposeConfig)
+			mergeFunc(actualDockerComposeConfig, dockerComposeConfig)
 		}
 
 		expectedBytes := make([]byte, 0)
@@ -381,7 +406,7 @@ func TestService_Equal(t *testing.T) {
 		},
 		{
 			equalableA: &dockerCompose.Service{
-				DependsOnContainer: &dockerCompose.DependsOnContainer{Slice: []string{"app"}},
+				DependsOnContainer: &dockerCompose.DependsOnContainer{DependsOn: map[string]*dockerCompose.ServiceDependsOn{"app": {Condition: "service_started"}}},
 			},
 			equalableB: &dockerCompose.Service{
 				DependsOnContainer: nil,
@@ -390,7 +415,7 @@ func TestService_Equal(t *testing.T) {
 		},
 		{
 			equalableA: &dockerCompose.Service{
-				DependsOnContainer: &dockerCompose.DependsOnContainer{Slice: []string{"app"}},
+				DependsOnContainer: &dockerCompose.DependsOnContainer{DependsOn: map[string]*dockerCompose.ServiceDependsOn{"app": {Condition: "service_started"}}},
 			},
 			equalableB: &dockerCompose.Service{
 				DependsOnContainer: &dockerCompose.DependsOnContainer{},
@@ -399,19 +424,19 @@ func TestService_Equal(t *testing.T) {
 		},
 		{
 			equalableA: &dockerCompose.Service{
-				DependsOnContainer: &dockerCompose.DependsOnContainer{Slice: []string{"app"}},
+				DependsOnContainer: &dockerCompose.DependsOnContainer{DependsOn: map[string]*dockerCompose.ServiceDependsOn{"app": {Condition: "service_started"}}},
 			},
 			equalableB: &dockerCompose.Service{
-				DependsOnContainer: &dockerCompose.DependsOnContainer{Slice: []string{}},
+				DependsOnContainer: &dockerCompose.DependsOnContainer{DependsOn: map[string]*dockerCompose.ServiceDependsOn{}},
 			},
 			expectedResult: false,
 		},
 		{
 			equalableA: &dockerCompose.Service{
-				DependsOnContainer: &dockerCompose.DependsOnContainer{Slice: []string{"app"}},
+				DependsOnContainer: &dockerCompose.DependsOnContainer{DependsOn: map[string]*dockerCompose.ServiceDependsOn{"app": {Condition: "service_started"}}},
 			},
 			equalableB: &dockerCompose.Service{
-				DependsOnContainer: &dockerCompose.DependsOnContainer{Slice: []string{"app"}},
+				DependsOnContainer: &dockerCompose.DependsOnContainer{DependsOn: map[string]*dockerCompose.ServiceDependsOn{"app": {Condition: "service_started"}}},
 			},
 			expectedResult: true,
 		},
@@ -628,6 +653,80 @@ func TestService_Equal(t *testing.T) {
 	}
 }
 
+func TestService_UnmarshalYAML(t *testing.T) {
+	require := require.New(t)
+
+	source := `command: echo "hello world"
+entrypoint: /docker-entrypoint.sh
+env_file: .env
+`
+
+	node := new(yaml.Node)
+	require.NoError(yaml.Unmarshal([]byte(source), node))
+
+	service := dockerCompose.NewService()
+	require.NoError(node.Decode(service))
+	require.Equal([]string{"echo", "hello world"}, service.Command)
+	require.Equal([]string{"/docker-entrypoint.sh"}, service.Entrypoint)
+	require.Equal([]string{".env"}, service.EnvFile)
+
+	// The node is kept as declared, including the line and column of its values.
+	b, err := yaml.Marshal(node)
+	require.NoError(err)
+	require.Equal(source, string(b))
+	require.Equal(yaml.ScalarNode, node.Content[0].Content[1].Kind)
+	require.Equal(1, node.Content[0].Content[1].Line)
+	require.Equal(10, node.Content[0].Content[1].Column)
+}
+
+func TestConfig_ExtendedService(t *testing.T) {
+	require := require.New(t)
+
+	config := dockerCompose.NewConfig()
+	require.NoError(yaml.Unmarshal([]byte(`services:
+  app:
+    cap_drop: !reset []
+    environment: !override
+    - LOG_LEVEL=debug
+    labels:
+    - com.example.team=b
+  worker:
+    cap_drop:
+    - NET_RAW
+`), config))
+
+	baseService := dockerCompose.NewService()
+	require.NoError(yaml.Unmarshal([]byte(`cap_drop:
+- ALL
+environment:
+- LOG_LEVEL=info
+- TZ=UTC
+image: example.local/app:1.0.0
+labels:
+- com.example.team=a
+- com.example.tier=backend
+`), baseService))
+	expectedBaseService := baseService.Clone()
+
+	expectedService := dockerCompose.NewService()
+	require.NoError(yaml.Unmarshal([]byte(`environment:
+- LOG_LEVEL=debug
+image: example.local/app:1.0.0
+labels:
+- com.example.team=b
+- com.example.tier=backend
+`), expectedService))
+
+	require.True(expectedService.Equal(config.ExtendedService("app", baseService)))
+
+	// The directives of other services are not applied.
+	expectedService = baseService.Clone()
+	expectedService.CapabilitiesDrop = append(expectedService.CapabilitiesDrop, "NET_RAW")
+	require.True(expectedService.Equal(config.ExtendedService("worker", baseService)))
+
+	require.True(expectedBaseService.Equal(baseService))
+}
+
 func TestService_MergeExistingWin(t *testing.T) {
 	require := require.New(t)
 
@@ -2879,6 +2978,168 @@ func TestService_SetPort(t *testing.T) {
 	}
 }
 
+func TestDependsOnContainer_MarshalYAML(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		dependsOnContainer *dockerCompose.DependsOnContainer
+		expectedString     string
+	}{
+		{
+			dependsOnContainer: &dockerCompose.DependsOnContainer{
+				DependsOn: map[string]*dockerCompose.ServiceDependsOn{
+					"db":    {Condition: dockerCompose.ServiceDependsOnConditionServiceStarted},
+					"cache": {Condition: dockerCompose.ServiceDependsOnConditionServiceStarted, Required: boolPtr(true)},
+				},
+			},
+			expectedString: "- cache\n- db\n",
+		},
+		{
+			dependsOnContainer: &dockerCompose.DependsOnContainer{
+				DependsOn: map[string]*dockerCompose.ServiceDependsOn{
+					"db": {Condition: dockerCompose.ServiceDependsOnConditionServiceStarted, Restart: boolPtr(true)},
+				},
+			},
+			expectedString: "db:\n    condition: service_started\n    restart: true\n",
+		},
+		{
+			dependsOnContainer: &dockerCompose.DependsOnContainer{
+				DependsOn: map[string]*dockerCompose.ServiceDependsOn{
+					"db": {Condition: dockerCompose.ServiceDependsOnConditionServiceStarted, Required: boolPtr(false)},
+				},
+			},
+			expectedString: "db:\n    condition: service_started\n    required: false\n",
+		},
+	}
+
+	for i, testCase := range testCases {
+		b, err := yaml.Marshal(testCase.dependsOnContainer)
+		require.NoError(err, "TestCase %v", i)
+		require.Equal(testCase.expectedString, string(b), "TestCase %v", i)
+	}
+}
+
+func TestDependsOnContainer_UnmarshalYAML(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		shortSyntax    string
+		longSyntax     string
+		expectedResult bool
+	}{
+		{
+			shortSyntax:    "- db\n",
+			longSyntax:     "db:\n  condition: service_started\n",
+			expectedResult: true,
+		},
+		{
+			shortSyntax:    "- cache\n- db\n",
+			longSyntax:     "db:\n  condition: service_started\ncache:\n  condition: service_started\n",
+			expectedResult: true,
+		},
+		{
+			shortSyntax:    "- db\n",
+			longSyntax:     "db:\n  condition: service_healthy\n",
+			expectedResult: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		shortDependsOnContainer := new(dockerCompose.DependsOnContainer)
+		require.NoError(yaml.Unmarshal([]byte(testCase.shortSyntax), shortDependsOnContainer), "TestCase %v", i)
+
+		longDependsOnContainer := new(dockerCompose.DependsOnContainer)
+		require.NoError(yaml.Unmarshal([]byte(testCase.longSyntax), longDependsOnContainer), "TestCase %v", i)
+
+		require.Equal(testCase.expectedResult, shortDependsOnContainer.Equal(longDependsOnContainer), "TestCase %v", i)
+	}
+}
+
+func TestServiceDependsOn_MergeExistingWin(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		serviceDependsOnA        *dockerCompose.ServiceDependsOn
+		serviceDependsOnB        *dockerCompose.ServiceDependsOn
+		expectedServiceDependsOn *dockerCompose.ServiceDependsOn
+	}{
+		{
+			serviceDependsOnA:        nil,
+			serviceDependsOnB:        nil,
+			expectedServiceDependsOn: nil,
+		},
+		{
+			serviceDependsOnA: &dockerCompose.ServiceDependsOn{
+				Condition: dockerCompose.ServiceDependsOnConditionServiceStarted,
+			},
+			serviceDependsOnB: &dockerCompose.ServiceDependsOn{
+				Condition: dockerCompose.ServiceDependsOnConditionServiceHealthy,
+				Required:  boolPtr(false),
+				Restart:   boolPtr(true),
+			},
+			expectedServiceDependsOn: &dockerCompose.ServiceDependsOn{
+				Condition: dockerCompose.ServiceDependsOnConditionServiceStarted,
+				Required:  boolPtr(false),
+				Restart:   boolPtr(true),
+			},
+		},
+		{
+			serviceDependsOnA: &dockerCompose.ServiceDependsOn{
+				Condition: dockerCompose.ServiceDependsOnConditionServiceStarted,
+				Restart:   boolPtr(false),
+			},
+			serviceDependsOnB: &dockerCompose.ServiceDependsOn{
+				Restart: boolPtr(true),
+			},
+			expectedServiceDependsOn: &dockerCompose.ServiceDependsOn{
+				Condition: dockerCompose.ServiceDependsOnConditionServiceStarted,
+				Restart:   boolPtr(false),
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		testCase.serviceDependsOnA.MergeExistingWin(testCase.serviceDependsOnB)
+		require.True(testCase.expectedServiceDependsOn.Equal(testCase.serviceDependsOnA), "Failed test case %v", i)
+	}
+}
+
+func TestServiceDependsOn_MergeLastWin(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		serviceDependsOnA        *dockerCompose.ServiceDependsOn
+		serviceDependsOnB        *dockerCompose.ServiceDependsOn
+		expectedServiceDependsOn *dockerCompose.ServiceDependsOn
+	}{
+		{
+			serviceDependsOnA:        nil,
+			serviceDependsOnB:        nil,
+			expectedServiceDependsOn: nil,
+		},
+		{
+			serviceDependsOnA: &dockerCompose.ServiceDependsOn{
+				Condition: dockerCompose.ServiceDependsOnConditionServiceStarted,
+				Restart:   boolPtr(true),
+			},
+			serviceDependsOnB: &dockerCompose.ServiceDependsOn{
+				Condition: dockerCompose.ServiceDependsOnConditionServiceHealthy,
+				Required:  boolPtr(false),
+			},
+			expectedServiceDependsOn: &dockerCompose.ServiceDependsOn{
+				Condition: dockerCompose.ServiceDependsOnConditionServiceHealthy,
+				Required:  boolPtr(false),
+				Restart:   boolPtr(true),
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		testCase.serviceDependsOnA.MergeLastWin(testCase.serviceDependsOnB)
+		require.True(testCase.expectedServiceDependsOn.Equal(testCase.serviceDependsOnA), "Failed test case %v", i)
+	}
+}
+
 func TestSecretDeploy_Equal(t *testing.T) {
 	require := require.New(t)
 
@@ -4303,3 +4564,47 @@ func TestVolume_MergeLastWin(t *testing.T) {
 		require.True(testCase.expectedVolume.Equal(testCase.volumeA), "Failed test case %v", i)
 	}
 }
+
+func TestInclude_UnmarshalYAML(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		s               string
+		expectedInclude *dockerCompose.Include
+	}{
+		{
+			s: `../commons/compose.yaml`,
+			expectedInclude: &dockerCompose.Include{
+				Path: []string{"../commons/compose.yaml"},
+			},
+		},
+		{
+			s: `path: ../commons/compose.yaml
+project_directory: ..
+env_file: ../another/.env`,
+			expectedInclude: &dockerCompose.Include{
+				EnvFile:          []string{"../another/.env"},
+				Path:             []string{"../commons/compose.yaml"},
+				ProjectDirectory: "..",
+			},
+		},
+		{
+			s: `path:
+- ../commons/compose.yaml
+- ./commons-override.yaml`,
+			expectedInclude: &dockerCompose.Include{
+				Path: []string{"../commons/compose.yaml", "./commons-override.yaml"},
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		actualInclude := new(dockerCompose.Include)
+		require.NoError(yaml.Unmarshal([]byte(testCase.s), actualInclude), "TestCase %v", i)
+		require.True(testCase.expectedInclude.Equal(actualInclude), "TestCase %v", i)
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
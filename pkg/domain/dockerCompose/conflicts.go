@@ -0,0 +1,300 @@
+package dockerCompose
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Conflict is an attribute, which is declared with different values by multiple sources.
+type Conflict struct {
+	Path   string           `json:"path"`
+	Values []*ConflictValue `json:"values"`
+}
+
+// ConflictValue is the value of a conflicting attribute declared by a source.
+type ConflictValue struct {
+	Source string `json:"source"`
+	Value  string `json:"value"`
+}
+
+// Conflicts is a list of conflicting attributes.
+type Conflicts []*Conflict
+
+// Clone returns a deep copy of the conflicts.
+func (c Conflicts) Clone() Conflicts {
+	return Conflicts(CloneSlice([]*Conflict(c)))
+}
+
+// Clone returns a deep copy of the conflict.
+func (c *Conflict) Clone() *Conflict {
+	if c == nil {
+		return nil
+	}
+
+	return &Conflict{
+		Path:   c.Path,
+		Values: CloneSlice(c.Values),
+	}
+}
+
+// Clone returns a copy of the conflicting value.
+func (cv *ConflictValue) Clone() *ConflictValue {
+	return clonePointer(cv)
+}
+
+// String returns a readable report of all conflicts.
+func (c Conflicts) String() string {
+	sb := new(strings.Builder)
+	for _, conflict := range c {
+		fmt.Fprintf(sb, "%s:\n", conflict.Path)
+		for _, value := range conflict.Values {
+			fmt.Fprintf(sb, "  %s: %s\n", value.Source, value.Value)
+		}
+	}
+	return sb.String()
+}
+
+// keyedLists maps the names of lists, whose items are merged by a key, to a function returning the key of an item. The
+// lists are declared by the merge tag keyed=<key>. Items with the same key but a different value are conflicting.
+var keyedLists = func() map[string]func(item string) listKey {
+	lists := make(map[string]func(item string) listKey)
+	for name, field := range collectLists(reflect.TypeOf(Config{}), mergeKindKeyed) {
+		lists[name] = field.keyFunc
+	}
+	return lists
+}()
+
+// replacedLists contains the names of lists, which are replaced as a whole instead of merged item by item. The lists are
+// declared by the merge tag replace. All other lists are merged as set and can't conflict.
+var replacedLists = func() map[string]struct{} {
+	lists := make(map[string]struct{})
+	for name := range collectLists(reflect.TypeOf(Config{}), mergeKindReplace) {
+		lists[name] = struct{}{}
+	}
+	return lists
+}()
+
+// FindConflicts returns all attributes, which are declared with different values by the passed documents. Identical
+// declarations are not a conflict. The names are the names of the sources of the documents in the same order. Objects
+// are compared by their Equal method, only unequal objects are compared attribute by attribute. Zero values like false
+// or 0 are only declared, when the YAML document, from which the document was decoded, declares them.
+func FindConflicts(names []string, documents []*Document) Conflicts {
+	conflicts := make(map[string]*Conflict)
+
+	declaredPaths := make([]map[string]struct{}, 0, len(documents))
+	for _, document := range documents {
+		declaredPaths = append(declaredPaths, document.declaredPaths())
+	}
+
+	for i := range documents {
+		for j := i + 1; j < len(documents); j++ {
+			report := func(path []string, a string, b string) {
+				key := strings.Join(path, pathSeparator)
+
+				conflict, present := conflicts[key]
+				if !present {
+					conflict = &Conflict{Path: key, Values: make([]*ConflictValue, 0)}
+					conflicts[key] = conflict
+				}
+
+				conflict.addValue(names[i], a)
+				conflict.addValue(names[j], b)
+			}
+
+			declared := func(path []string, a reflect.Value, b reflect.Value) bool {
+				return isDeclared(a, path, declaredPaths[i]) && isDeclared(b, path, declaredPaths[j])
+			}
+
+			findConflicts(reflect.ValueOf(documents[i].Config), reflect.ValueOf(documents[j].Config), make([]string, 0), declared, report)
+		}
+	}
+
+	paths := make([]string, 0, len(conflicts))
+	for path := range conflicts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	positions := make(map[string]int, len(names))
+	for i, name := range names {
+		positions[name] = i
+	}
+
+	sortedConflicts := make(Conflicts, 0, len(paths))
+	for _, path := range paths {
+		values := conflicts[path].Values
+		sort.SliceStable(values, func(i, j int) bool { return positions[values[i].Source] < positions[values[j].Source] })
+		sortedConflicts = append(sortedConflicts, conflicts[path])
+	}
+
+	return sortedConflicts
+}
+
+// addValue adds the value of the source, if the source is not already known.
+func (c *Conflict) addValue(source string, value string) {
+	for _, conflictValue := range c.Values {
+		if conflictValue.Source == source {
+			return
+		}
+	}
+	c.Values = append(c.Values, &ConflictValue{Source: source, Value: value})
+}
+
+// findConflicts compares the values a and b of the passed path and reports all conflicting attributes. Values, which
+// are not declared by both sides, can't conflict.
+func findConflicts(a reflect.Value, b reflect.Value, path []string, declared func(path []string, a reflect.Value, b reflect.Value) bool, report func(path []string, a string, b string)) {
+	switch {
+	case !declared(path, a, b):
+		return
+	case a.Type() != b.Type():
+		report(path, formatValue(a), formatValue(b))
+		return
+	}
+
+	if equalable, ok := a.Interface().(Equalable); ok && a.Kind() == reflect.Pointer {
+		if equalable.Equal(b.Interface().(Equalable)) {
+			return
+		}
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer:
+		if a.Elem().Kind() == reflect.Struct {
+			findStructConflicts(a.Elem(), b.Elem(), path, declared, report)
+			return
+		}
+		findConflicts(a.Elem(), b.Elem(), path, declared, report)
+	case reflect.Interface:
+		findConflicts(a.Elem(), b.Elem(), path, declared, report)
+	case reflect.Map:
+		keys := a.MapKeys()
+		for _, key := range keys {
+			value := b.MapIndex(key)
+			if value.IsValid() {
+				findConflicts(a.MapIndex(key), value, appendPath(path, fmt.Sprint(key.Interface())), declared, report)
+			}
+		}
+	case reflect.Slice:
+		findListConflicts(a, b, path, report)
+	default:
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			report(path, formatValue(a), formatValue(b))
+		}
+	}
+}
+
+// findStructConflicts compares the attributes of the structs a and b.
+func findStructConflicts(a reflect.Value, b reflect.Value, path []string, declared func(path []string, a reflect.Value, b reflect.Value) bool, report func(path []string, a string, b string)) {
+	for i := 0; i < a.NumField(); i++ {
+		structField := a.Type().Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+
+		name, inline := yamlFieldName(structField)
+		switch {
+		case inline || isHelperField(structField):
+			findConflicts(a.Field(i), b.Field(i), path, declared, report)
+		case len(name) > 0:
+			findConflicts(a.Field(i), b.Field(i), appendPath(path, name), declared, report)
+		}
+	}
+}
+
+// findListConflicts compares the lists a and b. Items of keyed lists are compared by their key, replaced lists are
+// compared as a whole.
+func findListConflicts(a reflect.Value, b reflect.Value, path []string, report func(path []string, a string, b string)) {
+	name := ""
+	if len(path) > 0 {
+		name = path[len(path)-1]
+	}
+
+	keyFunc, keyed := keyedLists[name]
+	_, replaced := replacedLists[name]
+
+	switch {
+	case keyed && a.Type().Elem().Kind() == reflect.String:
+		// Keys are matched like by the merge, for example the published port 127.0.0.1:8080 matches the unscoped
+		// published port 8080. The conflict is reported by the key of the existing item.
+		for i := 0; i < b.Len(); i++ {
+			item := b.Index(i).String()
+			j := indexOfKey(a, keyFunc(item), keyFunc)
+			if j < 0 {
+				continue
+			}
+
+			existingItem := a.Index(j).String()
+			if existingItem != item {
+				report(appendPath(path, keyFunc(existingItem).String()), existingItem, item)
+			}
+		}
+	case replaced && !reflect.DeepEqual(a.Interface(), b.Interface()):
+		report(path, formatValue(a), formatValue(b))
+	}
+}
+
+// declaredPaths returns the paths of all values declared by the YAML document, from which the document was decoded. The
+// paths are empty, when the document was not decoded from a YAML document.
+func (d *Document) declaredPaths() map[string]struct{} {
+	paths := make(map[string]struct{})
+	if d.node == nil {
+		return paths
+	}
+
+	walkNodePaths(d.node, make([]string, 0), func(path []string, keyNode *yaml.Node, valueNode *yaml.Node) {
+		paths[strings.Join(path, pathSeparator)] = struct{}{}
+	})
+	return paths
+}
+
+// isDeclared returns true if the value of the path is declared. Values, which are not zero, are always declared, for
+// example the condition of the short syntax of depends_on. Zero values of scalars like false or 0 are only declared,
+// when the paths of the YAML document contain the path. Nil and empty values are never declared.
+func isDeclared(v reflect.Value, path []string, declaredPaths map[string]struct{}) bool {
+	if !isZero(v) {
+		return true
+	}
+
+	switch {
+	case !v.IsValid():
+		return false
+	case v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface || v.Kind() == reflect.Map || v.Kind() == reflect.Slice:
+		return false
+	default:
+		_, declared := declaredPaths[strings.Join(path, pathSeparator)]
+		return declared
+	}
+}
+
+// isZero returns true if the value is invalid, nil or the zero value of its type. Zero values are treated as undefined
+// attributes, when it is unknown whether they are declared.
+func isZero(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil() || (v.Kind() != reflect.Pointer && v.Kind() != reflect.Interface && v.Len() <= 0)
+	default:
+		return v.IsZero()
+	}
+}
+
+// formatValue returns a readable representation of the value.
+func formatValue(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Sprint(v.Interface())
+	}
+	return string(b)
+}
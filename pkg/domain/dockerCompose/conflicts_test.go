@@ -0,0 +1,228 @@
+package dockerCompose_test
+
+import (
+	"fmt"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFindConflicts(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		configs           []string
+		expectedConflicts dockerCompose.Conflicts
+	}{
+		{
+			configs: []string{
+				`services:
+  app:
+    environment:
+    - LOG_LEVEL=info
+    image: example.local/app:1.0.0
+`,
+				`services:
+  app:
+    environment:
+    - LOG_LEVEL=info
+    - TZ=UTC
+    image: example.local/app:1.0.0
+`,
+			},
+			expectedConflicts: dockerCompose.Conflicts{},
+		},
+		{
+			configs: []string{
+				`services:
+  app:
+    cap_add:
+    - NET_ADMIN
+    command:
+    - serve
+    depends_on:
+      db:
+        condition: service_healthy
+    environment:
+    - LOG_LEVEL=info
+    image: example.local/app:1.0.0
+    ports:
+    - 8080:80
+    volumes:
+    - ./data:/data
+x-owner: team-a
+`,
+				`services:
+  app:
+    cap_add:
+    - SYS_TIME
+    command:
+    - serve
+    - --debug
+    depends_on:
+      db:
+        condition: service_started
+    environment:
+    - LOG_LEVEL=debug
+    image: example.local/app:1.0.0
+    ports:
+    - 8080:8080
+    volumes:
+    - ./data:/data:ro
+x-owner: team-b
+`,
+				`services:
+  app:
+    image: example.local/app:2.0.0
+x-owner: team-a
+`,
+			},
+			expectedConflicts: dockerCompose.Conflicts{
+				{
+					Path: "services.app.command",
+					Values: []*dockerCompose.ConflictValue{
+						{Source: "source-0", Value: `["serve"]`},
+						{Source: "source-1", Value: `["serve","--debug"]`},
+					},
+				},
+				{
+					Path: "services.app.depends_on.db.condition",
+					Values: []*dockerCompose.ConflictValue{
+						{Source: "source-0", Value: "service_healthy"},
+						{Source: "source-1", Value: "service_started"},
+					},
+				},
+				{
+					Path: "services.app.environment.LOG_LEVEL",
+					Values: []*dockerCompose.ConflictValue{
+						{Source: "source-0", Value: "LOG_LEVEL=info"},
+						{Source: "source-1", Value: "LOG_LEVEL=debug"},
+					},
+				},
+				{
+					Path: "services.app.image",
+					Values: []*dockerCompose.ConflictValue{
+						{Source: "source-0", Value: "example.local/app:1.0.0"},
+						{Source: "source-1", Value: "example.local/app:1.0.0"},
+						{Source: "source-2", Value: "example.local/app:2.0.0"},
+					},
+				},
+				{
+					Path: "services.app.ports.8080",
+					Values: []*dockerCompose.ConflictValue{
+						{Source: "source-0", Value: "8080:80"},
+						{Source: "source-1", Value: "8080:8080"},
+					},
+				},
+				{
+					Path: "services.app.volumes./data",
+					Values: []*dockerCompose.ConflictValue{
+						{Source: "source-0", Value: "./data:/data"},
+						{Source: "source-1", Value: "./data:/data:ro"},
+					},
+				},
+				{
+					Path: "x-owner",
+					Values: []*dockerCompose.ConflictValue{
+						{Source: "source-0", Value: "team-a"},
+						{Source: "source-1", Value: "team-b"},
+						{Source: "source-2", Value: "team-a"},
+					},
+				},
+			},
+		},
+		{
+			configs: []string{
+				`services:
+  app:
+    image: example.local/app:1.0.0
+    ports:
+    - 127.0.0.1:8080:80
+    - 9090:90
+`,
+				`services:
+  app:
+    image: example.local/app:1.0.0
+    ports:
+    - 8080:80
+    - 127.0.0.1:9090:90
+    - 0.0.0.0:9443:443
+`,
+			},
+			expectedConflicts: dockerCompose.Conflicts{
+				{
+					Path: "services.app.ports.127.0.0.1:8080",
+					Values: []*dockerCompose.ConflictValue{
+						{Source: "source-0", Value: "127.0.0.1:8080:80"},
+						{Source: "source-1", Value: "8080:80"},
+					},
+				},
+				{
+					Path: "services.app.ports.9090",
+					Values: []*dockerCompose.ConflictValue{
+						{Source: "source-0", Value: "9090:90"},
+						{Source: "source-1", Value: "127.0.0.1:9090:90"},
+					},
+				},
+			},
+		},
+		{
+			configs: []string{
+				`networks:
+  backend:
+    external: true
+services:
+  app:
+    ulimits:
+      nofile:
+        hard: 1024
+volumes:
+  data:
+    external: false
+`,
+				`networks:
+  backend:
+    external: false
+services:
+  app:
+    ulimits:
+      nofile:
+        hard: 0
+volumes:
+  data: {}
+`,
+			},
+			expectedConflicts: dockerCompose.Conflicts{
+				{
+					Path: "networks.backend.external",
+					Values: []*dockerCompose.ConflictValue{
+						{Source: "source-0", Value: "true"},
+						{Source: "source-1", Value: "false"},
+					},
+				},
+				{
+					Path: "services.app.ulimits.nofile.hard",
+					Values: []*dockerCompose.ConflictValue{
+						{Source: "source-0", Value: "1024"},
+						{Source: "source-1", Value: "0"},
+					},
+				},
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		names := make([]string, 0, len(testCase.configs))
+		documents := make([]*dockerCompose.Document, 0, len(testCase.configs))
+		for j, s := range testCase.configs {
+			document := dockerCompose.NewDocument(dockerCompose.NewConfig())
+			require.NoError(yaml.Unmarshal([]byte(s), document), "TestCase %v", i)
+			documents = append(documents, document)
+			names = append(names, fmt.Sprintf("source-%v", j))
+		}
+
+		require.Equal(testCase.expectedConflicts, dockerCompose.FindConflicts(names, documents), "TestCase %v", i)
+	}
+}
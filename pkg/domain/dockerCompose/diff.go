@@ -0,0 +1,344 @@
+package dockerCompose
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is the kind of a change between two configs.
+type ChangeKind string
+
+const (
+	ChangeKindAdded   ChangeKind = "added"
+	ChangeKindChanged ChangeKind = "changed"
+	ChangeKindRemoved ChangeKind = "removed"
+)
+
+// Operations of a JSON Patch defined by RFC 6902.
+const (
+	jsonPatchAdd     string = "add"
+	jsonPatchRemove  string = "remove"
+	jsonPatchReplace string = "replace"
+)
+
+const (
+	// changeIndexNone is the index of changed attributes, which are not an item of a list.
+	changeIndexNone int = -1
+
+	// jsonPointerAppend is the last token of a JSON pointer to append an item to a list.
+	jsonPointerAppend string = "-"
+)
+
+var jsonPointerReplacer = strings.NewReplacer("~", "~0", "/", "~1")
+
+// Change is an attribute, which was added, changed or removed. From is the value of the old config and To the value of
+// the new config.
+type Change struct {
+	Path string      `json:"path"`
+	Kind ChangeKind  `json:"kind"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+
+	// pointer is the JSON pointer of the attribute in the old config. Items of lists are referenced by their index in
+	// the old config, which is changeIndexNone for all other attributes and added items. The pointer is nil for
+	// extensions, which are not part of the JSON representation.
+	pointer []string
+	index   int
+}
+
+// Changes is a list of changed attributes.
+type Changes []*Change
+
+// Clone returns a deep copy of the changes.
+func (c Changes) Clone() Changes {
+	return Changes(CloneSlice([]*Change(c)))
+}
+
+// Clone returns a deep copy of the change.
+func (c *Change) Clone() *Change {
+	if c == nil {
+		return nil
+	}
+
+	return &Change{
+		Path:    c.Path,
+		Kind:    c.Kind,
+		From:    cloneChangeValue(c.From),
+		To:      cloneChangeValue(c.To),
+		pointer: cloneSlice(c.pointer),
+		index:   c.index,
+	}
+}
+
+// String returns a readable report of all changes. Added attributes are prefixed by +, removed attributes by - and
+// changed attributes by ~.
+func (c Changes) String() string {
+	sb := new(strings.Builder)
+	for _, change := range c {
+		switch change.Kind {
+		case ChangeKindAdded:
+			fmt.Fprintf(sb, "+ %s: %s\n", change.Path, formatValue(reflect.ValueOf(change.To)))
+		case ChangeKindRemoved:
+			fmt.Fprintf(sb, "- %s: %s\n", change.Path, formatValue(reflect.ValueOf(change.From)))
+		default:
+			fmt.Fprintf(sb, "~ %s: %s => %s\n", change.Path, formatValue(reflect.ValueOf(change.From)), formatValue(reflect.ValueOf(change.To)))
+		}
+	}
+	return sb.String()
+}
+
+// PatchOperation is an operation of a JSON Patch defined by RFC 6902.
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. The value is only omitted for remove operations, because values
+// like false, 0 or "" of add and replace operations are significant.
+func (po PatchOperation) MarshalJSON() ([]byte, error) {
+	if po.Op == jsonPatchRemove {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{Op: po.Op, Path: po.Path})
+	}
+
+	type patchOperation PatchOperation
+	return json.Marshal(patchOperation(po))
+}
+
+// JSONPatch returns the changes as JSON Patch defined by RFC 6902, which transforms the JSON representation of the old
+// config into the new config. The JSON representation is the one of encoding/json, whose attributes are named like in
+// the YAML document and which declares depends_on always in the long syntax. Items of lists are replaced and removed
+// by their index in the old config, the items are removed from the highest to the lowest index. Added items are
+// appended. Changes of extensions are skipped, because extensions are not part of the JSON representation.
+func (c Changes) JSONPatch() []*PatchOperation {
+	replaced := make(Changes, 0)
+	removed := make(Changes, 0)
+	added := make(Changes, 0)
+	for _, change := range c {
+		switch {
+		case change.pointer == nil:
+			continue
+		case change.Kind == ChangeKindAdded:
+			added = append(added, change)
+		case change.Kind == ChangeKindRemoved:
+			removed = append(removed, change)
+		default:
+			replaced = append(replaced, change)
+		}
+	}
+	sort.SliceStable(removed, func(i, j int) bool { return removed[i].index > removed[j].index })
+
+	patch := make([]*PatchOperation, 0, len(c))
+	for _, change := range replaced {
+		patch = append(patch, &PatchOperation{Op: jsonPatchReplace, Path: jsonPointer(change.pointer), Value: change.To})
+	}
+	for _, change := range removed {
+		patch = append(patch, &PatchOperation{Op: jsonPatchRemove, Path: jsonPointer(change.pointer)})
+	}
+	for _, change := range added {
+		patch = append(patch, &PatchOperation{Op: jsonPatchAdd, Path: jsonPointer(change.pointer), Value: change.To})
+	}
+
+	return patch
+}
+
+// Diff returns the changes of the attributes from the config a to the config b. The changes are detected by the Equal
+// methods, only unequal objects are compared attribute by attribute. Items of lists merged as set are compared item by
+// item, items of keyed lists by their key. All other lists are compared as a whole.
+func Diff(a *Config, b *Config) Changes {
+	if a == nil {
+		a = NewConfig()
+	}
+	if b == nil {
+		b = NewConfig()
+	}
+
+	changes := make(Changes, 0)
+	diffValue(reflect.ValueOf(a), reflect.ValueOf(b), make([]string, 0), make([]string, 0), &changes)
+	return changes
+}
+
+// diffValue appends the changes from the value a to the value b. Invalid values are absent entries of a mapping. The
+// path is the readable path of the values and the pointer their JSON pointer.
+func diffValue(a reflect.Value, b reflect.Value, path []string, pointer []string, changes *Changes) {
+	switch {
+	case equalMerge3Value(a, b):
+		return
+	case isZero(a) && isZero(b):
+		return
+	case isZero(a):
+		*changes = append(*changes, newChange(ChangeKindAdded, path, pointer, changeIndexNone, a, b))
+		return
+	case isZero(b):
+		*changes = append(*changes, newChange(ChangeKindRemoved, path, pointer, changeIndexNone, a, b))
+		return
+	}
+
+	switch {
+	case a.Kind() == reflect.Pointer && a.Elem().Kind() == reflect.Struct:
+		diffStruct(a.Elem(), b.Elem(), path, pointer, changes)
+	case a.Kind() == reflect.Map:
+		diffMap(a, b, path, pointer, changes)
+	default:
+		*changes = append(*changes, newChange(ChangeKindChanged, path, pointer, changeIndexNone, a, b))
+	}
+}
+
+// diffStruct appends the changes from the struct a to the struct b attribute by attribute.
+func diffStruct(a reflect.Value, b reflect.Value, path []string, pointer []string, changes *Changes) {
+	for _, field := range planOf(a.Type()) {
+		fieldPath, fieldPointer := path, pointer
+		structField := a.Type().Field(field.index)
+		name, inline := yamlFieldName(structField)
+		switch {
+		case field.kind == mergeKindExtensions:
+			fieldPointer = nil
+		case !inline && !isHelperField(structField):
+			fieldPath, fieldPointer = appendPath(path, name), appendPointer(pointer, name)
+		}
+
+		aField, bField := a.Field(field.index), b.Field(field.index)
+		switch {
+		case field.kind == mergeKindExtensions:
+			// Extensions are inlined, therefore they are compared entry by entry even when absent on one side.
+			diffMap(aField, bField, fieldPath, fieldPointer, changes)
+		case isZero(aField) || isZero(bField) || equalMerge3Value(aField, bField):
+			diffValue(aField, bField, fieldPath, fieldPointer, changes)
+		case field.kind == mergeKindSet:
+			diffSet(aField, bField, fieldPath, fieldPointer, changes)
+		case field.kind == mergeKindKeyed:
+			diffKeyed(aField, bField, field.keyFunc, fieldPath, fieldPointer, changes)
+		default:
+			diffValue(aField, bField, fieldPath, fieldPointer, changes)
+		}
+	}
+}
+
+// diffMap appends the changes from the mapping a to the mapping b entry by entry. The entries are compared in the
+// order of their keys.
+func diffMap(a reflect.Value, b reflect.Value, path []string, pointer []string, changes *Changes) {
+	keys := make([]reflect.Value, 0)
+	knownKeys := make(map[interface{}]struct{})
+	for _, m := range []reflect.Value{a, b} {
+		for _, key := range m.MapKeys() {
+			if _, present := knownKeys[key.Interface()]; !present {
+				knownKeys[key.Interface()] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface()) })
+
+	for _, key := range keys {
+		name := fmt.Sprint(key.Interface())
+		diffValue(mapIndex(a, key), mapIndex(b, key), appendPath(path, name), appendPointer(pointer, name), changes)
+	}
+}
+
+// diffSet appends the items removed from the list a and the items added to the list b.
+func diffSet(a reflect.Value, b reflect.Value, path []string, pointer []string, changes *Changes) {
+	for i := 0; i < a.Len(); i++ {
+		item := a.Index(i)
+		if !containsValue(b, item) {
+			*changes = append(*changes, newChange(ChangeKindRemoved, appendPath(path, formatValue(item)), appendPointer(pointer, strconv.Itoa(i)), i, item, reflect.Value{}))
+		}
+	}
+
+	for i := 0; i < b.Len(); i++ {
+		item := b.Index(i)
+		if !containsValue(a, item) {
+			*changes = append(*changes, newChange(ChangeKindAdded, appendPath(path, formatValue(item)), appendPointer(pointer, jsonPointerAppend), changeIndexNone, reflect.Value{}, item))
+		}
+	}
+}
+
+// diffKeyed appends the changes from the keyed list a to the keyed list b item by item. Items are identified by their
+// key.
+func diffKeyed(a reflect.Value, b reflect.Value, keyFunc func(item string) listKey, path []string, pointer []string, changes *Changes) {
+	matched := make([]bool, b.Len())
+	for i := 0; i < a.Len(); i++ {
+		key := keyFunc(a.Index(i).String())
+		itemPath, itemPointer := appendPath(path, key.String()), appendPointer(pointer, strconv.Itoa(i))
+
+		j := indexOfKey(b, key, keyFunc)
+		switch {
+		case j < 0:
+			*changes = append(*changes, newChange(ChangeKindRemoved, itemPath, itemPointer, i, a.Index(i), reflect.Value{}))
+		case !equalMerge3Value(a.Index(i), b.Index(j)):
+			matched[j] = true
+			*changes = append(*changes, newChange(ChangeKindChanged, itemPath, itemPointer, i, a.Index(i), b.Index(j)))
+		default:
+			matched[j] = true
+		}
+	}
+
+	for j := 0; j < b.Len(); j++ {
+		if !matched[j] && indexOfKey(a, keyFunc(b.Index(j).String()), keyFunc) < 0 {
+			key := keyFunc(b.Index(j).String())
+			*changes = append(*changes, newChange(ChangeKindAdded, appendPath(path, key.String()), appendPointer(pointer, jsonPointerAppend), changeIndexNone, reflect.Value{}, b.Index(j)))
+		}
+	}
+}
+
+// indexOfKey returns the index of the first item of the keyed list, whose key matches the passed key, or -1.
+func indexOfKey(list reflect.Value, key listKey, keyFunc func(item string) listKey) int {
+	for i := 0; i < list.Len(); i++ {
+		if keyFunc(list.Index(i).String()).matches(key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// newChange returns a change of the values a and b. Absent values are omitted.
+func newChange(kind ChangeKind, path []string, pointer []string, index int, a reflect.Value, b reflect.Value) *Change {
+	change := &Change{
+		Path:    strings.Join(path, pathSeparator),
+		Kind:    kind,
+		pointer: pointer,
+		index:   index,
+	}
+
+	if !isZero(a) {
+		change.From = cloneMerge3Value(a).Interface()
+	}
+	if !isZero(b) {
+		change.To = cloneMerge3Value(b).Interface()
+	}
+
+	return change
+}
+
+// cloneChangeValue returns a deep copy of the value of a change.
+func cloneChangeValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return cloneMerge3Value(reflect.ValueOf(value)).Interface()
+}
+
+// appendPointer returns a new JSON pointer of the tokens of the pointer and the passed token. The nil pointer of
+// extensions stays nil.
+func appendPointer(pointer []string, token string) []string {
+	if pointer == nil {
+		return nil
+	}
+	return appendPath(pointer, token)
+}
+
+// jsonPointer returns the JSON pointer defined by RFC 6901 of the passed tokens.
+func jsonPointer(tokens []string) string {
+	sb := new(strings.Builder)
+	for _, token := range tokens {
+		sb.WriteString("/")
+		sb.WriteString(jsonPointerReplacer.Replace(token))
+	}
+	return sb.String()
+}
@@ -0,0 +1,278 @@
+package dockerCompose_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDiff(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		a                 string
+		b                 string
+		expectedString    string
+		expectedJSONPatch string
+	}{
+		{
+			a: `services:
+  app:
+    image: app:1.0.0
+`,
+			b: `services:
+  app:
+    image: app:1.0.0
+`,
+			expectedString:    "",
+			expectedJSONPatch: `[]`,
+		},
+		{
+			a: `services:
+  app:
+    cap_add:
+    - NET_ADMIN
+    - SYS_TIME
+    environment:
+    - LOG_LEVEL=info
+    - TZ=UTC
+    - X=1
+    image: app:1.0.0
+    ports:
+    - 8080:80
+networks:
+  backend: {}
+`,
+			b: `services:
+  app:
+    cap_add:
+    - NET_ADMIN
+    - NET_RAW
+    environment:
+    - LOG_LEVEL=info
+    - X=2
+    image: app:1.1.0
+    ports:
+    - 8080:80
+    - 127.0.0.1:8443:443
+  db:
+    image: postgres:16
+`,
+			expectedString: `- networks: {"backend":{}}
+- services.app.cap_add.SYS_TIME: SYS_TIME
++ services.app.cap_add.NET_RAW: NET_RAW
+- services.app.environment.TZ: TZ=UTC
+~ services.app.environment.X: X=1 => X=2
+~ services.app.image: app:1.0.0 => app:1.1.0
++ services.app.ports.127.0.0.1:8443: 127.0.0.1:8443:443
++ services.db: {"image":"postgres:16"}
+`,
+			expectedJSONPatch: `[
+  {"op":"replace","path":"/services/app/environment/2","value":"X=2"},
+  {"op":"replace","path":"/services/app/image","value":"app:1.1.0"},
+  {"op":"remove","path":"/services/app/cap_add/1"},
+  {"op":"remove","path":"/services/app/environment/1"},
+  {"op":"remove","path":"/networks"},
+  {"op":"add","path":"/services/app/cap_add/-","value":"NET_RAW"},
+  {"op":"add","path":"/services/app/ports/-","value":"127.0.0.1:8443:443"},
+  {"op":"add","path":"/services/db","value":{"image":"postgres:16"}}
+]`,
+		},
+		{
+			a: `services:
+  app:
+    image: app:1.0.0
+x-owner: team-a
+`,
+			b: `services:
+  app:
+    image: app:1.1.0
+    x-debug: false
+x-owner: team-b
+`,
+			expectedString: `~ services.app.image: app:1.0.0 => app:1.1.0
++ services.app.x-debug: false
+~ x-owner: team-a => team-b
+`,
+			expectedJSONPatch: `[
+  {"op":"replace","path":"/services/app/image","value":"app:1.1.0"}
+]`,
+		},
+		{
+			a: `services:
+  app:
+    depends_on:
+    - db
+`,
+			b: `services:
+  app:
+    depends_on:
+      db:
+        condition: service_started
+`,
+			expectedString:    "",
+			expectedJSONPatch: `[]`,
+		},
+		{
+			a: `services:
+  app:
+    depends_on:
+    - cache
+    - db
+`,
+			b: `services:
+  app:
+    depends_on:
+      db:
+        condition: service_healthy
+        restart: true
+`,
+			expectedString: `- services.app.depends_on.cache: {"condition":"service_started"}
+~ services.app.depends_on.db.condition: service_started => service_healthy
++ services.app.depends_on.db.restart: true
+`,
+			expectedJSONPatch: `[
+  {"op":"replace","path":"/services/app/depends_on/db/condition","value":"service_healthy"},
+  {"op":"remove","path":"/services/app/depends_on/cache"},
+  {"op":"add","path":"/services/app/depends_on/db/restart","value":true}
+]`,
+		},
+	}
+
+	for i, testCase := range testCases {
+		a := dockerCompose.NewConfig()
+		require.NoError(yaml.Unmarshal([]byte(testCase.a), a), "TestCase %v", i)
+
+		b := dockerCompose.NewConfig()
+		require.NoError(yaml.Unmarshal([]byte(testCase.b), b), "TestCase %v", i)
+
+		changes := dockerCompose.Diff(a, b)
+		require.Equal(testCase.expectedString, changes.String(), "TestCase %v", i)
+
+		jsonPatch, err := json.Marshal(changes.JSONPatch())
+		require.NoError(err, "TestCase %v", i)
+		require.JSONEq(testCase.expectedJSONPatch, string(jsonPatch), "TestCase %v", i)
+
+		// The patch transforms the JSON representation of a into the one of b.
+		var jsonA, jsonB interface{}
+		require.NoError(unmarshalJSON(a, &jsonA), "TestCase %v", i)
+		require.NoError(unmarshalJSON(b, &jsonB), "TestCase %v", i)
+		patchedJSONA, err := applyJSONPatch(jsonA, jsonPatch)
+		require.NoError(err, "TestCase %v", i)
+		require.Equal(jsonB, patchedJSONA, "TestCase %v", i)
+
+		require.Empty(dockerCompose.Diff(b, b.Clone()), "TestCase %v", i)
+	}
+}
+
+func TestPatchOperation_MarshalJSON(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		patchOperation *dockerCompose.PatchOperation
+		expectedJSON   string
+	}{
+		{
+			patchOperation: &dockerCompose.PatchOperation{Op: "add", Path: "/x", Value: false},
+			expectedJSON:   `{"op":"add","path":"/x","value":false}`,
+		},
+		{
+			patchOperation: &dockerCompose.PatchOperation{Op: "replace", Path: "/x", Value: ""},
+			expectedJSON:   `{"op":"replace","path":"/x","value":""}`,
+		},
+		{
+			patchOperation: &dockerCompose.PatchOperation{Op: "remove", Path: "/x"},
+			expectedJSON:   `{"op":"remove","path":"/x"}`,
+		},
+	}
+
+	for i, testCase := range testCases {
+		b, err := json.Marshal(testCase.patchOperation)
+		require.NoError(err, "TestCase %v", i)
+		require.JSONEq(testCase.expectedJSON, string(b), "TestCase %v", i)
+	}
+}
+
+// unmarshalJSON decodes the JSON representation of the passed value into v.
+func unmarshalJSON(value interface{}, v interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// applyJSONPatch applies the add, remove and replace operations of the JSON Patch to the decoded JSON document.
+func applyJSONPatch(document interface{}, jsonPatch []byte) (interface{}, error) {
+	patchOperations := make([]*dockerCompose.PatchOperation, 0)
+	err := json.Unmarshal(jsonPatch, &patchOperations)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, patchOperation := range patchOperations {
+		tokens := strings.Split(patchOperation.Path, "/")[1:]
+		for i := range tokens {
+			tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(tokens[i])
+		}
+
+		document, err = applyJSONPatchOperation(document, tokens, patchOperation)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return document, nil
+}
+
+// applyJSONPatchOperation applies the operation to the value referenced by the tokens of the JSON pointer and returns
+// the modified document.
+func applyJSONPatchOperation(document interface{}, tokens []string, patchOperation *dockerCompose.PatchOperation) (interface{}, error) {
+	if len(tokens) <= 0 {
+		return patchOperation.Value, nil
+	}
+
+	switch v := document.(type) {
+	case map[string]interface{}:
+		child, present := v[tokens[0]]
+		switch {
+		case len(tokens) == 1 && patchOperation.Op == "remove":
+			delete(v, tokens[0])
+			return v, nil
+		case len(tokens) == 1 && patchOperation.Op == "add":
+			v[tokens[0]] = patchOperation.Value
+			return v, nil
+		case !present:
+			return nil, fmt.Errorf("path not found: %s", patchOperation.Path)
+		}
+
+		var err error
+		v[tokens[0]], err = applyJSONPatchOperation(child, tokens[1:], patchOperation)
+		return v, err
+	case []interface{}:
+		if len(tokens) == 1 && patchOperation.Op == "add" && tokens[0] == "-" {
+			return append(v, patchOperation.Value), nil
+		}
+
+		i, err := strconv.Atoi(tokens[0])
+		switch {
+		case err != nil:
+			return nil, err
+		case i < 0 || i >= len(v):
+			return nil, fmt.Errorf("index out of range: %s", patchOperation.Path)
+		case len(tokens) == 1 && patchOperation.Op == "remove":
+			return append(v[:i], v[i+1:]...), nil
+		}
+
+		v[i], err = applyJSONPatchOperation(v[i], tokens[1:], patchOperation)
+		return v, err
+	default:
+		return nil, fmt.Errorf("path not found: %s", patchOperation.Path)
+	}
+}
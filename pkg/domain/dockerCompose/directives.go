@@ -0,0 +1,224 @@
+package dockerCompose
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// yamlTagOverride replaces an inherited attribute wholesale instead of merging it.
+	yamlTagOverride string = "!override"
+
+	// yamlTagReset removes an inherited attribute.
+	yamlTagReset string = "!reset"
+)
+
+// mergeDirective is a !reset or !override YAML tag of a docker-compose file, which is applied when the docker-compose
+// file is merged into another one.
+type mergeDirective struct {
+	// item is the value of a sequence item tagged by !reset. It's nil, when the whole attribute is reset.
+	item     *string
+	override bool
+	path     []string
+}
+
+// collectMergeDirectives returns all !reset and !override directives of the passed node. Nodes tagged by !reset are
+// removed from the node and the tag of nodes tagged by !override is dropped, therefore the node can be decoded as
+// usual afterwards.
+func collectMergeDirectives(node *yaml.Node) []*mergeDirective {
+	mergeDirectives := make([]*mergeDirective, 0)
+
+	var walk func(node *yaml.Node, path []string)
+	walk = func(node *yaml.Node, path []string) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, path)
+			}
+		case yaml.MappingNode:
+			content := make([]*yaml.Node, 0, len(node.Content))
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				keyNode, valueNode := node.Content[i], node.Content[i+1]
+				valuePath := append(append(make([]string, 0, len(path)+1), path...), keyNode.Value)
+
+				switch valueNode.Tag {
+				case yamlTagReset:
+					mergeDirectives = append(mergeDirectives, &mergeDirective{path: valuePath})
+					continue
+				case yamlTagOverride:
+					mergeDirectives = append(mergeDirectives, &mergeDirective{path: valuePath, override: true})
+					valueNode.Tag = ""
+				default:
+					walk(valueNode, valuePath)
+				}
+
+				content = append(content, keyNode, valueNode)
+			}
+			node.Content = content
+		case yaml.SequenceNode:
+			content := make([]*yaml.Node, 0, len(node.Content))
+			for _, itemNode := range node.Content {
+				switch itemNode.Tag {
+				case yamlTagReset:
+					item := itemNode.Value
+					mergeDirectives = append(mergeDirectives, &mergeDirective{path: path, item: &item})
+					continue
+				case yamlTagOverride:
+					itemNode.Tag = ""
+				}
+
+				content = append(content, itemNode)
+			}
+			node.Content = content
+		}
+	}
+
+	walk(node, make([]string, 0))
+
+	return mergeDirectives
+}
+
+// applyResets removes all attributes of the config, which are reset by the passed config.
+func (c *Config) applyResets(config *Config, mc *mergeContext) {
+	for _, mergeDirective := range config.mergeDirectives {
+		switch {
+		case mergeDirective.override:
+			continue
+		case mergeDirective.item != nil:
+			removeItemAtPath(reflect.ValueOf(c), mergeDirective.path, *mergeDirective.item)
+			if mc.tracker != nil {
+				mc.tracker.removeItem(mergeDirective.path, *mergeDirective.item)
+			}
+		default:
+			before, _ := valueAtPath(reflect.ValueOf(c), mergeDirective.path)
+			before = mc.detach(before)
+			resetPath(reflect.ValueOf(c), mergeDirective.path)
+			mc.record(mergeDirective.path, before, reflect.Value{}, mergeDecisionApplied)
+		}
+	}
+}
+
+// applyOverrides replaces all attributes of the config by the attributes of the passed config, which are tagged by
+// !override.
+func (c *Config) applyOverrides(config *Config, mc *mergeContext) {
+	var source *Config
+	for _, mergeDirective := range config.mergeDirectives {
+		if !mergeDirective.override {
+			continue
+		}
+
+		// The overridden attributes are taken from a copy, otherwise the config would share them with the passed config.
+		if source == nil {
+			source = config.Clone()
+		}
+
+		before, _ := valueAtPath(reflect.ValueOf(c), mergeDirective.path)
+		before = mc.detach(before)
+		overridePath(reflect.ValueOf(c), reflect.ValueOf(source), mergeDirective.path)
+
+		src, _ := valueAtPath(reflect.ValueOf(source), mergeDirective.path)
+		mc.record(mergeDirective.path, before, src, mergeDecisionApplied)
+	}
+}
+
+// inlineDirectiveExtension is the extension field of a docker-compose file or service, which declares the strategies
+// to merge the docker-compose file or service.
+const inlineDirectiveExtension string = "x-dcmerge"
+
+// inlineDirective is the value of the extension field x-dcmerge. The strategy applies to all attributes of its scope,
+// the strategies of the fields to the attributes of the paths relative to its scope.
+type inlineDirective struct {
+	Strategy Strategy            `yaml:"strategy,omitempty"`
+	Fields   map[string]Strategy `yaml:"fields,omitempty"`
+}
+
+// collectInlineDirectives returns the policy declared by the x-dcmerge extension fields at top level and on each
+// service of the config. The extension fields are removed from the config. When no extension field is declared, nil
+// is returned.
+func collectInlineDirectives(c *Config) (*Policy, error) {
+	policy := NewPolicy()
+
+	err := policy.addInlineDirective(c.Extensions, make([]string, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if c.Services[name] == nil {
+			continue
+		}
+
+		err = policy.addInlineDirective(c.Services[name].Extensions, []string{"services", name})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(policy.rules) <= 0 {
+		return nil, nil
+	}
+
+	return policy, nil
+}
+
+// addInlineDirective adds the rules of the x-dcmerge extension field of the scope to the policy and removes the
+// extension field.
+func (p *Policy) addInlineDirective(extensions Extensions, scope []string) error {
+	value, present := extensions[inlineDirectiveExtension]
+	if !present {
+		return nil
+	}
+	delete(extensions, inlineDirectiveExtension)
+
+	node := new(yaml.Node)
+	err := node.Encode(value)
+	if err != nil {
+		return err
+	}
+
+	directive := new(inlineDirective)
+	err = node.Decode(directive)
+	if err != nil {
+		return fmt.Errorf("invalid %s of %s: %w", inlineDirectiveExtension, scopeName(scope), err)
+	}
+
+	if len(directive.Strategy) > 0 {
+		err = p.addRule(scope, directive.Strategy)
+		if err != nil {
+			return fmt.Errorf("invalid %s of %s: %w", inlineDirectiveExtension, scopeName(scope), err)
+		}
+	}
+
+	paths := make([]string, 0, len(directive.Fields))
+	for path := range directive.Fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		err = p.addRule(append(append(make([]string, 0), scope...), strings.Split(path, pathSeparator)...), directive.Fields[path])
+		if err != nil {
+			return fmt.Errorf("invalid %s of %s: %w", inlineDirectiveExtension, scopeName(scope), err)
+		}
+	}
+
+	return nil
+}
+
+// scopeName returns the readable name of the scope of an inline directive.
+func scopeName(scope []string) string {
+	if len(scope) <= 0 {
+		return "top level"
+	}
+	return strings.Join(scope, pathSeparator)
+}
@@ -0,0 +1,137 @@
+package dockerCompose
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a docker-compose file decoded from a YAML document. Besides the config, the document contains the
+// information of the YAML document, which is not part of the config, but required to merge the config like
+// dcmerge does: the anchors, the x-dcmerge and x-dcmerge-lock extension fields, the origins of all values and the
+// diagnostics of the docker-compose file.
+type Document struct {
+	Config *Config
+
+	anchors       Anchors
+	locks         [][]string
+	node          *yaml.Node
+	policy        *Policy
+	provenance    Provenance
+	source        string
+	versionErr    error
+	versionIssues VersionIssues
+}
+
+// Anchors returns the anchors of all mappings and sequences declared in the document.
+func (d *Document) Anchors() Anchors {
+	return d.anchors
+}
+
+// Clone returns a deep copy of the document. The YAML node, from which the document was decoded, is shared.
+func (d *Document) Clone() *Document {
+	if d == nil {
+		return nil
+	}
+
+	return &Document{
+		Config:        d.Config.Clone(),
+		anchors:       d.anchors.Clone(),
+		locks:         cloneLocks(d.locks),
+		node:          d.node,
+		policy:        d.policy.Clone(),
+		provenance:    d.provenance.Clone(),
+		source:        d.source,
+		versionErr:    d.versionErr,
+		versionIssues: d.versionIssues.Clone(),
+	}
+}
+
+// Include adds the configs, networks, secrets, services and volumes of the included document, which are not declared
+// yet, like the default merge. The origins of the added values, the anchors and the locks of the included document are
+// kept.
+func (d *Document) Include(document *Document) {
+	if document == nil {
+		return
+	}
+
+	// The origins must be determined before the config is modified by the merge.
+	d.Provenance()
+	document.Provenance()
+
+	d.Config.mergeConfig(document.Config, nil, StrategyDefault, &mergeTracker{target: d, source: document, origins: true})
+	d.anchors = mergeAnchors(d.anchors, document.anchors)
+	d.locks = append(d.locks, cloneLocks(document.locks)...)
+}
+
+// Provenance returns the origins of all leaf values of the document. The origins of a decoded document are determined
+// on the first call from the YAML document and the current config. It's nil, when the document was neither decoded from
+// a YAML document nor merged by a merger tracking the changes.
+func (d *Document) Provenance() Provenance {
+	if d.provenance == nil && d.node != nil {
+		d.provenance = newProvenance(d.node, d.Config)
+		d.SetSource(d.source)
+	}
+	return d.provenance
+}
+
+// Source returns the name of the source, from which the document was read.
+func (d *Document) Source() string {
+	return d.source
+}
+
+// SetSource sets the name of the source, from which the document was read. The source is also set for all origins
+// without source.
+func (d *Document) SetSource(source string) {
+	d.source = source
+	for _, origin := range d.provenance {
+		if len(origin.Source) <= 0 {
+			origin.Source = source
+		}
+	}
+}
+
+// UnmarshalYAML implements the UnmarshalYAML interface to customize the behavior when being unmarshaled into a YAML
+// document. The config is decoded like a config of its own. Additionally, the names of all anchors are recorded to be
+// able to declare them again when the config is marshaled. The x-dcmerge extension fields are removed and their
+// strategies applied when the document is merged. The paths of the x-dcmerge-lock extension field are locked for all
+// documents merged afterwards. Attributes, which are not supported by the declared version, are recorded as version
+// issues. The node is kept to validate the document against the JSON schema and to determine the origins of all values
+// on request.
+func (d *Document) UnmarshalYAML(value *yaml.Node) error {
+	if d.Config == nil {
+		d.Config = NewConfig()
+	}
+
+	err := value.Decode(d.Config)
+	if err != nil {
+		return err
+	}
+
+	policy, err := collectInlineDirectives(d.Config)
+	if err != nil {
+		return err
+	}
+	d.policy = d.policy.withRules(policy)
+
+	locks, err := collectLocks(d.Config)
+	if err != nil {
+		return err
+	}
+	d.locks = append(d.locks, locks...)
+
+	anchors, err := collectAnchors(value)
+	if err != nil {
+		return err
+	}
+	d.anchors = mergeAnchors(d.anchors, anchors)
+	d.node = value
+	d.versionIssues, d.versionErr = validateVersion(value, d.Config.Version)
+
+	return nil
+}
+
+// NewDocument returns a document of the passed config, which was not decoded from a YAML document.
+func NewDocument(config *Config) *Document {
+	return &Document{
+		Config: config,
+	}
+}
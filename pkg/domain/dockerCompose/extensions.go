@@ -0,0 +1,152 @@
+package dockerCompose
+
+import (
+	"reflect"
+	"strings"
+)
+
+// extensionPrefix is the prefix of all extension fields. Extension fields can be declared at top level and on every
+// object of a docker-compose file.
+const extensionPrefix string = "x-"
+
+// Extensions contains the extension fields of an object. The values are neither validated nor interpreted.
+type Extensions map[string]interface{}
+
+// Clone returns a deep copy of the extensions. Nested mappings and sequences are copied as well.
+func (e Extensions) Clone() Extensions {
+	if e == nil {
+		return nil
+	}
+
+	extensions := make(Extensions, len(e))
+	for key, value := range e {
+		extensions[key] = cloneExtensionValue(value)
+	}
+	return extensions
+}
+
+func cloneExtensionValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return map[string]interface{}(Extensions(v).Clone())
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i := range v {
+			values[i] = cloneExtensionValue(v[i])
+		}
+		return values
+	default:
+		return value
+	}
+}
+
+// Equal returns true if the passed extensions are equal. Nil and empty extensions are equal.
+func (e Extensions) Equal(extensions Extensions) bool {
+	switch {
+	case len(e) == 0 && len(extensions) == 0:
+		return true
+	case len(e) != len(extensions):
+		return false
+	default:
+		return reflect.DeepEqual(map[string]interface{}(e), map[string]interface{}(extensions))
+	}
+}
+
+// mergeExistingWinExtensions adds only missing extension fields. Nested mappings are merged recursively.
+func mergeExistingWinExtensions(e Extensions, extensions Extensions) Extensions {
+	switch {
+	case len(extensions) <= 0:
+		return e
+	case e == nil:
+		e = make(Extensions)
+	}
+
+	for key, value := range extensions {
+		existingValue, present := e[key]
+		switch {
+		case !present:
+			e[key] = cloneExtensionValue(value)
+		default:
+			e[key] = mergeExistingWinExtensionValue(existingValue, value)
+		}
+	}
+
+	return e
+}
+
+func mergeExistingWinExtensionValue(existingValue, value interface{}) interface{} {
+	existingMap, existingIsMap := existingValue.(map[string]interface{})
+	valueMap, valueIsMap := value.(map[string]interface{})
+	if !existingIsMap || !valueIsMap {
+		return existingValue
+	}
+	return map[string]interface{}(mergeExistingWinExtensions(existingMap, valueMap))
+}
+
+// mergeLastWinExtensions adds or overwrites extension fields. Nested mappings are merged recursively.
+func mergeLastWinExtensions(e Extensions, extensions Extensions) Extensions {
+	switch {
+	case len(extensions) <= 0:
+		return e
+	case e == nil:
+		e = make(Extensions)
+	}
+
+	for key, value := range extensions {
+		existingValue, present := e[key]
+		switch {
+		case !present:
+			e[key] = cloneExtensionValue(value)
+		default:
+			e[key] = mergeLastWinExtensionValue(existingValue, value)
+		}
+	}
+
+	return e
+}
+
+func mergeLastWinExtensionValue(existingValue, value interface{}) interface{} {
+	existingMap, existingIsMap := existingValue.(map[string]interface{})
+	valueMap, valueIsMap := value.(map[string]interface{})
+	if !existingIsMap || !valueIsMap {
+		return cloneExtensionValue(value)
+	}
+	return map[string]interface{}(mergeLastWinExtensions(existingMap, valueMap))
+}
+
+// pruneExtensions removes recursively all fields from Extensions which are not prefixed by x-. The YAML decoder
+// collects all unknown fields of an object in the inline Extensions map, but only extension fields should be kept.
+func pruneExtensions(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			pruneExtensions(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+
+			field := v.Field(i)
+			if extensions, ok := field.Interface().(Extensions); ok {
+				for key := range extensions {
+					if !strings.HasPrefix(key, extensionPrefix) {
+						delete(extensions, key)
+					}
+				}
+				continue
+			}
+
+			pruneExtensions(field)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			pruneExtensions(v.MapIndex(key))
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			pruneExtensions(v.Index(i))
+		}
+	}
+}
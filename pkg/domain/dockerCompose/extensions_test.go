@@ -0,0 +1,127 @@
+package dockerCompose_test
+
+import (
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig_UnmarshalYAMLExtensions(t *testing.T) {
+	require := require.New(t)
+
+	s := `x-common: &common
+  image: library/app:latest
+  environment:
+  - TZ=UTC
+services:
+  app:
+    <<: *common
+    unknown: attribute
+    x-team: backend
+networks:
+  default:
+    x-description: Default network
+`
+
+	expectedConfig := &dockerCompose.Config{
+		Extensions: dockerCompose.Extensions{
+			"x-common": map[string]interface{}{
+				"image":       "library/app:latest",
+				"environment": []interface{}{"TZ=UTC"},
+			},
+		},
+		Networks: map[string]*dockerCompose.Network{
+			"default": {
+				Extensions: dockerCompose.Extensions{
+					"x-description": "Default network",
+				},
+			},
+		},
+		Services: map[string]*dockerCompose.Service{
+			"app": {
+				Environments: []string{"TZ=UTC"},
+				Image:        "library/app:latest",
+				Extensions: dockerCompose.Extensions{
+					"x-team": "backend",
+				},
+			},
+		},
+	}
+
+	actualConfig := dockerCompose.NewConfig()
+	require.NoError(yaml.Unmarshal([]byte(s), actualConfig))
+	require.True(expectedConfig.Equal(actualConfig))
+}
+
+func TestConfig_MergeExtensions(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		configA             *dockerCompose.Config
+		configB             *dockerCompose.Config
+		expectedExistingWin *dockerCompose.Config
+		expectedLastWin     *dockerCompose.Config
+		expectedDefault     *dockerCompose.Config
+	}{
+		{
+			configA: &dockerCompose.Config{
+				Extensions: dockerCompose.Extensions{
+					"x-a": "a",
+					"x-c": map[string]interface{}{"foo": "a"},
+				},
+			},
+			configB: &dockerCompose.Config{
+				Extensions: dockerCompose.Extensions{
+					"x-a": "b",
+					"x-b": "b",
+					"x-c": map[string]interface{}{"foo": "b", "bar": "b"},
+				},
+			},
+			expectedDefault: &dockerCompose.Config{
+				Extensions: dockerCompose.Extensions{
+					"x-a": "a",
+					"x-b": "b",
+					"x-c": map[string]interface{}{"foo": "a"},
+				},
+			},
+			expectedExistingWin: &dockerCompose.Config{
+				Extensions: dockerCompose.Extensions{
+					"x-a": "a",
+					"x-b": "b",
+					"x-c": map[string]interface{}{"foo": "a", "bar": "b"},
+				},
+			},
+			expectedLastWin: &dockerCompose.Config{
+				Extensions: dockerCompose.Extensions{
+					"x-a": "b",
+					"x-b": "b",
+					"x-c": map[string]interface{}{"foo": "b", "bar": "b"},
+				},
+			},
+		},
+	}
+
+	copyConfig := func(config *dockerCompose.Config) *dockerCompose.Config {
+		b, err := yaml.Marshal(config)
+		require.NoError(err)
+		copiedConfig := dockerCompose.NewConfig()
+		require.NoError(yaml.Unmarshal(b, copiedConfig))
+		return copiedConfig
+	}
+
+	for i, testCase := range testCases {
+		actualConfig := copyConfig(testCase.configA)
+		actualConfig.Merge(copyConfig(testCase.configB))
+		require.True(testCase.expectedDefault.Equal(actualConfig), "TestCase %v", i)
+
+		actualConfig = copyConfig(testCase.configA)
+		actualConfig.MergeExistingWin(copyConfig(testCase.configB))
+		require.True(testCase.expectedExistingWin.Equal(actualConfig), "TestCase %v", i)
+
+		actualConfig = copyConfig(testCase.configA)
+		actualConfig.MergeLastWin(copyConfig(testCase.configB))
+		require.True(testCase.expectedLastWin.Equal(actualConfig), "TestCase %v", i)
+	}
+}
@@ -0,0 +1,194 @@
+package dockerCompose
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mergeKey is the key of a YAML mapping, which merges the entries of other mappings.
+const mergeKey string = "<<"
+
+// sortedList is a list of key value pairs, whose items are sorted by their key.
+type sortedList struct {
+	pattern   []string
+	delimiter string
+}
+
+// sortedLists contains the lists of key value pairs, whose items are sorted by their key.
+var sortedLists = []*sortedList{
+	{pattern: []string{"networks", "*", "labels"}, delimiter: labelDelimiter},
+	{pattern: []string{"services", "*", "environment"}, delimiter: environmentDelimiter},
+	{pattern: []string{"services", "*", "labels"}, delimiter: labelDelimiter},
+	{pattern: []string{"volumes", "*", "labels"}, delimiter: labelDelimiter},
+}
+
+// portLists contains the lists of ports, whose items are normalized.
+var portLists = [][]string{
+	{"services", "*", "ports"},
+}
+
+// Format rewrites the passed node of a docker-compose file into its canonical form. The keys of all mappings are sorted,
+// the ports are normalized to the quoted short syntax and environment variables and labels are sorted by their key.
+// The order of all other lists is kept. Comments are moved with their nodes.
+//
+// The top-level extensions x-* are kept in front of all other keys in their original order, because they commonly
+// declare the anchors of the document. Each other entry, which declares an anchor, is kept in front of the entries
+// referring to it, so that the document is still valid.
+func Format(node *yaml.Node) {
+	if node == nil {
+		return
+	}
+
+	formatNode(node, make([]string, 0))
+}
+
+// formatNode formats the node and all its children. The path is the path of the node inside the docker-compose file.
+func formatNode(node *yaml.Node, path []string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			formatNode(child, path)
+		}
+	case yaml.MappingNode:
+		sortMappingKeys(node, len(path) <= 0)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			switch {
+			case keyNode.Value == mergeKey:
+				// yaml.v3 writes the merge key with an explicit !!merge tag, when the tag of the node is set
+				keyNode.Tag = ""
+				formatNode(valueNode, path)
+			default:
+				formatNode(valueNode, appendPath(path, keyNode.Value))
+			}
+		}
+	case yaml.SequenceNode:
+		for _, sortedList := range sortedLists {
+			if len(sortedList.pattern) == len(path) && matchesPattern(sortedList.pattern, path) {
+				sortSequenceByKey(node, sortedList.delimiter)
+			}
+		}
+
+		for _, pattern := range portLists {
+			if len(pattern) == len(path) && matchesPattern(pattern, path) {
+				normalizePorts(node)
+			}
+		}
+
+		for i, itemNode := range node.Content {
+			formatNode(itemNode, appendPath(path, strconv.Itoa(i)))
+		}
+	}
+}
+
+// sortMappingKeys sorts the entries of the mapping by their key. Merge keys are kept in front of all other keys,
+// because their entries are overwritten by the following keys. The extensions of the top-level mapping are kept in
+// front as well. Entries declaring an anchor are moved in front of the entries referring to it.
+func sortMappingKeys(node *yaml.Node, topLevel bool) {
+	pairs := make([][]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, node.Content[i:i+2])
+	}
+
+	leading := func(keyNode *yaml.Node) bool {
+		return keyNode.Value == mergeKey || (topLevel && strings.HasPrefix(keyNode.Value, extensionPrefix))
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		switch {
+		case leading(pairs[i][0]):
+			return !leading(pairs[j][0])
+		case leading(pairs[j][0]):
+			return false
+		default:
+			return pairs[i][0].Value < pairs[j][0].Value
+		}
+	})
+
+	pairs = moveAnchorsBeforeAliases(pairs)
+
+	content := make([]*yaml.Node, 0, len(node.Content))
+	for _, pair := range pairs {
+		content = append(content, pair...)
+	}
+	node.Content = content
+}
+
+// moveAnchorsBeforeAliases moves each entry, which declares an anchor, in front of the first entry referring to it.
+// Aliases always refer to a node declared before, so the original order of the entries has no cycles.
+func moveAnchorsBeforeAliases(pairs [][]*yaml.Node) [][]*yaml.Node {
+	for i := 0; i < len(pairs); i++ {
+		for j := i + 1; j < len(pairs); j++ {
+			if !refersTo(pairs[i][1], pairs[j][1]) {
+				continue
+			}
+
+			pair := pairs[j]
+			copy(pairs[i+1:j+1], pairs[i:j])
+			pairs[i] = pair
+
+			// The moved entry may refer to anchors of the following entries as well.
+			i--
+			break
+		}
+	}
+	return pairs
+}
+
+// refersTo returns true if an alias of the node refers to an anchor declared by the other node.
+func refersTo(node *yaml.Node, other *yaml.Node) bool {
+	anchors := make(map[*yaml.Node]struct{})
+	walkNodes(other, func(node *yaml.Node) {
+		if len(node.Anchor) > 0 {
+			anchors[node] = struct{}{}
+		}
+	})
+
+	if len(anchors) <= 0 {
+		return false
+	}
+
+	found := false
+	walkNodes(node, func(node *yaml.Node) {
+		if node.Kind == yaml.AliasNode {
+			_, present := anchors[node.Alias]
+			found = found || present
+		}
+	})
+	return found
+}
+
+// walkNodes calls the function for the node and all its children. The nodes of aliases are not followed.
+func walkNodes(node *yaml.Node, fn func(node *yaml.Node)) {
+	fn(node)
+	for _, child := range node.Content {
+		walkNodes(child, fn)
+	}
+}
+
+// sortSequenceByKey sorts the items of a list of key value pairs like FOO=bar by their key. Items with the same key
+// keep their order.
+func sortSequenceByKey(node *yaml.Node, delimiter string) {
+	sort.SliceStable(node.Content, func(i, j int) bool {
+		keyI, _ := splitStringInKeyValue(node.Content[i].Value, delimiter)
+		keyJ, _ := splitStringInKeyValue(node.Content[j].Value, delimiter)
+		return keyI < keyJ
+	})
+}
+
+// normalizePorts rewrites the ports of the short syntax by their normalized and quoted form. Ports of the long syntax
+// are kept.
+func normalizePorts(node *yaml.Node) {
+	for _, itemNode := range node.Content {
+		if itemNode.Kind != yaml.ScalarNode {
+			continue
+		}
+
+		itemNode.Value = string(Port(itemNode.Value).normalize())
+		itemNode.Tag = "!!str"
+		itemNode.Style = yaml.DoubleQuotedStyle
+	}
+}
@@ -0,0 +1,155 @@
+package dockerCompose_test
+
+import (
+	"bytes"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFormat(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		s              string
+		expectedResult string
+	}{
+		{
+			s: `version: "3.8"
+# Services of the application
+services:
+  web:
+    ports:
+    - 0.0.0.0:443:8443/tcp
+    - 127.0.0.1:80:8080
+    - 53:53/udp
+    - 9090
+    image: nginx # pinned by renovate
+  app:
+    labels:
+    - traefik.enable=true
+    - com.example.team=backend
+    environment:
+    # Log level of the application
+    - LOG_LEVEL=debug
+    - DB_HOST=db
+    command:
+    - serve
+    - --verbose
+    image: app
+`,
+			expectedResult: `# Services of the application
+services:
+  app:
+    command:
+      - serve
+      - --verbose
+    environment:
+      - DB_HOST=db
+      # Log level of the application
+      - LOG_LEVEL=debug
+    image: app
+    labels:
+      - com.example.team=backend
+      - traefik.enable=true
+  web:
+    image: nginx # pinned by renovate
+    ports:
+      - "443:8443"
+      - "127.0.0.1:80:8080"
+      - "53:53/udp"
+      - "9090"
+version: "3.8"
+`,
+		},
+		{
+			s: `x-defaults: &defaults
+  restart: always
+  image: app
+services:
+  worker:
+    <<: *defaults
+    command: work
+  api: *defaults
+`,
+			expectedResult: `x-defaults: &defaults
+  image: app
+  restart: always
+services:
+  api: *defaults
+  worker:
+    <<: *defaults
+    command: work
+`,
+		},
+		{
+			s: `# Shared settings of all services
+x-logging: &logging
+  # Rotate the logs
+  options:
+    max-size: 10m
+  driver: json-file
+x-app: &app
+  image: app
+  logging: *logging
+volumes:
+  data: {}
+services:
+  worker:
+    command: work
+    <<: *app
+  web: &web
+    image: nginx
+    logging: *logging
+  proxy: *web
+`,
+			expectedResult: `# Shared settings of all services
+x-logging: &logging
+  driver: json-file
+  # Rotate the logs
+  options:
+    max-size: 10m
+x-app: &app
+  image: app
+  logging: *logging
+services:
+  web: &web
+    image: nginx
+    logging: *logging
+  proxy: *web
+  worker:
+    <<: *app
+    command: work
+volumes:
+  data: {}
+`,
+		},
+	}
+
+	for i, testCase := range testCases {
+		node := new(yaml.Node)
+		require.NoError(yaml.Unmarshal([]byte(testCase.s), node), "TestCase %v", i)
+
+		dockerCompose.Format(node)
+
+		buffer := new(bytes.Buffer)
+		yamlEncoder := yaml.NewEncoder(buffer)
+		yamlEncoder.SetIndent(2)
+		require.NoError(yamlEncoder.Encode(node), "TestCase %v", i)
+		require.Equal(testCase.expectedResult, buffer.String(), "TestCase %v", i)
+
+		// The canonical form is formatted again without changes.
+		node = new(yaml.Node)
+		require.NoError(yaml.Unmarshal(buffer.Bytes(), node), "TestCase %v", i)
+
+		dockerCompose.Format(node)
+
+		formattedBuffer := new(bytes.Buffer)
+		yamlEncoder = yaml.NewEncoder(formattedBuffer)
+		yamlEncoder.SetIndent(2)
+		require.NoError(yamlEncoder.Encode(node), "TestCase %v", i)
+		require.Equal(buffer.String(), formattedBuffer.String(), "TestCase %v", i)
+	}
+}
@@ -0,0 +1,227 @@
+package dockerCompose
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// lockExtension is the top-level extension field of a docker-compose file, which declares the paths of the locked
+// attributes.
+const lockExtension string = "x-dcmerge-lock"
+
+// LockViolation is a locked attribute, whose value declared by a source differs from the locked value. An empty value
+// marks an attribute, which was removed by the source or not declared before.
+type LockViolation struct {
+	Path        string `json:"path"`
+	Source      string `json:"source"`
+	LockedValue string `json:"lockedValue"`
+	Value       string `json:"value"`
+}
+
+// LockViolations is a list of violated locked attributes in the order of the merge.
+type LockViolations []*LockViolation
+
+// Clone returns a deep copy of the lock violations.
+func (lv LockViolations) Clone() LockViolations {
+	return LockViolations(CloneSlice([]*LockViolation(lv)))
+}
+
+// Clone returns a copy of the lock violation.
+func (lv *LockViolation) Clone() *LockViolation {
+	return clonePointer(lv)
+}
+
+// String returns a readable report of all lock violations.
+func (lv LockViolations) String() string {
+	sb := new(strings.Builder)
+	for _, violation := range lv {
+		fmt.Fprintf(sb, "%s:\n", violation.Path)
+		fmt.Fprintf(sb, "  locked: %s\n", violation.LockedValue)
+		fmt.Fprintf(sb, "  %s: %s\n", violation.Source, violation.Value)
+	}
+	return sb.String()
+}
+
+// collectLocks returns the paths of the x-dcmerge-lock extension field of the config. The extension field is removed
+// from the config.
+func collectLocks(c *Config) ([][]string, error) {
+	value, present := c.Extensions[lockExtension]
+	if !present {
+		return nil, nil
+	}
+	delete(c.Extensions, lockExtension)
+
+	paths, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a list of paths", lockExtension)
+	}
+
+	locks := make([][]string, 0, len(paths))
+	for _, path := range paths {
+		name, ok := path.(string)
+		if !ok || len(name) <= 0 {
+			return nil, fmt.Errorf("%s must be a list of paths", lockExtension)
+		}
+		locks = append(locks, strings.Split(name, pathSeparator))
+	}
+
+	return locks, nil
+}
+
+// lockCheck compares the values declared by a merged document with the locked values of the document merged into.
+// Each declared value, which differs from the locked value, is a lock violation, whatever the strategy merges.
+type lockCheck struct {
+	locks [][]string
+
+	// lockedValues contains the leaf values of the locked attributes before the merge.
+	lockedValues map[string]string
+
+	// values contains the values of the locked attributes declared by the merged document. An empty value marks a
+	// removed attribute.
+	values map[string]string
+}
+
+// relates returns true if the attribute of the passed path is locked or contains locked attributes.
+func (lc *lockCheck) relates(path []string) bool {
+	for _, lock := range lc.locks {
+		n := len(lock)
+		if len(path) < n {
+			n = len(path)
+		}
+
+		if matchesPattern(lock[:n], path[:n]) {
+			return true
+		}
+	}
+	return false
+}
+
+// check records the value of the passed path declared by the merged document, when the path is locked.
+func (lc *lockCheck) check(path string, value string) {
+	if matchesLock(lc.locks, strings.Split(path, pathSeparator)) == nil || !pinnedByLock(lc.lockedValues, lc.locks, path) {
+		return
+	}
+	lc.values[path] = value
+}
+
+// lockViolations returns the declared values, which differ from the locked values, ordered by their path.
+func (lc *lockCheck) lockViolations(source string) LockViolations {
+	paths := make([]string, 0, len(lc.values))
+	for path := range lc.values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	lockViolations := make(LockViolations, 0)
+	for _, path := range paths {
+		lockedValue, value := lc.lockedValues[path], lc.values[path]
+		if lockedValue == value {
+			continue
+		}
+
+		lockViolations = append(lockViolations, &LockViolation{
+			Path:        path,
+			Source:      source,
+			LockedValue: lockedValue,
+			Value:       value,
+		})
+	}
+	return lockViolations
+}
+
+// collectLockedValues returns the leaf values of the config, which are locked by one of the passed locks. Only the
+// locked attributes are walked.
+func collectLockedValues(c *Config, locks [][]string) map[string]string {
+	lockedValues := make(map[string]string)
+	for _, lock := range locks {
+		walkPattern(reflect.ValueOf(c), lock, make([]string, 0), func(path []string, v reflect.Value) {
+			for leafPath, value := range leafValuesAt(v, path) {
+				if matchesLock(locks, strings.Split(leafPath, pathSeparator)) != nil {
+					lockedValues[leafPath] = value
+				}
+			}
+		})
+	}
+	return lockedValues
+}
+
+// walkPattern calls the function for each attribute, whose path matches the pattern. Wildcards are resolved for the
+// entries of mappings. For other attributes, for example the items of a list, the function is called for the nearest
+// attribute, which contains all matching attributes.
+func walkPattern(v reflect.Value, pattern []string, path []string, fn func(path []string, v reflect.Value)) {
+	if len(pattern) <= 0 {
+		fn(path, v)
+		return
+	}
+
+	value, ok := indirect(v)
+	if !ok {
+		return
+	}
+
+	isMapping := value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String
+	switch {
+	case isMapping && pattern[0] == pathWildcard:
+		for _, key := range value.MapKeys() {
+			walkPattern(value.MapIndex(key), pattern[1:], appendPath(path, key.String()), fn)
+		}
+	case pattern[0] != pathWildcard:
+		child, ok := childByName(value, pattern[0])
+		switch {
+		case ok:
+			walkPattern(child, pattern[1:], appendPath(path, pattern[0]), fn)
+		case !isMapping:
+			fn(path, v)
+		}
+	default:
+		fn(path, v)
+	}
+}
+
+// newLockCheck returns a check of the passed locks, which compares the values of a merged document with the values of
+// the locked attributes of the config.
+func newLockCheck(c *Config, locks [][]string) *lockCheck {
+	return &lockCheck{
+		locks:        locks,
+		lockedValues: collectLockedValues(c, locks),
+		values:       make(map[string]string),
+	}
+}
+
+// pinnedByLock returns true if the attribute matched by the lock of the passed path was declared before. Locked
+// attributes, which were not declared before, can be declared by any source.
+func pinnedByLock(lockedValues map[string]string, locks [][]string, path string) bool {
+	segments := strings.Split(path, pathSeparator)
+	scope := strings.Join(segments[:len(matchesLock(locks, segments))], pathSeparator)
+	for lockedPath := range lockedValues {
+		if lockedPath == scope || strings.HasPrefix(lockedPath, scope+pathSeparator) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesLock returns the first lock, which matches the path or a parent of the path. When no lock matches, nil is
+// returned.
+func matchesLock(locks [][]string, path []string) []string {
+	for _, lock := range locks {
+		if len(lock) <= len(path) && matchesPattern(lock, path) {
+			return lock
+		}
+	}
+	return nil
+}
+
+func cloneLocks(locks [][]string) [][]string {
+	if locks == nil {
+		return nil
+	}
+
+	clonedLocks := make([][]string, len(locks))
+	for i := range locks {
+		clonedLocks[i] = cloneSlice(locks[i])
+	}
+	return clonedLocks
+}
@@ -0,0 +1,129 @@
+package dockerCompose_test
+
+import (
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMerger_LockViolations(t *testing.T) {
+	require := require.New(t)
+
+	base := `services:
+  app:
+    cap_drop:
+    - ALL
+    image: example.local/app@sha256:0123
+    read_only: true
+  db:
+    image: postgres:16
+x-dcmerge-lock:
+- services.*.cap_drop
+- services.app.image
+- services.app.read_only
+- services.app.security_opt
+`
+
+	testCases := []struct {
+		strategy           dockerCompose.Strategy
+		locks              []string
+		overlay            string
+		expectedViolations dockerCompose.LockViolations
+	}{
+		{
+			strategy: dockerCompose.StrategyLastWin,
+			overlay: `services:
+  app:
+    environment:
+    - LOG_LEVEL=debug
+    security_opt:
+    - no-new-privileges:true
+  db:
+    cap_drop:
+    - NET_RAW
+    image: postgres:17
+`,
+			expectedViolations: dockerCompose.LockViolations{},
+		},
+		{
+			strategy: dockerCompose.StrategyLastWin,
+			overlay: `services:
+  app:
+    cap_drop:
+    - NET_RAW
+    image: example.local/app:latest
+`,
+			expectedViolations: dockerCompose.LockViolations{
+				{Path: "services.app.cap_drop.NET_RAW", Source: "overlay.yml", LockedValue: "", Value: "NET_RAW"},
+				{Path: "services.app.image", Source: "overlay.yml", LockedValue: "example.local/app@sha256:0123", Value: "example.local/app:latest"},
+			},
+		},
+		{
+			strategy: dockerCompose.StrategyExistingWin,
+			overlay: `services:
+  app:
+    image: example.local/app:latest
+    read_only: true
+`,
+			expectedViolations: dockerCompose.LockViolations{
+				{Path: "services.app.image", Source: "overlay.yml", LockedValue: "example.local/app@sha256:0123", Value: "example.local/app:latest"},
+			},
+		},
+		{
+			strategy: dockerCompose.StrategyDefault,
+			overlay: `services:
+  app:
+    cap_drop:
+    - NET_RAW
+  cache:
+    cap_drop:
+    - NET_RAW
+`,
+			expectedViolations: dockerCompose.LockViolations{
+				{Path: "services.app.cap_drop.NET_RAW", Source: "overlay.yml", LockedValue: "", Value: "NET_RAW"},
+			},
+		},
+		{
+			strategy: dockerCompose.StrategyLastWin,
+			overlay: `services:
+  app:
+    cap_drop: !reset []
+`,
+			expectedViolations: dockerCompose.LockViolations{
+				{Path: "services.app.cap_drop.ALL", Source: "overlay.yml", LockedValue: "ALL", Value: ""},
+			},
+		},
+		{
+			strategy: dockerCompose.StrategyLastWin,
+			locks:    []string{"services.db.image"},
+			overlay: `services:
+  db:
+    image: postgres:17
+`,
+			expectedViolations: dockerCompose.LockViolations{
+				{Path: "services.db.image", Source: "overlay.yml", LockedValue: "postgres:16", Value: "postgres:17"},
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		baseDocument := dockerCompose.NewDocument(dockerCompose.NewConfig())
+		require.NoError(yaml.Unmarshal([]byte(base), baseDocument), "TestCase %v", i)
+		baseDocument.SetSource("base.yml")
+
+		overlayDocument := dockerCompose.NewDocument(dockerCompose.NewConfig())
+		require.NoError(yaml.Unmarshal([]byte(testCase.overlay), overlayDocument), "TestCase %v", i)
+		overlayDocument.SetSource("overlay.yml")
+
+		merger := dockerCompose.NewMerger()
+		merger.Lock(testCase.locks...)
+		require.NoError(merger.Merge(baseDocument, testCase.strategy), "TestCase %v", i)
+		require.NoError(merger.Merge(overlayDocument, testCase.strategy), "TestCase %v", i)
+
+		result := merger.Result()
+		require.Equal(testCase.expectedViolations, result.LockViolations, "TestCase %v", i)
+		require.NotContains(result.Document.Config.Extensions, "x-dcmerge-lock", "TestCase %v", i)
+	}
+}
@@ -0,0 +1,850 @@
+package dockerCompose
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// mergeTag is the name of the struct tag, which declares how an attribute is merged by the existing-win and last-win
+// strategy. The following values are supported:
+//
+//   - overwrite: The last-win strategy overwrites the attribute even by its zero value, for example external: false.
+//   - replace: The attribute is replaced as a whole instead of merged, for example the command of a service.
+//   - set: The items of the list are merged as set. Empty items are skipped.
+//   - keyed=<key>: The items of the list are identified by a key. The existing-win strategy adds only items with an
+//     unknown key, the last-win strategy replaces items with the same key. See listKeyFuncs for the supported keys.
+//   - -: The attribute is not merged, but still compared and copied.
+//
+// Attributes without a merge tag are merged by their type. Scalars are set when they are undefined (existing-win) or
+// declared (last-win), objects are merged attribute by attribute, mappings of objects entry by entry, lists as set and
+// extension fields key by key. The default strategy only adds missing entries of mappings and extension fields.
+const mergeTag string = "merge"
+
+// composeMergeTag is the name of the struct tag, which declares how the compose-compatible strategy merges an
+// attribute, when it differs from the merge tag. The values of the merge tag are supported.
+//
+// The compose-compatible strategy merges like the last-win strategy, except that items of keyed lists are replaced at
+// their position and extension fields are replaced as a whole.
+const composeMergeTag string = "compose"
+
+type mergeKind int
+
+const (
+	mergeKindValue mergeKind = iota
+	mergeKindOverwrite
+	mergeKindReplace
+	mergeKindSet
+	mergeKindKeyed
+	mergeKindObject
+	mergeKindObjects
+	mergeKindExtensions
+	mergeKindIgnore
+)
+
+// listKey identifies an item of a keyed list. An empty scope matches any scope, for example a published port without
+// a host IP matches the same port published on any host IP.
+type listKey struct {
+	name  string
+	scope string
+}
+
+// matches returns true if both keys identify the same item.
+func (lk listKey) matches(key listKey) bool {
+	return lk.name == key.name && (len(lk.scope) <= 0 || len(key.scope) <= 0 || lk.scope == key.scope)
+}
+
+// String returns the key as declared in the item, for example 0.0.0.0:8080 for a published port.
+func (lk listKey) String() string {
+	if len(lk.scope) <= 0 {
+		return lk.name
+	}
+	return fmt.Sprintf("%s%s%s", lk.scope, portDelimiter, lk.name)
+}
+
+// listKeyFuncs maps the keys of the merge tag keyed=<key> to a function returning the key of an item.
+var listKeyFuncs = map[string]func(item string) listKey{
+	// name is the name of an environment variable or label, for example FOO of FOO=bar.
+	"name": func(item string) listKey {
+		name, _ := splitStringInKeyValue(item, environmentDelimiter)
+		return listKey{name: name}
+	},
+
+	// published is the published port of a port mapping scoped by its host IP, for example 8080 of 0.0.0.0:8080:80.
+	"published": func(item string) listKey {
+		matches := regExpPort.FindStringSubmatch(item)
+		if len(matches) <= 0 {
+			return listKey{name: item}
+		}
+		return listKey{
+			name:  matches[regExpPort.SubexpIndex("srcPort")],
+			scope: matches[regExpPort.SubexpIndex("srcIP")],
+		}
+	},
+
+	// mapping is the host IP, published port, container port and protocol of a port mapping, for example
+	// 0.0.0.0:8080:80/tcp of 8080:80.
+	"mapping": func(item string) listKey {
+		matches := regExpPort.FindStringSubmatch(item)
+		if len(matches) <= 0 {
+			return listKey{name: item}
+		}
+
+		srcIP := matches[regExpPort.SubexpIndex("srcIP")]
+		if len(srcIP) <= 0 {
+			srcIP = "0.0.0.0"
+		}
+
+		protocol := matches[regExpPort.SubexpIndex("protocol")]
+		if len(protocol) <= 0 {
+			protocol = "tcp"
+		}
+
+		return listKey{name: fmt.Sprintf("%s:%s:%s/%s", srcIP, matches[regExpPort.SubexpIndex("srcPort")], matches[regExpPort.SubexpIndex("dstPort")], protocol)}
+	},
+
+	// target is the path of a volume inside the container, for example /data of ./data:/data:ro.
+	"target": func(item string) listKey {
+		_, dest, _, err := splitStringInVolume(item)
+		if err != nil {
+			return listKey{name: item}
+		}
+		return listKey{name: dest}
+	},
+}
+
+type fieldPlan struct {
+	index     int
+	name      string
+	kind      mergeKind
+	keyFunc   func(item string) listKey
+	reference bool
+
+	// transparent marks inline fields and helper fields without YAML tag, which are not part of the path of their
+	// attributes.
+	transparent bool
+
+	// composeKind and composeKeyFunc declare the merge of the compose-compatible strategy.
+	composeKind    mergeKind
+	composeKeyFunc func(item string) listKey
+}
+
+// structPlans caches the merge plan of each struct type.
+var structPlans sync.Map
+
+// planOf returns the merge plan of all exported fields of the struct type.
+func planOf(t reflect.Type) []*fieldPlan {
+	if plan, ok := structPlans.Load(t); ok {
+		return plan.([]*fieldPlan)
+	}
+
+	plan := make([]*fieldPlan, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+
+		name, inline := yamlFieldName(structField)
+		field := &fieldPlan{
+			index:       i,
+			name:        name,
+			kind:        fieldKind(structField.Type),
+			reference:   isReference(structField.Type),
+			transparent: inline || isHelperField(structField),
+		}
+
+		field.kind, field.keyFunc = parseMergeTag(t, structField, structField.Tag.Get(mergeTag), field.kind, nil)
+		field.composeKind, field.composeKeyFunc = field.kind, field.keyFunc
+		if tag, present := structField.Tag.Lookup(composeMergeTag); present {
+			field.composeKind, field.composeKeyFunc = parseMergeTag(t, structField, tag, field.kind, field.keyFunc)
+		}
+
+		plan = append(plan, field)
+	}
+
+	actual, _ := structPlans.LoadOrStore(t, plan)
+	return actual.([]*fieldPlan)
+}
+
+// parseMergeTag returns the merge kind and key function declared by the tag of the struct field. Without tag, the
+// passed kind and key function are returned.
+func parseMergeTag(t reflect.Type, structField reflect.StructField, tag string, kind mergeKind, keyFunc func(item string) listKey) (mergeKind, func(item string) listKey) {
+	name, keyName, _ := strings.Cut(tag, "=")
+	switch name {
+	case "":
+		return kind, keyFunc
+	case "-":
+		return mergeKindIgnore, nil
+	case "overwrite":
+		return mergeKindOverwrite, nil
+	case "replace":
+		return mergeKindReplace, nil
+	case "set":
+		return mergeKindSet, nil
+	case "keyed":
+		keyFunc, known := listKeyFuncs[keyName]
+		if !known || structField.Type.Kind() != reflect.Slice || structField.Type.Elem().Kind() != reflect.String {
+			panic(fmt.Sprintf("dockerCompose: invalid merge tag of %s.%s", t.Name(), structField.Name))
+		}
+		return mergeKindKeyed, keyFunc
+	default:
+		panic(fmt.Sprintf("dockerCompose: unknown merge tag %s of %s.%s", name, t.Name(), structField.Name))
+	}
+}
+
+// isReference returns true if values of the type refer to other values, which must be copied by a deep copy.
+func isReference(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	default:
+		return false
+	}
+}
+
+// fieldKind returns how a field of the passed type is merged without a merge tag.
+func fieldKind(t reflect.Type) mergeKind {
+	switch {
+	case t == reflect.TypeOf(Extensions{}):
+		return mergeKindExtensions
+	case t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct:
+		return mergeKindObject
+	case t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Pointer && t.Elem().Elem().Kind() == reflect.Struct:
+		return mergeKindObjects
+	case t.Kind() == reflect.Slice:
+		return mergeKindSet
+	default:
+		return mergeKindValue
+	}
+}
+
+// mergeContext declares the strategies of a merge. Without policy, all attributes are merged by the fallback strategy,
+// otherwise the strategy of each attribute is looked up in the policy by the path of the attribute. The decisions of
+// the merge are recorded by the tracker, when the merge is tracked.
+type mergeContext struct {
+	fallback Strategy
+	policy   *Policy
+	tracker  *mergeTracker
+}
+
+// tracksPaths returns true if the paths of the attributes are required to merge or track them.
+func (mc *mergeContext) tracksPaths() bool {
+	return mc.policy != nil || mc.tracker != nil
+}
+
+// detach returns the attribute detached from its struct field or map entry, therefore the attribute can be recorded
+// after it was replaced. Without tracker, the attribute is not required and an invalid value is returned.
+func (mc *mergeContext) detach(v reflect.Value) reflect.Value {
+	if mc.tracker == nil || !v.IsValid() {
+		return reflect.Value{}
+	}
+	return reflect.ValueOf(v.Interface())
+}
+
+// leafValues returns the leaf values of the attribute of the passed path to record a partly applied merge afterwards.
+// Without tracker, nil is returned.
+func (mc *mergeContext) leafValues(path []string, v reflect.Value) map[string]string {
+	if mc.tracker == nil {
+		return nil
+	}
+	return leafValuesAt(v, path)
+}
+
+// record records the decision about the attribute of the passed path, when the merge is tracked.
+func (mc *mergeContext) record(path []string, before reflect.Value, src reflect.Value, decision mergeDecision) {
+	if mc.tracker != nil {
+		mc.tracker.record(path, before, src, decision)
+	}
+}
+
+// recordMerged records the partly applied merge of the attribute of the passed path, when the merge is tracked.
+func (mc *mergeContext) recordMerged(path []string, beforeValues map[string]string, src reflect.Value, after reflect.Value) {
+	if mc.tracker != nil {
+		mc.tracker.recordMerged(path, beforeValues, src, after)
+	}
+}
+
+// recordItem records the decision about an item of the list of the passed path, when the merge is tracked.
+func (mc *mergeContext) recordItem(path []string, item string, matches []string, applied bool) {
+	if mc.tracker != nil {
+		mc.tracker.recordItem(path, item, matches, applied)
+	}
+}
+
+// strategyOf returns the strategy of the attribute of the passed path.
+func (mc *mergeContext) strategyOf(path []string) Strategy {
+	if mc.policy == nil {
+		return mc.fallback
+	}
+	return mc.policy.Strategy(path, mc.fallback)
+}
+
+// descends returns true if attributes below the passed path are merged by a strategy of their own.
+func (mc *mergeContext) descends(path []string) bool {
+	return mc.policy != nil && mc.policy.hasRulesBelow(path)
+}
+
+// mergeObject merges the object src into the object dst by the strategy. Nothing is merged, when one of both is nil.
+func mergeObject[T any](dst *T, src *T, strategy Strategy) {
+	if dst == nil || src == nil {
+		return
+	}
+	mergeStruct(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem(), nil, &mergeContext{fallback: strategy})
+}
+
+// equalObject returns true when both objects are nil or all their attributes are equal.
+func equalObject[T any](a *T, b *T) bool {
+	switch {
+	case a == nil && b == nil:
+		return true
+	case a == nil || b == nil:
+		return false
+	default:
+		return equalStruct(reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem())
+	}
+}
+
+// mergeStruct merges the struct src into the struct dst. The path of the structs is only maintained, when the merge
+// context tracks paths.
+func mergeStruct(dst reflect.Value, src reflect.Value, path []string, mc *mergeContext) {
+	for _, field := range planOf(dst.Type()) {
+		dstField, srcField := dst.Field(field.index), src.Field(field.index)
+
+		fieldPath := path
+		if mc.tracksPaths() && !field.transparent {
+			fieldPath = appendPath(path, field.name)
+		}
+		strategy := mc.strategyOf(fieldPath)
+
+		kind, keyFunc := field.kind, field.keyFunc
+		if strategy == StrategyComposeCompatible {
+			kind, keyFunc = field.composeKind, field.composeKeyFunc
+		}
+
+		switch {
+		case kind == mergeKindIgnore:
+			continue
+		case strategy == StrategyDefault && kind == mergeKindObject && mc.descends(fieldPath):
+			// Like the default strategy, the object is not added, but its attributes with a strategy of their own are
+			// merged.
+			if !dstField.IsNil() && !srcField.IsNil() {
+				mergeStruct(dstField.Elem(), srcField.Elem(), fieldPath, mc)
+				continue
+			}
+			mc.record(fieldPath, dstField, srcField, mergeDecisionSkipped)
+			continue
+		case strategy == StrategyDefault && kind != mergeKindObjects && kind != mergeKindExtensions:
+			mc.record(fieldPath, dstField, srcField, mergeDecisionSkipped)
+			continue
+		}
+
+		switch kind {
+		case mergeKindValue, mergeKindOverwrite, mergeKindReplace:
+			before := mc.detach(dstField)
+			switch {
+			case strategy == StrategyExistingWin && isZero(dstField) && !isZero(srcField):
+				fallthrough
+			case overwrites(strategy) && (kind == mergeKindOverwrite || !isZero(srcField)):
+				dstField.Set(cloneValue(srcField))
+				mc.record(fieldPath, before, srcField, mergeDecisionApplied)
+			default:
+				mc.record(fieldPath, before, srcField, mergeDecisionSkipped)
+			}
+		case mergeKindObject:
+			switch {
+			case srcField.IsNil():
+				continue
+			case dstField.IsNil():
+				dstField.Set(cloneValue(srcField))
+				mc.record(fieldPath, reflect.Value{}, srcField, mergeDecisionApplied)
+			default:
+				mergeStruct(dstField.Elem(), srcField.Elem(), fieldPath, mc)
+			}
+		case mergeKindObjects:
+			mergeObjects(dstField, srcField, fieldPath, mc)
+		case mergeKindSet:
+			mergeSet(dstField, srcField, fieldPath, mc)
+		case mergeKindKeyed:
+			mergeKeyed(dstField, srcField, keyFunc, strategy, fieldPath, mc)
+		case mergeKindExtensions:
+			mergeExtensions(dstField, srcField, fieldPath, mc)
+		}
+	}
+}
+
+// overwrites returns true if the strategy overwrites existing attributes by declared attributes.
+func overwrites(strategy Strategy) bool {
+	return strategy == StrategyLastWin || strategy == StrategyComposeCompatible
+}
+
+// mergeObjects merges the mapping of objects src into the mapping dst. Missing objects are added by every strategy,
+// existing objects are merged by the existing-win and last-win strategy. The default strategy only merges the
+// attributes of existing objects, which are merged by a strategy of their own.
+func mergeObjects(dst reflect.Value, src reflect.Value, path []string, mc *mergeContext) {
+	if src.Len() <= 0 {
+		return
+	}
+
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMapWithSize(dst.Type(), src.Len()))
+	}
+
+	iter := src.MapRange()
+	for iter.Next() {
+		key, srcEntry := iter.Key(), iter.Value()
+		dstEntry := dst.MapIndex(key)
+
+		entryPath := path
+		if mc.tracksPaths() {
+			entryPath = appendPath(path, key.String())
+		}
+
+		switch {
+		case !dstEntry.IsValid():
+			dst.SetMapIndex(key, cloneValue(srcEntry))
+			mc.record(entryPath, reflect.Value{}, srcEntry, mergeDecisionApplied)
+		case srcEntry.IsNil():
+			continue
+		case mc.strategyOf(entryPath) == StrategyDefault && !mc.descends(entryPath):
+			mc.record(entryPath, dstEntry, srcEntry, mergeDecisionSkipped)
+		case dstEntry.IsNil():
+			dst.SetMapIndex(key, cloneValue(srcEntry))
+			mc.record(entryPath, dstEntry, srcEntry, mergeDecisionApplied)
+		default:
+			mergeStruct(dstEntry.Elem(), srcEntry.Elem(), entryPath, mc)
+		}
+	}
+}
+
+// mergeSet appends all items of the list src, which are neither empty nor already contained in the list dst.
+func mergeSet(dst reflect.Value, src reflect.Value, path []string, mc *mergeContext) {
+	switch items := dst.Addr().Interface().(type) {
+	case *[]string:
+		*items = mergeSetItems(*items, listOf[string](src), path, mc)
+	case *[]Port:
+		*items = mergeSetItems(*items, listOf[Port](src), path, mc)
+	default:
+		for i := 0; i < src.Len(); i++ {
+			item := src.Index(i)
+			if isZero(item) {
+				continue
+			}
+
+			// Items of lists of objects are identified by their position in the merged list.
+			index := indexOfValue(dst, item)
+			if index >= 0 {
+				mc.record(appendPath(path, strconv.Itoa(index)), dst.Index(index), item, mergeDecisionSkipped)
+				continue
+			}
+
+			dst.Set(reflect.Append(dst, cloneValue(item)))
+			mc.record(appendPath(path, strconv.Itoa(dst.Len()-1)), reflect.Value{}, item, mergeDecisionApplied)
+		}
+	}
+}
+
+func mergeSetItems[K ~string](items []K, newItems []K, path []string, mc *mergeContext) []K {
+	for _, item := range newItems {
+		switch {
+		case len(item) <= 0:
+			continue
+		case existsInSlice(items, item):
+			mc.recordItem(path, string(item), []string{string(item)}, false)
+		default:
+			items = append(items, item)
+			mc.recordItem(path, string(item), nil, true)
+		}
+	}
+	return items
+}
+
+// mergeKeyed merges the keyed list src into the keyed list dst. The existing-win strategy appends only items with an
+// unknown key, the last-win strategy removes existing items with the same key before the item is appended. The
+// compose-compatible strategy replaces existing items with the same key at their position.
+func mergeKeyed(dst reflect.Value, src reflect.Value, keyFunc func(item string) listKey, strategy Strategy, path []string, mc *mergeContext) {
+	switch items := dst.Addr().Interface().(type) {
+	case *[]string:
+		*items = mergeKeyedItems(*items, listOf[string](src), keyFunc, strategy, path, mc)
+	case *[]Port:
+		*items = mergeKeyedItems(*items, listOf[Port](src), keyFunc, strategy, path, mc)
+	}
+}
+
+func mergeKeyedItems[K ~string](items []K, newItems []K, keyFunc func(item string) listKey, strategy Strategy, path []string, mc *mergeContext) []K {
+	if len(newItems) <= 0 {
+		return items
+	}
+
+	keys := make([]listKey, len(items), len(items)+len(newItems))
+	for i := range items {
+		keys[i] = keyFunc(string(items[i]))
+	}
+
+	for _, item := range newItems {
+		if len(item) <= 0 {
+			continue
+		}
+
+		key := keyFunc(string(item))
+		matches := matchingItems(items, keys, key, mc)
+		switch {
+		case !containsKey(keys, key):
+		case strategy == StrategyLastWin:
+			items, keys = removeItemsByKey(items, keys, key)
+		case strategy == StrategyComposeCompatible:
+			items = replaceItemsByKey(items, keys, key, item)
+			mc.recordItem(path, string(item), matches, true)
+			continue
+		default:
+			mc.recordItem(path, string(item), matches, false)
+			continue
+		}
+
+		items = append(items, item)
+		keys = append(keys, key)
+		mc.recordItem(path, string(item), matches, true)
+	}
+
+	return items
+}
+
+func containsKey(keys []listKey, key listKey) bool {
+	for i := range keys {
+		if keys[i].matches(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchingItems returns the items matching the passed key to record the decision about an item with this key. Without
+// tracker, the items are not required and nil is returned.
+func matchingItems[K ~string](items []K, keys []listKey, key listKey, mc *mergeContext) []string {
+	if mc.tracker == nil {
+		return nil
+	}
+
+	matches := make([]string, 0)
+	for i := range items {
+		if keys[i].matches(key) {
+			matches = append(matches, string(items[i]))
+		}
+	}
+	return matches
+}
+
+// removeItemsByKey returns new lists of the items and their keys without the items matching the passed key.
+func removeItemsByKey[K any](items []K, keys []listKey, key listKey) ([]K, []listKey) {
+	remainingItems := make([]K, 0, len(items))
+	remainingKeys := make([]listKey, 0, len(keys))
+	for i := range items {
+		if !keys[i].matches(key) {
+			remainingItems = append(remainingItems, items[i])
+			remainingKeys = append(remainingKeys, keys[i])
+		}
+	}
+	return remainingItems, remainingKeys
+}
+
+// replaceItemsByKey returns a new list of the items, whose items matching the passed key are replaced by the item.
+func replaceItemsByKey[K any](items []K, keys []listKey, key listKey, item K) []K {
+	replacedItems := make([]K, len(items))
+	for i := range items {
+		replacedItems[i] = items[i]
+		if keys[i].matches(key) {
+			replacedItems[i] = item
+		}
+	}
+	return replacedItems
+}
+
+// mergeExtensions merges the extension fields src into the extension fields dst key by key, because each key is an
+// attribute of its own. Missing keys are added by every strategy. The existing-win and last-win strategy merge nested
+// mappings, the compose-compatible strategy replaces the value of existing keys.
+func mergeExtensions(dst reflect.Value, src reflect.Value, path []string, mc *mergeContext) {
+	extensions := src.Interface().(Extensions)
+	if len(extensions) <= 0 {
+		return
+	}
+
+	e := dst.Interface().(Extensions)
+	if e == nil {
+		e = make(Extensions, len(extensions))
+	}
+
+	for key, value := range extensions {
+		keyPath := path
+		if mc.tracksPaths() {
+			keyPath = appendPath(path, key)
+		}
+
+		existingValue, present := e[key]
+		switch strategy := mc.strategyOf(keyPath); {
+		case !present:
+			e[key] = cloneExtensionValue(value)
+			mc.record(keyPath, reflect.Value{}, reflect.ValueOf(value), mergeDecisionApplied)
+		case strategy == StrategyExistingWin:
+			// Nested mappings are merged in place and partly applied.
+			beforeValues := mc.leafValues(keyPath, reflect.ValueOf(existingValue))
+			e[key] = mergeExistingWinExtensionValue(existingValue, value)
+			mc.recordMerged(keyPath, beforeValues, reflect.ValueOf(value), reflect.ValueOf(e[key]))
+		case strategy == StrategyLastWin:
+			beforeValues := mc.leafValues(keyPath, reflect.ValueOf(existingValue))
+			e[key] = mergeLastWinExtensionValue(existingValue, value)
+			mc.recordMerged(keyPath, beforeValues, reflect.ValueOf(value), reflect.ValueOf(e[key]))
+		case strategy == StrategyComposeCompatible:
+			e[key] = cloneExtensionValue(value)
+			mc.record(keyPath, reflect.ValueOf(existingValue), reflect.ValueOf(value), mergeDecisionApplied)
+		default:
+			mc.record(keyPath, reflect.ValueOf(existingValue), reflect.ValueOf(value), mergeDecisionSkipped)
+		}
+	}
+	dst.Set(reflect.ValueOf(e))
+}
+
+func equalStruct(a reflect.Value, b reflect.Value) bool {
+	for _, field := range planOf(a.Type()) {
+		if !equalValue(a.Field(field.index), b.Field(field.index)) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalValue returns true if both values are equal. Lists of scalars are equal, when each item is contained in the
+// other list, lists of objects additionally need the same length. The order of the items is insignificant. Nil and
+// empty extension fields are equal.
+func equalValue(a reflect.Value, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Pointer:
+		switch {
+		case a.IsNil() && b.IsNil():
+			return true
+		case a.IsNil() || b.IsNil():
+			return false
+		case a.Elem().Kind() == reflect.Struct:
+			return equalStruct(a.Elem(), b.Elem())
+		default:
+			return equalValue(a.Elem(), b.Elem())
+		}
+	case reflect.Slice:
+		return equalList(a, b)
+	case reflect.Map:
+		if a.Type() == reflect.TypeOf(Extensions{}) {
+			return a.Interface().(Extensions).Equal(b.Interface().(Extensions))
+		}
+
+		if a.Len() != b.Len() {
+			return false
+		}
+
+		iter := a.MapRange()
+		for iter.Next() {
+			value := b.MapIndex(iter.Key())
+			if !value.IsValid() || !equalValue(iter.Value(), value) {
+				return false
+			}
+		}
+		return true
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return a.Uint() == b.Uint()
+	default:
+		return reflect.DeepEqual(a.Interface(), b.Interface())
+	}
+}
+
+func equalList(a reflect.Value, b reflect.Value) bool {
+	if items, ok := typedList[string](a); ok {
+		return equalSlice(items, listOf[string](b))
+	}
+
+	if items, ok := typedList[Port](a); ok {
+		return equalSlice(items, listOf[Port](b))
+	}
+
+	switch {
+	case a.IsNil() && b.IsNil():
+		return true
+	case a.IsNil() || b.IsNil() || a.Len() != b.Len():
+		return false
+	}
+
+	for i := 0; i < a.Len(); i++ {
+		if !containsValue(b, a.Index(i)) {
+			return false
+		}
+	}
+
+	for i := 0; i < b.Len(); i++ {
+		if !containsValue(a, b.Index(i)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// containsValue returns true if the list contains an item equal to the passed value.
+func containsValue(list reflect.Value, v reflect.Value) bool {
+	return indexOfValue(list, v) >= 0
+}
+
+// indexOfValue returns the index of the first item of the list, which is equal to the passed value, or -1.
+func indexOfValue(list reflect.Value, v reflect.Value) int {
+	for i := 0; i < list.Len(); i++ {
+		if equalValue(list.Index(i), v) {
+			return i
+		}
+	}
+	return -1
+}
+
+// cloneStruct sets the struct dst to a deep copy of the struct src. Unexported attributes are copied shallow.
+func cloneStruct(dst reflect.Value, src reflect.Value) {
+	dst.Set(src)
+	for _, field := range planOf(dst.Type()) {
+		if field.reference {
+			cloneReference(dst.Field(field.index))
+		}
+	}
+}
+
+// cloneReference replaces the pointer, list or mapping v by a deep copy of itself.
+func cloneReference(v reflect.Value) {
+	if !v.IsNil() {
+		v.Set(cloneValue(v))
+	}
+}
+
+// cloneMethods caches the Clone method of each type, which implements Cloneable.
+var cloneMethods sync.Map
+
+// cloneMethodOf returns the Clone method of the type, when the type implements Cloneable.
+func cloneMethodOf(t reflect.Type) (reflect.Value, bool) {
+	if method, ok := cloneMethods.Load(t); ok {
+		return method.(reflect.Value), method.(reflect.Value).IsValid()
+	}
+
+	var function reflect.Value
+	method, present := t.MethodByName("Clone")
+	if present && method.Type.NumIn() == 1 && method.Type.NumOut() == 1 && method.Type.Out(0) == t {
+		function = method.Func
+	}
+
+	cloneMethods.Store(t, function)
+	return function, function.IsValid()
+}
+
+// cloneValue returns a deep copy of the value. Objects are copied by their Clone method, other values attribute by
+// attribute. Nil pointers, lists and mappings stay nil.
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+
+		if method, ok := cloneMethodOf(v.Type()); ok {
+			return method.Call([]reflect.Value{v})[0]
+		}
+
+		clone := reflect.New(v.Type().Elem())
+		if v.Elem().Kind() == reflect.Struct {
+			cloneStruct(clone.Elem(), v.Elem())
+		} else {
+			clone.Elem().Set(cloneValue(v.Elem()))
+		}
+		return clone
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		clone := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		if !isReference(v.Type().Elem()) {
+			reflect.Copy(clone, v)
+			return clone
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			clone.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return clone
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		if v.Type() == reflect.TypeOf(Extensions{}) {
+			return reflect.ValueOf(v.Interface().(Extensions).Clone())
+		}
+
+		clone := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			clone.SetMapIndex(iter.Key(), cloneValue(iter.Value()))
+		}
+		return clone
+	default:
+		return v
+	}
+}
+
+// typedList returns the list as typed slice without copying its items, when the list is a slice of the type K.
+func typedList[K any](v reflect.Value) ([]K, bool) {
+	if v.CanAddr() {
+		items, ok := v.Addr().Interface().(*[]K)
+		if !ok {
+			return nil, false
+		}
+		return *items, true
+	}
+
+	items, ok := v.Interface().([]K)
+	return items, ok
+}
+
+// listOf returns the list as typed slice of the type K. Lists of another type are returned as nil.
+func listOf[K any](v reflect.Value) []K {
+	items, _ := typedList[K](v)
+	return items
+}
+
+// collectLists returns the YAML names of all lists of the struct type and its nested objects, which are merged by the
+// passed kind.
+func collectLists(t reflect.Type, kind mergeKind) map[string]*fieldPlan {
+	lists := make(map[string]*fieldPlan)
+	visited := make(map[reflect.Type]struct{})
+
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Map || t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+
+		if _, present := visited[t]; present || t.Kind() != reflect.Struct {
+			return
+		}
+		visited[t] = struct{}{}
+
+		for _, field := range planOf(t) {
+			fieldType := t.Field(field.index).Type
+			if field.kind == kind && fieldType.Kind() == reflect.Slice && len(field.name) > 0 {
+				lists[field.name] = field
+			}
+			collect(fieldType)
+		}
+	}
+	collect(t)
+
+	return lists
+}
@@ -0,0 +1,298 @@
+package dockerCompose
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var ErrUnknownSide = errors.New("unknown side")
+
+// Side is one of the changed configs of a three-way merge.
+type Side string
+
+const (
+	SideNone   Side = ""
+	SideOurs   Side = "ours"
+	SideTheirs Side = "theirs"
+)
+
+// Names of the configs of a three-way merge in the values of a conflict.
+const (
+	merge3Base   string = "base"
+	merge3Ours   string = "ours"
+	merge3Theirs string = "theirs"
+)
+
+// Merge3 combines the changes of ours and theirs to their common ancestor base. An attribute changed by only one side
+// is taken from this side, an attribute changed by both sides in the same way is taken from both. The changes are
+// detected by the Equal methods, only unequal objects are compared attribute by attribute. Items of lists merged as
+// set are added and removed item by item, items of keyed lists by their key.
+//
+// An attribute changed by both sides in a different way is a conflict. Conflicts are resolved by the value of the
+// preferred side. Without a preferred side, conflicts are resolved by the value of ours and returned for a report.
+func Merge3(base *Config, ours *Config, theirs *Config, prefer Side) (*Config, Conflicts, error) {
+	switch prefer {
+	case SideNone, SideOurs, SideTheirs:
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownSide, prefer)
+	}
+
+	for _, config := range []**Config{&base, &ours, &theirs} {
+		if *config == nil {
+			*config = NewConfig()
+		}
+	}
+
+	conflicts := make(Conflicts, 0)
+	resolve := func(path []string, baseValue reflect.Value, oursValue reflect.Value, theirsValue reflect.Value) reflect.Value {
+		conflicts = append(conflicts, &Conflict{
+			Path: strings.Join(path, pathSeparator),
+			Values: []*ConflictValue{
+				{Source: merge3Base, Value: formatMerge3Value(baseValue)},
+				{Source: merge3Ours, Value: formatMerge3Value(oursValue)},
+				{Source: merge3Theirs, Value: formatMerge3Value(theirsValue)},
+			},
+		})
+
+		if prefer == SideTheirs {
+			return cloneMerge3Value(theirsValue)
+		}
+		return cloneMerge3Value(oursValue)
+	}
+
+	result := merge3Value(reflect.ValueOf(base), reflect.ValueOf(ours), reflect.ValueOf(theirs), make([]string, 0), resolve)
+	sort.SliceStable(conflicts, func(i, j int) bool { return conflicts[i].Path < conflicts[j].Path })
+
+	return result.Interface().(*Config), conflicts, nil
+}
+
+// merge3Resolver resolves a conflict of the passed path and returns the resolved value.
+type merge3Resolver func(path []string, base reflect.Value, ours reflect.Value, theirs reflect.Value) reflect.Value
+
+// merge3Value returns the three-way merge of the values. Invalid values are absent entries of a mapping. When the
+// returned value is invalid, the entry is removed.
+func merge3Value(base reflect.Value, ours reflect.Value, theirs reflect.Value, path []string, resolve merge3Resolver) reflect.Value {
+	switch {
+	case equalMerge3Value(ours, theirs):
+		return cloneMerge3Value(ours)
+	case equalMerge3Value(base, ours):
+		return cloneMerge3Value(theirs)
+	case equalMerge3Value(base, theirs):
+		return cloneMerge3Value(ours)
+	case !ours.IsValid() || !theirs.IsValid():
+		return resolve(path, base, ours, theirs)
+	}
+
+	switch ours.Kind() {
+	case reflect.Pointer:
+		if ours.IsNil() || theirs.IsNil() || ours.Elem().Kind() != reflect.Struct {
+			return resolve(path, base, ours, theirs)
+		}
+
+		baseStruct := reflect.New(ours.Type().Elem()).Elem()
+		if base.IsValid() && !base.IsNil() {
+			baseStruct = base.Elem()
+		}
+
+		result := reflect.New(ours.Type().Elem())
+		merge3Struct(result.Elem(), baseStruct, ours.Elem(), theirs.Elem(), path, resolve)
+		return result
+	case reflect.Map:
+		return merge3Map(base, ours, theirs, path, resolve)
+	default:
+		return resolve(path, base, ours, theirs)
+	}
+}
+
+// merge3Struct sets the struct dst to the three-way merge of the structs attribute by attribute.
+func merge3Struct(dst reflect.Value, base reflect.Value, ours reflect.Value, theirs reflect.Value, path []string, resolve merge3Resolver) {
+	for _, field := range planOf(dst.Type()) {
+		fieldPath := path
+		structField := dst.Type().Field(field.index)
+		name, inline := yamlFieldName(structField)
+		if !inline && !isHelperField(structField) {
+			fieldPath = appendPath(path, name)
+		}
+
+		baseField, oursField, theirsField := base.Field(field.index), ours.Field(field.index), theirs.Field(field.index)
+
+		var value reflect.Value
+		switch {
+		case field.kind == mergeKindSet && !equalMerge3Value(oursField, theirsField):
+			value = merge3Set(baseField, oursField, theirsField)
+		case field.kind == mergeKindKeyed && !equalMerge3Value(oursField, theirsField):
+			value = merge3Keyed(baseField, oursField, theirsField, field.keyFunc, fieldPath, resolve)
+		default:
+			value = merge3Value(baseField, oursField, theirsField, fieldPath, resolve)
+		}
+
+		if value.IsValid() {
+			dst.Field(field.index).Set(value)
+		}
+	}
+}
+
+// merge3Map returns the three-way merge of the mappings entry by entry. The mapping is nil, when no entry is left.
+func merge3Map(base reflect.Value, ours reflect.Value, theirs reflect.Value, path []string, resolve merge3Resolver) reflect.Value {
+	keys := make([]reflect.Value, 0)
+	knownKeys := make(map[interface{}]struct{})
+	for _, m := range []reflect.Value{ours, theirs, base} {
+		if !m.IsValid() || m.IsNil() {
+			continue
+		}
+
+		mapKeys := m.MapKeys()
+		sort.Slice(mapKeys, func(i, j int) bool { return fmt.Sprint(mapKeys[i].Interface()) < fmt.Sprint(mapKeys[j].Interface()) })
+		for _, key := range mapKeys {
+			if _, present := knownKeys[key.Interface()]; !present {
+				knownKeys[key.Interface()] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	result := reflect.MakeMapWithSize(ours.Type(), len(keys))
+	for _, key := range keys {
+		value := merge3Value(mapIndex(base, key), mapIndex(ours, key), mapIndex(theirs, key),
+			appendPath(path, fmt.Sprint(key.Interface())), resolve)
+		if value.IsValid() {
+			result.SetMapIndex(key, value)
+		}
+	}
+
+	if result.Len() <= 0 && ours.IsNil() && theirs.IsNil() {
+		return reflect.Zero(ours.Type())
+	}
+
+	return result
+}
+
+// merge3Set returns the items of ours without the items removed by theirs and with the items added by theirs. Lists
+// merged as set never conflict.
+func merge3Set(base reflect.Value, ours reflect.Value, theirs reflect.Value) reflect.Value {
+	result := reflect.MakeSlice(ours.Type(), 0, ours.Len()+theirs.Len())
+	for i := 0; i < ours.Len(); i++ {
+		item := ours.Index(i)
+		if containsValue(base, item) && !containsValue(theirs, item) {
+			continue
+		}
+		result = reflect.Append(result, cloneValue(item))
+	}
+
+	for i := 0; i < theirs.Len(); i++ {
+		item := theirs.Index(i)
+		if !containsValue(base, item) && !containsValue(result, item) {
+			result = reflect.Append(result, cloneValue(item))
+		}
+	}
+
+	if result.Len() <= 0 && ours.IsNil() {
+		return reflect.Zero(ours.Type())
+	}
+
+	return result
+}
+
+// merge3Keyed returns the three-way merge of the keyed lists item by item. Items are identified by their key. An item
+// changed by both sides in a different way is a conflict of the path of its key.
+func merge3Keyed(base reflect.Value, ours reflect.Value, theirs reflect.Value, keyFunc func(item string) listKey, path []string, resolve merge3Resolver) reflect.Value {
+	type keyedItems struct {
+		key   listKey
+		items [3]reflect.Value
+	}
+
+	// The groups of the base are created first to report conflicts by the key of the base, the items are merged in the
+	// order of ours and theirs.
+	groups := make([]*keyedItems, 0)
+	order := make([]*keyedItems, 0)
+	for i, list := range []reflect.Value{base, ours, theirs} {
+		for j := 0; j < list.Len(); j++ {
+			key := keyFunc(list.Index(j).String())
+
+			var group *keyedItems
+			for _, existingGroup := range groups {
+				if existingGroup.key.matches(key) {
+					group = existingGroup
+					break
+				}
+			}
+
+			if group == nil {
+				group = &keyedItems{key: key}
+				groups = append(groups, group)
+			}
+
+			if i > 0 && !group.items[1].IsValid() && !group.items[2].IsValid() {
+				order = append(order, group)
+			}
+			group.items[i] = list.Index(j)
+		}
+	}
+
+	result := reflect.MakeSlice(ours.Type(), 0, ours.Len()+theirs.Len())
+	for _, group := range order {
+		item := merge3Value(group.items[0], group.items[1], group.items[2], appendPath(path, group.key.String()), resolve)
+		if item.IsValid() {
+			result = reflect.Append(result, item)
+		}
+	}
+
+	if result.Len() <= 0 && ours.IsNil() {
+		return reflect.Zero(ours.Type())
+	}
+
+	return result
+}
+
+// equalMerge3Value returns true if both values are absent or equal. Objects are compared by their Equal method.
+func equalMerge3Value(a reflect.Value, b reflect.Value) bool {
+	switch {
+	case !a.IsValid() && !b.IsValid():
+		return true
+	case !a.IsValid() || !b.IsValid():
+		return false
+	case a.Kind() == reflect.Pointer && (a.IsNil() || b.IsNil()):
+		return a.IsNil() && b.IsNil()
+	case a.Kind() == reflect.Interface && (a.IsNil() || b.IsNil()):
+		return a.IsNil() && b.IsNil()
+	}
+
+	if equalable, ok := a.Interface().(Equalable); ok && a.Kind() == reflect.Pointer {
+		return equalable.Equal(b.Interface().(Equalable))
+	}
+
+	return equalValue(a, b)
+}
+
+// cloneMerge3Value returns a deep copy of the value. Absent values stay absent. Values of extension fields are copied
+// as extension value.
+func cloneMerge3Value(v reflect.Value) reflect.Value {
+	switch {
+	case !v.IsValid():
+		return v
+	case v.Kind() == reflect.Interface && !v.IsNil():
+		return reflect.ValueOf(cloneExtensionValue(v.Interface()))
+	default:
+		return cloneValue(v)
+	}
+}
+
+// formatMerge3Value returns a readable representation of the value. Absent values are represented by an empty
+// string.
+func formatMerge3Value(v reflect.Value) string {
+	if isZero(v) {
+		return ""
+	}
+	return formatValue(v)
+}
+
+// mapIndex returns the entry of the key. The entry is invalid, when the mapping is nil or the key absent.
+func mapIndex(m reflect.Value, key reflect.Value) reflect.Value {
+	if !m.IsValid() || m.IsNil() {
+		return reflect.Value{}
+	}
+	return m.MapIndex(key)
+}
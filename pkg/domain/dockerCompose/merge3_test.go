@@ -0,0 +1,218 @@
+package dockerCompose_test
+
+import (
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMerge3(t *testing.T) {
+	require := require.New(t)
+
+	base := `services:
+  app:
+    cap_add:
+    - NET_ADMIN
+    - SYS_TIME
+    environment:
+    - LOG_LEVEL=info
+    - TZ=UTC
+    image: example.local/app:1.0.0
+    ports:
+    - 8080:80
+  db:
+    image: example.local/db:1.0.0
+x-owner: team-a
+`
+
+	testCases := []struct {
+		ours              string
+		theirs            string
+		prefer            dockerCompose.Side
+		expectedConfig    string
+		expectedConflicts []string
+	}{
+		// Non-conflicting changes of both sides
+		{
+			ours: `services:
+  app:
+    cap_add:
+    - NET_ADMIN
+    environment:
+    - LOG_LEVEL=debug
+    - TZ=UTC
+    image: example.local/app:1.0.0
+    ports:
+    - 8080:80
+  db:
+    image: example.local/db:1.0.0
+x-owner: team-a
+`,
+			theirs: `services:
+  app:
+    cap_add:
+    - NET_ADMIN
+    - SYS_TIME
+    - SYS_NICE
+    environment:
+    - LOG_LEVEL=info
+    - TZ=UTC
+    - CACHE=true
+    image: example.local/app:2.0.0
+    ports:
+    - 8080:80
+  cache:
+    image: example.local/cache:1.0.0
+x-owner: team-b
+`,
+			expectedConfig: `services:
+  app:
+    cap_add:
+    - NET_ADMIN
+    - SYS_NICE
+    environment:
+    - LOG_LEVEL=debug
+    - TZ=UTC
+    - CACHE=true
+    image: example.local/app:2.0.0
+    ports:
+    - 8080:80
+  cache:
+    image: example.local/cache:1.0.0
+x-owner: team-b
+`,
+			expectedConflicts: []string{},
+		},
+
+		// Conflicting changes resolved by ours
+		{
+			ours: `services:
+  app:
+    cap_add:
+    - NET_ADMIN
+    - SYS_TIME
+    environment:
+    - LOG_LEVEL=debug
+    - TZ=UTC
+    image: example.local/app:1.1.0
+    ports:
+    - 8080:80
+x-owner: team-a
+`,
+			theirs: `services:
+  app:
+    cap_add:
+    - NET_ADMIN
+    - SYS_TIME
+    environment:
+    - LOG_LEVEL=warn
+    - TZ=UTC
+    image: example.local/app:2.0.0
+    ports:
+    - 8080:80
+  db:
+    image: example.local/db:2.0.0
+x-owner: team-a
+`,
+			expectedConfig: `services:
+  app:
+    cap_add:
+    - NET_ADMIN
+    - SYS_TIME
+    environment:
+    - LOG_LEVEL=debug
+    - TZ=UTC
+    image: example.local/app:1.1.0
+    ports:
+    - 8080:80
+x-owner: team-a
+`,
+			expectedConflicts: []string{
+				"services.app.environment.LOG_LEVEL",
+				"services.app.image",
+				"services.db",
+			},
+		},
+
+		// Conflicting changes resolved by theirs
+		{
+			ours: `services:
+  app:
+    cap_add:
+    - NET_ADMIN
+    - SYS_TIME
+    environment:
+    - LOG_LEVEL=info
+    - TZ=UTC
+    image: example.local/app:1.1.0
+    ports:
+    - 127.0.0.1:8080:80
+  db:
+    image: example.local/db:1.0.0
+x-owner: team-a
+`,
+			theirs: `services:
+  app:
+    cap_add:
+    - NET_ADMIN
+    - SYS_TIME
+    environment:
+    - LOG_LEVEL=info
+    - TZ=UTC
+    image: example.local/app:2.0.0
+    ports:
+    - 8080:8080
+  db:
+    image: example.local/db:1.0.0
+x-owner: team-a
+`,
+			prefer: dockerCompose.SideTheirs,
+			expectedConfig: `services:
+  app:
+    cap_add:
+    - NET_ADMIN
+    - SYS_TIME
+    environment:
+    - LOG_LEVEL=info
+    - TZ=UTC
+    image: example.local/app:2.0.0
+    ports:
+    - 8080:8080
+  db:
+    image: example.local/db:1.0.0
+x-owner: team-a
+`,
+			expectedConflicts: []string{
+				"services.app.image",
+				"services.app.ports.8080",
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		configs := make([]*dockerCompose.Config, 0)
+		for _, s := range []string{base, testCase.ours, testCase.theirs, testCase.expectedConfig} {
+			config := dockerCompose.NewConfig()
+			require.NoError(yaml.Unmarshal([]byte(s), config), "TestCase %v", i)
+			configs = append(configs, config)
+		}
+
+		actualConfig, conflicts, err := dockerCompose.Merge3(configs[0], configs[1], configs[2], testCase.prefer)
+		require.NoError(err, "TestCase %v", i)
+
+		b, err := yaml.Marshal(actualConfig)
+		require.NoError(err, "TestCase %v", i)
+		require.True(configs[3].Equal(actualConfig), "TestCase %v: %s", i, string(b))
+
+		actualConflicts := make([]string, 0)
+		for _, conflict := range conflicts {
+			actualConflicts = append(actualConflicts, conflict.Path)
+		}
+		require.Equal(testCase.expectedConflicts, actualConflicts, "TestCase %v", i)
+	}
+
+	_, _, err := dockerCompose.Merge3(nil, nil, nil, "both")
+	require.ErrorIs(err, dockerCompose.ErrUnknownSide)
+}
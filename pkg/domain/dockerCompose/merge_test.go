@@ -0,0 +1,232 @@
+package dockerCompose_test
+
+import (
+	"fmt"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig_MergeByTags(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		configA        *dockerCompose.Config
+		configB        *dockerCompose.Config
+		merge          func(c *dockerCompose.Config, config *dockerCompose.Config)
+		expectedConfig *dockerCompose.Config
+	}{
+		// Version is only overwritten when declared
+		{
+			configA:        &dockerCompose.Config{Version: "3.9"},
+			configB:        &dockerCompose.Config{},
+			merge:          (*dockerCompose.Config).MergeLastWin,
+			expectedConfig: &dockerCompose.Config{Version: "3.9"},
+		},
+
+		// Undefined attributes of networks are added
+		{
+			configA: &dockerCompose.Config{Networks: map[string]*dockerCompose.Network{"proxy": {}}},
+			configB: &dockerCompose.Config{Networks: map[string]*dockerCompose.Network{"proxy": {Driver: "bridge"}}},
+			merge:   (*dockerCompose.Config).MergeExistingWin,
+			expectedConfig: &dockerCompose.Config{
+				Networks: map[string]*dockerCompose.Network{"proxy": {Driver: "bridge"}},
+			},
+		},
+
+		// A port published on all host IPs replaces the port published on a single host IP
+		{
+			configA: &dockerCompose.Config{
+				Services: map[string]*dockerCompose.Service{"app": {Ports: []dockerCompose.Port{"127.0.0.1:8080:80"}}},
+			},
+			configB: &dockerCompose.Config{
+				Services: map[string]*dockerCompose.Service{"app": {Ports: []dockerCompose.Port{"8080:8080"}}},
+			},
+			merge: (*dockerCompose.Config).MergeLastWin,
+			expectedConfig: &dockerCompose.Config{
+				Services: map[string]*dockerCompose.Service{"app": {Ports: []dockerCompose.Port{"8080:8080"}}},
+			},
+		},
+
+		// Undefined ulimits are added
+		{
+			configA: &dockerCompose.Config{
+				Services: map[string]*dockerCompose.Service{"app": {ULimits: &dockerCompose.ServiceULimits{
+					NoFile: &dockerCompose.ServiceULimitsNoFile{Soft: 1024},
+				}}},
+			},
+			configB: &dockerCompose.Config{
+				Services: map[string]*dockerCompose.Service{"app": {ULimits: &dockerCompose.ServiceULimits{
+					NProc:  512,
+					NoFile: &dockerCompose.ServiceULimitsNoFile{Hard: 2048, Soft: 4096},
+				}}},
+			},
+			merge: (*dockerCompose.Config).MergeExistingWin,
+			expectedConfig: &dockerCompose.Config{
+				Services: map[string]*dockerCompose.Service{"app": {ULimits: &dockerCompose.ServiceULimits{
+					NProc:  512,
+					NoFile: &dockerCompose.ServiceULimitsNoFile{Hard: 2048, Soft: 1024},
+				}}},
+			},
+		},
+
+		// Duplicated IPAM configs are skipped
+		{
+			configA: &dockerCompose.Config{Networks: map[string]*dockerCompose.Network{"proxy": {IPAM: &dockerCompose.NetworkIPAM{
+				Configs: []*dockerCompose.NetworkIPAMConfig{{Subnet: "10.0.0.0/24"}},
+			}}}},
+			configB: &dockerCompose.Config{Networks: map[string]*dockerCompose.Network{"proxy": {IPAM: &dockerCompose.NetworkIPAM{
+				Configs: []*dockerCompose.NetworkIPAMConfig{{Subnet: "10.0.0.0/24"}, {Subnet: "10.0.1.0/24"}},
+			}}}},
+			merge: (*dockerCompose.Config).MergeLastWin,
+			expectedConfig: &dockerCompose.Config{Networks: map[string]*dockerCompose.Network{"proxy": {IPAM: &dockerCompose.NetworkIPAM{
+				Configs: []*dockerCompose.NetworkIPAMConfig{{Subnet: "10.0.0.0/24"}, {Subnet: "10.0.1.0/24"}},
+			}}}},
+		},
+	}
+
+	for i, testCase := range testCases {
+		testCase.merge(testCase.configA, testCase.configB)
+		require.True(testCase.expectedConfig.Equal(testCase.configA), "Failed test case %v", i)
+	}
+}
+
+// newBenchmarkConfig returns a config with the passed number of services. The offset changes the values of the
+// attributes, therefore configs with different offsets overlap partially.
+func newBenchmarkConfig(services int, offset int) *dockerCompose.Config {
+	config := dockerCompose.NewConfig()
+	for i := 0; i < services; i++ {
+		service := &dockerCompose.Service{
+			Build:           &dockerCompose.ServiceBuild{Context: fmt.Sprintf("./service-%v", i)},
+			CapabilitiesAdd: []string{"NET_ADMIN", fmt.Sprintf("CAP_%v", offset)},
+			Command:         []string{"serve", fmt.Sprintf("--offset=%v", offset)},
+			DependsOnContainer: &dockerCompose.DependsOnContainer{
+				DependsOn: map[string]*dockerCompose.ServiceDependsOn{
+					"db": {Condition: dockerCompose.ServiceDependsOnConditionServiceHealthy},
+				},
+			},
+			Deploy: &dockerCompose.ServiceDeploy{
+				Resources: &dockerCompose.ServiceDeployResources{
+					Limits: &dockerCompose.ServiceDeployResourcesLimits{Memory: fmt.Sprintf("%vM", 256+offset)},
+				},
+			},
+			Image:    fmt.Sprintf("example.local/service-%v:%v", i, offset),
+			Networks: map[string]*dockerCompose.ServiceNetwork{"proxy": {Aliases: []string{fmt.Sprintf("service-%v", i)}}},
+			ULimits:  &dockerCompose.ServiceULimits{NProc: uint(offset), NoFile: &dockerCompose.ServiceULimitsNoFile{Hard: 2048, Soft: 1024}},
+		}
+
+		for j := 0; j < 20; j++ {
+			service.Environments = append(service.Environments, fmt.Sprintf("VARIABLE_%v=%v", j+offset, offset))
+			service.Labels = append(service.Labels, fmt.Sprintf("label.%v=%v", j+offset, offset))
+		}
+
+		for j := 0; j < 5; j++ {
+			service.Ports = append(service.Ports, dockerCompose.Port(fmt.Sprintf("%v:%v", 8000+j+offset, 80+j)))
+			service.Volumes = append(service.Volumes, fmt.Sprintf("./data-%v:/data/%v", offset, j+offset))
+		}
+
+		config.Services[fmt.Sprintf("service-%v", i+offset)] = service
+	}
+
+	config.Networks["proxy"] = &dockerCompose.Network{External: true}
+	config.Volumes[fmt.Sprintf("data-%v", offset)] = &dockerCompose.Volume{}
+
+	return config
+}
+
+// newBenchmarkDocument returns the benchmark config decoded from its YAML document like a docker-compose file,
+// including the origins of all values.
+func newBenchmarkDocument(b *testing.B, services int, offset int) *dockerCompose.Document {
+	data, err := yaml.Marshal(newBenchmarkConfig(services, offset))
+	require.NoError(b, err)
+
+	document := dockerCompose.NewDocument(dockerCompose.NewConfig())
+	require.NoError(b, yaml.Unmarshal(data, document))
+	document.SetSource(fmt.Sprintf("docker-compose-%v.yaml", offset))
+
+	return document
+}
+
+func benchmarkConfigMerge(b *testing.B, mergeFunc func(*dockerCompose.Config, *dockerCompose.Config)) {
+	base := newBenchmarkDocument(b, 100, 0).Config
+	overlay := newBenchmarkDocument(b, 100, 10).Config
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		config := base.Clone()
+		b.StartTimer()
+
+		mergeFunc(config, overlay)
+	}
+}
+
+func BenchmarkConfig_Merge(b *testing.B) {
+	benchmarkConfigMerge(b, (*dockerCompose.Config).Merge)
+}
+
+func BenchmarkConfig_MergeExistingWin(b *testing.B) {
+	benchmarkConfigMerge(b, (*dockerCompose.Config).MergeExistingWin)
+}
+
+func BenchmarkConfig_MergeLastWin(b *testing.B) {
+	benchmarkConfigMerge(b, (*dockerCompose.Config).MergeLastWin)
+}
+
+// benchmarkMerger merges two decoded documents by a merger, which tracks the changes and checks a lock of each service.
+func benchmarkMerger(b *testing.B, strategy dockerCompose.Strategy) {
+	base := newBenchmarkDocument(b, 100, 0)
+	overlay := newBenchmarkDocument(b, 100, 10)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		merger := dockerCompose.NewMerger()
+		merger.Lock("services.*.image")
+		merger.TrackChanges()
+		require.NoError(b, merger.Merge(base.Clone(), strategy))
+		document := overlay.Clone()
+		b.StartTimer()
+
+		require.NoError(b, merger.Merge(document, strategy))
+	}
+}
+
+func BenchmarkMerger_Merge(b *testing.B) {
+	benchmarkMerger(b, dockerCompose.StrategyDefault)
+}
+
+func BenchmarkMerger_MergeExistingWin(b *testing.B) {
+	benchmarkMerger(b, dockerCompose.StrategyExistingWin)
+}
+
+func BenchmarkMerger_MergeLastWin(b *testing.B) {
+	benchmarkMerger(b, dockerCompose.StrategyLastWin)
+}
+
+func BenchmarkConfig_Equal(b *testing.B) {
+	configA := newBenchmarkConfig(50, 0)
+	configB := newBenchmarkConfig(50, 0)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !configA.Equal(configB) {
+			b.Fatal("expected equal configs")
+		}
+	}
+}
+
+func BenchmarkConfig_Clone(b *testing.B) {
+	config := newBenchmarkConfig(50, 0)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = config.Clone()
+	}
+}
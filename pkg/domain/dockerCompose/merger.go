@@ -0,0 +1,103 @@
+package dockerCompose
+
+import (
+	"strings"
+)
+
+// MergeResult is the result of the merge of multiple documents.
+type MergeResult struct {
+	// Document is the merged document. Its provenance contains the origins of all merged values, when the changes were
+	// tracked.
+	Document *Document
+
+	// Report describes the changes of each merged document in the order of the merge, when the changes were tracked.
+	Report *Report
+
+	// LockViolations contains all violations of locked attributes in the order of the merge.
+	LockViolations LockViolations
+}
+
+// Merger merges documents one after another into a single document. Unlike the merge of configs, the merger applies
+// the x-dcmerge and x-dcmerge-lock extension fields of the documents and tracks on request the origins of the merged
+// values and the changes of each document.
+type Merger struct {
+	document       *Document
+	lockViolations LockViolations
+	reports        []*SourceReport
+	trackChanges   bool
+}
+
+// Lock locks the attributes of the passed paths. The segments of a path are separated by dots, the wildcard * matches
+// any segment. Once a locked attribute is declared, a merged document must neither declare a different value nor
+// remove its value or the values below, whatever the strategy merges. Such values are recorded as lock violations, but
+// changes are not reverted.
+func (m *Merger) Lock(paths ...string) {
+	for _, path := range paths {
+		m.document.locks = append(m.document.locks, strings.Split(path, pathSeparator))
+	}
+}
+
+// TrackChanges enables the tracking of the documents merged afterwards. The merge engine records the origins of the
+// merged values in the provenance of the merged document and the status of each attribute of a merged document in the
+// report. Without tracking, the merged document has no provenance and the report is empty.
+func (m *Merger) TrackChanges() {
+	m.trackChanges = true
+}
+
+// Merge merges the passed document by the strategy registered by the passed name. The x-dcmerge extension fields of
+// the document take precedence over the strategy.
+func (m *Merger) Merge(document *Document, strategy Strategy) error {
+	return m.MergeWithPolicy(document, nil, strategy)
+}
+
+// MergeWithPolicy merges the passed document by the strategies of the policy. Attributes, whose path is not matched by
+// any rule of the policy, are merged by the fallback strategy. Without rules, the fallback can be any registered
+// strategy, otherwise only the builtin strategies are supported. The x-dcmerge extension fields of the document take
+// precedence over the policy.
+func (m *Merger) MergeWithPolicy(document *Document, policy *Policy, fallback Strategy) error {
+	if document == nil {
+		return nil
+	}
+
+	policy = policy.withRules(document.policy)
+
+	tracker := newMergeTracker(m.document, document, m.trackChanges)
+
+	err := m.document.Config.mergeTracked(document.Config, policy, fallback, tracker)
+	if err != nil {
+		return err
+	}
+	m.document.anchors = mergeAnchors(m.document.anchors, document.anchors)
+
+	if tracker != nil {
+		m.lockViolations = append(m.lockViolations, tracker.lockViolations()...)
+	}
+
+	if m.trackChanges {
+		m.reports = append(m.reports, tracker.sourceReport())
+	}
+
+	// The locks of the merged document apply to all documents merged afterwards.
+	m.document.locks = append(m.document.locks, cloneLocks(document.locks)...)
+
+	return nil
+}
+
+// Result returns the merged document, the report and the lock violations of all documents merged so far.
+func (m *Merger) Result() *MergeResult {
+	return &MergeResult{
+		Document: m.document,
+		Report: &Report{
+			Sources: append(make([]*SourceReport, 0, len(m.reports)), m.reports...),
+		},
+		LockViolations: append(make(LockViolations, 0, len(m.lockViolations)), m.lockViolations...),
+	}
+}
+
+func NewMerger() *Merger {
+	return &Merger{
+		document:       NewDocument(NewConfig()),
+		lockViolations: make(LockViolations, 0),
+		reports:        make([]*SourceReport, 0),
+	}
+}
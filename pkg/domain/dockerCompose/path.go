@@ -0,0 +1,267 @@
+package dockerCompose
+
+import (
+	"reflect"
+	"strings"
+)
+
+// yamlFieldName returns the name of the struct field in a YAML document and true if the field is inlined.
+func yamlFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("yaml")
+	name, options, _ := strings.Cut(tag, ",")
+	switch {
+	case tag == "-":
+		return "", false
+	case strings.Contains(options, "inline"):
+		return "", true
+	case len(name) <= 0:
+		return strings.ToLower(field.Name), false
+	default:
+		return name, false
+	}
+}
+
+// isHelperField returns true if the struct field has no YAML tag. Such fields are helper fields of a custom YAML
+// representation, for example the dependencies of depends_on, and are transparent in the paths of a config.
+func isHelperField(field reflect.StructField) bool {
+	return len(field.Tag.Get("yaml")) <= 0
+}
+
+// containerOf returns the value, which contains the attribute of the passed name. This is the passed value itself or
+// the value of a helper field of the passed struct, when the struct does not declare the attribute.
+func containerOf(v reflect.Value, name string) reflect.Value {
+	if v.Kind() != reflect.Struct {
+		return v
+	}
+
+	if _, ok := fieldByYAMLName(v, name); ok {
+		return v
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		structField := v.Type().Field(i)
+		if !structField.IsExported() || !isHelperField(structField) {
+			continue
+		}
+
+		if field, ok := indirect(v.Field(i)); ok {
+			return field
+		}
+	}
+
+	return v
+}
+
+// fieldByYAMLName returns the struct field, which is declared in a YAML document by the passed name. Extension fields
+// are looked up in the inline Extensions map and are not addressable.
+func fieldByYAMLName(v reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		structField := v.Type().Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+
+		fieldName, inline := yamlFieldName(structField)
+		switch {
+		case inline && strings.HasPrefix(name, extensionPrefix):
+			extension := v.Field(i).MapIndex(reflect.ValueOf(name))
+			if extension.IsValid() {
+				return extension, true
+			}
+		case fieldName == name:
+			return v.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// indirect dereferences pointers and interfaces until a concrete value is reached. The second return value is false,
+// when a nil pointer or interface was found.
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, v.IsValid()
+}
+
+// resetPath resets the attribute of the passed path to its zero value. Entries of maps and extension fields are
+// removed.
+func resetPath(v reflect.Value, path []string) {
+	v, ok := indirect(v)
+	if !ok || len(path) <= 0 {
+		return
+	}
+
+	if len(path) > 1 {
+		child, ok := childByName(v, path[0])
+		if ok {
+			resetPath(child, path[1:])
+		}
+		return
+	}
+
+	v = containerOf(v, path[0])
+	switch v.Kind() {
+	case reflect.Struct:
+		if strings.HasPrefix(path[0], extensionPrefix) {
+			if extensions, ok := extensionsOf(v); ok {
+				delete(extensions, path[0])
+			}
+			return
+		}
+
+		field, ok := fieldByYAMLName(v, path[0])
+		if ok && field.CanSet() {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			v.SetMapIndex(reflect.ValueOf(path[0]).Convert(v.Type().Key()), reflect.Value{})
+		}
+	}
+}
+
+// removeItemAtPath removes all items of the sequence of the passed path, which are equal to the passed item. When the
+// attribute of the path is a map, for example the dependencies of depends_on, the entry of the item is removed.
+func removeItemAtPath(v reflect.Value, path []string, item string) {
+	v, ok := indirect(v)
+	if !ok {
+		return
+	}
+
+	if len(path) > 0 {
+		child, ok := childByName(v, path[0])
+		if ok {
+			removeItemAtPath(child, path[1:], item)
+		}
+		return
+	}
+
+	v = containerOf(v, item)
+	if v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String {
+		v.SetMapIndex(reflect.ValueOf(item).Convert(v.Type().Key()), reflect.Value{})
+		return
+	}
+
+	if v.Kind() != reflect.Slice || !v.CanSet() {
+		return
+	}
+
+	items := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		existingItem, ok := indirect(v.Index(i))
+		if ok && existingItem.Kind() == reflect.String && existingItem.String() == item {
+			continue
+		}
+		items = reflect.Append(items, v.Index(i))
+	}
+	v.Set(items)
+}
+
+// overridePath replaces the attribute of the passed path of dst by the attribute of src.
+func overridePath(dst reflect.Value, src reflect.Value, path []string) {
+	dst, ok := indirect(dst)
+	if !ok || len(path) <= 0 {
+		return
+	}
+
+	src, ok = indirect(src)
+	if !ok {
+		return
+	}
+
+	srcChild, ok := childByName(src, path[0])
+	if !ok {
+		return
+	}
+
+	if len(path) > 1 {
+		dstChild, ok := childByName(dst, path[0])
+		if ok {
+			if _, notNil := indirect(dstChild); notNil {
+				overridePath(dstChild, srcChild, path[1:])
+				return
+			}
+		}
+	}
+
+	dst = containerOf(dst, path[0])
+	switch dst.Kind() {
+	case reflect.Struct:
+		if strings.HasPrefix(path[0], extensionPrefix) {
+			extensions, ok := extensionsOf(dst)
+			if ok && extensions != nil {
+				extensions[path[0]] = srcChild.Interface()
+			}
+			return
+		}
+
+		field, ok := fieldByYAMLName(dst, path[0])
+		if ok && field.CanSet() {
+			field.Set(srcChild)
+		}
+	case reflect.Map:
+		if dst.IsNil() {
+			if !dst.CanSet() {
+				return
+			}
+			dst.Set(reflect.MakeMap(dst.Type()))
+		}
+		dst.SetMapIndex(reflect.ValueOf(path[0]).Convert(dst.Type().Key()), srcChild)
+	}
+}
+
+// valueAtPath returns the attribute of the passed path.
+func valueAtPath(v reflect.Value, path []string) (reflect.Value, bool) {
+	for _, name := range path {
+		child, ok := childByName(v, name)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		v = child
+	}
+	return v, true
+}
+
+// childByName returns the struct field or map entry of the passed name. Helper fields are transparent.
+func childByName(v reflect.Value, name string) (reflect.Value, bool) {
+	v, ok := indirect(v)
+	if !ok {
+		return reflect.Value{}, false
+	}
+
+	switch v = containerOf(v, name); v.Kind() {
+	case reflect.Struct:
+		return fieldByYAMLName(v, name)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		child := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		return child, child.IsValid()
+	default:
+		return reflect.Value{}, false
+	}
+}
+
+// extensionsOf returns the inline Extensions map of the passed struct.
+func extensionsOf(v reflect.Value) (Extensions, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		if !v.Type().Field(i).IsExported() {
+			continue
+		}
+
+		if extensions, ok := v.Field(i).Interface().(Extensions); ok {
+			if extensions == nil && v.Field(i).CanSet() {
+				extensions = make(Extensions)
+				v.Field(i).Set(reflect.ValueOf(extensions))
+			}
+			return extensions, true
+		}
+	}
+	return nil, false
+}
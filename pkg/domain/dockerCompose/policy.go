@@ -0,0 +1,245 @@
+package dockerCompose
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrUnsupportedStrategy error = errors.New("merge strategy not supported by policies")
+
+// builtinStrategies contains the strategies of the merge engine. Only these strategies can be selected per attribute,
+// because the merge engine looks up the strategy of each attribute.
+var builtinStrategies = map[Strategy]struct{}{
+	StrategyDefault:     {},
+	StrategyExistingWin: {},
+	StrategyLastWin:     {},
+
+	StrategyComposeCompatible: {},
+}
+
+// pathSeparator separates the segments of a path like services.*.image.
+const pathSeparator string = "."
+
+// pathWildcard matches any segment of a path.
+const pathWildcard string = "*"
+
+// Policy maps paths of a docker-compose file to merge strategies. The paths are declared by the attribute names of a
+// docker-compose file separated by dots, for example services.*.image. The wildcard * matches any segment. A rule
+// applies to the attribute of its path and all attributes below.
+type Policy struct {
+	rules []*policyRule
+}
+
+type policyRule struct {
+	pattern  []string
+	strategy Strategy
+}
+
+// matches returns true if the rule applies to the passed path.
+func (pr *policyRule) matches(path []string) bool {
+	if len(pr.pattern) > len(path) {
+		return false
+	}
+	return matchesPattern(pr.pattern, path)
+}
+
+// wildcards returns the number of wildcards of the pattern.
+func (pr *policyRule) wildcards() int {
+	wildcards := 0
+	for _, segment := range pr.pattern {
+		if segment == pathWildcard {
+			wildcards++
+		}
+	}
+	return wildcards
+}
+
+// Clone returns a deep copy of the policy.
+func (p *Policy) Clone() *Policy {
+	if p == nil {
+		return nil
+	}
+
+	return &Policy{
+		rules: CloneSlice(p.rules),
+	}
+}
+
+// Clone returns a deep copy of the rule.
+func (pr *policyRule) Clone() *policyRule {
+	if pr == nil {
+		return nil
+	}
+
+	return &policyRule{
+		pattern:  cloneSlice(pr.pattern),
+		strategy: pr.strategy,
+	}
+}
+
+// AddRule adds a rule, which merges the attributes of the passed path by the strategy.
+func (p *Policy) AddRule(path string, strategy Strategy) error {
+	return p.addRule(strings.Split(path, pathSeparator), strategy)
+}
+
+// addRule adds a rule, which merges the attributes matching the pattern by the strategy. An empty pattern matches all
+// attributes.
+func (p *Policy) addRule(pattern []string, strategy Strategy) error {
+	if !isBuiltinStrategy(strategy) {
+		return fmt.Errorf("%w: %s", ErrUnknownStrategy, strategy)
+	}
+
+	p.rules = append(p.rules, &policyRule{
+		pattern:  pattern,
+		strategy: strategy,
+	})
+
+	return nil
+}
+
+// withRules returns a new policy with the rules of the policy followed by the rules of the passed policy. On the same
+// specificity, the rules of the passed policy win.
+func (p *Policy) withRules(policy *Policy) *Policy {
+	switch {
+	case policy == nil:
+		return p
+	case p == nil:
+		return policy
+	}
+
+	return &Policy{
+		rules: append(append(make([]*policyRule, 0, len(p.rules)+len(policy.rules)), p.rules...), policy.rules...),
+	}
+}
+
+// Strategy returns the strategy of the passed path. When multiple rules apply, the most specific rule wins. A rule is
+// more specific if its path is longer or contains fewer wildcards. When no rule applies, the fallback is returned.
+func (p *Policy) Strategy(path []string, fallback Strategy) Strategy {
+	var matchingRule *policyRule
+	for _, rule := range p.rules {
+		switch {
+		case !rule.matches(path):
+			continue
+		case matchingRule == nil:
+			fallthrough
+		case len(rule.pattern) > len(matchingRule.pattern):
+			fallthrough
+		case len(rule.pattern) == len(matchingRule.pattern) && rule.wildcards() <= matchingRule.wildcards():
+			matchingRule = rule
+		}
+	}
+
+	if matchingRule == nil {
+		return fallback
+	}
+
+	return matchingRule.strategy
+}
+
+// hasRulesBelow returns true if a rule applies to an attribute below the passed path.
+func (p *Policy) hasRulesBelow(path []string) bool {
+	for _, rule := range p.rules {
+		if len(rule.pattern) > len(path) && matchesPattern(rule.pattern[:len(path)], path) {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalYAML implements the UnmarshalYAML interface to customize the behavior when being unmarshaled into a YAML
+// document. A policy is declared as mapping of paths to strategies. The order of the rules is kept.
+func (p *Policy) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %v: expected a mapping of paths to merge strategies", value.Line)
+	}
+
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		keyNode, valueNode := value.Content[i], value.Content[i+1]
+
+		err := p.AddRule(keyNode.Value, Strategy(valueNode.Value))
+		if err != nil {
+			return fmt.Errorf("line %v: %w", valueNode.Line, err)
+		}
+	}
+
+	return nil
+}
+
+func NewPolicy() *Policy {
+	return &Policy{
+		rules: make([]*policyRule, 0),
+	}
+}
+
+// matchesPattern returns true if each segment of the path matches the segment of the pattern at the same position.
+func matchesPattern(pattern []string, path []string) bool {
+	for i := range pattern {
+		if pattern[i] != pathWildcard && pattern[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// MergeWithPolicy merges the passed config by the strategies of the policy. Attributes, whose path is not matched by any
+// rule of the policy, are merged by the fallback strategy. Without rules, the fallback can be any registered strategy,
+// otherwise only the builtin strategies are supported.
+func (c *Config) MergeWithPolicy(config *Config, policy *Policy, fallback Strategy) error {
+	return c.mergeTracked(config, policy, fallback, nil)
+}
+
+// mergeTracked merges the passed config like MergeWithPolicy and records the decisions of the merge by the tracker,
+// unless the tracker is nil. The merge engine records the decisions of the builtin strategies, the changes of other
+// strategies are recorded by comparing the config before and after the merge.
+func (c *Config) mergeTracked(config *Config, policy *Policy, fallback Strategy, tracker *mergeTracker) error {
+	if policy != nil && len(policy.rules) <= 0 {
+		policy = nil
+	}
+
+	switch {
+	case c == nil || config == nil:
+		return nil
+	case isBuiltinStrategy(fallback):
+		c.mergeConfig(config, policy, fallback, tracker)
+		return nil
+	case policy != nil:
+		return fmt.Errorf("%w: %s", ErrUnsupportedStrategy, fallback)
+	case tracker == nil:
+		return c.MergeWithStrategy(config, fallback)
+	}
+
+	beforeValues := leafValues(c)
+
+	err := c.MergeWithStrategy(config, fallback)
+	if err != nil {
+		return err
+	}
+
+	tracker.recordMerged(make([]string, 0), beforeValues, reflect.ValueOf(config), reflect.ValueOf(c))
+	return nil
+}
+
+// MergedWithPolicy returns a new config, into which the passed config is merged by the strategies of the policy.
+// Neither the config nor the passed config are modified.
+func (c *Config) MergedWithPolicy(config *Config, policy *Policy, fallback Strategy) (*Config, error) {
+	mergedConfig := c.cloneOrNew()
+	err := mergedConfig.MergeWithPolicy(config, policy, fallback)
+	if err != nil {
+		return nil, err
+	}
+	return mergedConfig, nil
+}
+
+// isBuiltinStrategy returns true if the strategy is implemented by the merge engine.
+func isBuiltinStrategy(strategy Strategy) bool {
+	_, builtin := builtinStrategies[strategy]
+	return builtin
+}
+
+func appendPath(path []string, name string) []string {
+	return append(append(make([]string, 0, len(path)+1), path...), name)
+}
@@ -0,0 +1,332 @@
+package dockerCompose_test
+
+import (
+	"strings"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPolicy_Strategy(t *testing.T) {
+	require := require.New(t)
+
+	policy := dockerCompose.NewPolicy()
+	require.NoError(yaml.Unmarshal([]byte(`services.*.image: last-win
+services.db.environment: existing-win
+services.*.environment: last-win
+networks.*: existing-win
+`), policy))
+
+	testCases := []struct {
+		path             string
+		expectedStrategy dockerCompose.Strategy
+	}{
+		{path: "services", expectedStrategy: dockerCompose.StrategyDefault},
+		{path: "services.app.image", expectedStrategy: dockerCompose.StrategyLastWin},
+		{path: "services.app.environment", expectedStrategy: dockerCompose.StrategyLastWin},
+		{path: "services.db.environment", expectedStrategy: dockerCompose.StrategyExistingWin},
+		{path: "services.db.ports", expectedStrategy: dockerCompose.StrategyDefault},
+		{path: "networks.frontend", expectedStrategy: dockerCompose.StrategyExistingWin},
+		{path: "networks.frontend.ipam.config", expectedStrategy: dockerCompose.StrategyExistingWin},
+	}
+
+	for i, testCase := range testCases {
+		strategy := policy.Strategy(strings.Split(testCase.path, "."), dockerCompose.StrategyDefault)
+		require.Equal(testCase.expectedStrategy, strategy, "TestCase %v", i)
+	}
+}
+
+func TestPolicy_UnmarshalYAML(t *testing.T) {
+	require := require.New(t)
+
+	err := yaml.Unmarshal([]byte(`services.*.image: first-win`), dockerCompose.NewPolicy())
+	require.ErrorIs(err, dockerCompose.ErrUnknownStrategy)
+}
+
+func TestConfig_MergeWithPolicy(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		policy         string
+		fallback       dockerCompose.Strategy
+		configA        string
+		configB        string
+		expectedConfig string
+	}{
+		{
+			policy:   `services.*.image: last-win`,
+			fallback: dockerCompose.StrategyExistingWin,
+			configA: `services:
+  app:
+    environment:
+    - LOG_LEVEL=info
+    image: example.local/app:1.0.0
+`,
+			configB: `services:
+  app:
+    environment:
+    - LOG_LEVEL=debug
+    - TZ=UTC
+    image: example.local/app:2.0.0
+  db:
+    image: postgres
+`,
+			expectedConfig: `services:
+  app:
+    environment:
+    - LOG_LEVEL=info
+    - TZ=UTC
+    image: example.local/app:2.0.0
+  db:
+    image: postgres
+`,
+		},
+		{
+			policy: `services.*.image: last-win
+services.app.environment: existing-win`,
+			fallback: dockerCompose.StrategyDefault,
+			configA: `services:
+  app:
+    environment:
+    - LOG_LEVEL=info
+    image: example.local/app:1.0.0
+    ports:
+    - 8080:80
+  db:
+    environment:
+    - POSTGRES_DB=app
+    image: postgres:15
+`,
+			configB: `services:
+  app:
+    environment:
+    - LOG_LEVEL=debug
+    - TZ=UTC
+    image: example.local/app:2.0.0
+    ports:
+    - 8443:443
+  db:
+    environment:
+    - POSTGRES_USER=app
+    image: postgres:16
+volumes:
+  data: {}
+`,
+			expectedConfig: `services:
+  app:
+    environment:
+    - LOG_LEVEL=info
+    - TZ=UTC
+    image: example.local/app:2.0.0
+    ports:
+    - 8080:80
+  db:
+    environment:
+    - POSTGRES_DB=app
+    image: postgres:16
+volumes:
+  data: {}
+`,
+		},
+		{
+			policy:   `networks.*: existing-win`,
+			fallback: dockerCompose.StrategyLastWin,
+			configA: `networks:
+  frontend:
+    x-description: frontend
+services:
+  app:
+    image: example.local/app:1.0.0
+`,
+			configB: `networks:
+  frontend:
+    x-description: public frontend
+services:
+  app:
+    image: example.local/app:2.0.0
+`,
+			expectedConfig: `networks:
+  frontend:
+    x-description: frontend
+services:
+  app:
+    image: example.local/app:2.0.0
+`,
+		},
+		{
+			policy:   `x-version: last-win`,
+			fallback: dockerCompose.StrategyExistingWin,
+			configA: `x-version: 1
+x-owner: team-a
+`,
+			configB: `x-version: 2
+x-owner: team-b
+`,
+			expectedConfig: `x-version: 2
+x-owner: team-a
+`,
+		},
+		{
+			policy:   `services.app: last-win`,
+			fallback: dockerCompose.StrategyDefault,
+			configA: `services:
+  app:
+    image: example.local/app:1.0.0
+  db:
+    image: postgres:15
+`,
+			configB: `services:
+  app:
+    image: example.local/app:2.0.0
+  db:
+    image: postgres:16
+`,
+			expectedConfig: `services:
+  app:
+    image: example.local/app:2.0.0
+  db:
+    image: postgres:15
+`,
+		},
+		{
+			policy:   `services.*.depends_on.db: last-win`,
+			fallback: dockerCompose.StrategyExistingWin,
+			configA: `services:
+  app:
+    depends_on:
+      db:
+        condition: service_started
+`,
+			configB: `services:
+  app:
+    depends_on:
+      db:
+        condition: service_healthy
+`,
+			expectedConfig: `services:
+  app:
+    depends_on:
+      db:
+        condition: service_healthy
+`,
+		},
+	}
+
+	for i, testCase := range testCases {
+		policy := dockerCompose.NewPolicy()
+		require.NoError(yaml.Unmarshal([]byte(testCase.policy), policy), "TestCase %v", i)
+
+		configA := dockerCompose.NewConfig()
+		require.NoError(yaml.Unmarshal([]byte(testCase.configA), configA), "TestCase %v", i)
+
+		configB := dockerCompose.NewConfig()
+		require.NoError(yaml.Unmarshal([]byte(testCase.configB), configB), "TestCase %v", i)
+
+		expectedConfig := dockerCompose.NewConfig()
+		require.NoError(yaml.Unmarshal([]byte(testCase.expectedConfig), expectedConfig), "TestCase %v", i)
+
+		require.NoError(configA.MergeWithPolicy(configB, policy, testCase.fallback), "TestCase %v", i)
+
+		expectedYAML, err := yaml.Marshal(expectedConfig)
+		require.NoError(err, "TestCase %v", i)
+		actualYAML, err := yaml.Marshal(configA)
+		require.NoError(err, "TestCase %v", i)
+		require.Equal(string(expectedYAML), string(actualYAML), "TestCase %v", i)
+	}
+}
+
+func TestMerger_MergeInlineDirectives(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		strategy       dockerCompose.Strategy
+		configA        string
+		configB        string
+		expectedConfig string
+	}{
+		{
+			strategy: dockerCompose.StrategyExistingWin,
+			configA: `services:
+  app:
+    environment:
+    - LOG_LEVEL=info
+    image: example.local/app:1.0.0
+x-owner: team-a
+`,
+			configB: `services:
+  app:
+    environment:
+    - LOG_LEVEL=debug
+    image: example.local/app:2.0.0
+x-dcmerge:
+  strategy: last-win
+  fields:
+    services.app.environment: existing-win
+x-owner: team-b
+`,
+			expectedConfig: `services:
+  app:
+    environment:
+    - LOG_LEVEL=info
+    image: example.local/app:2.0.0
+x-owner: team-b
+`,
+		},
+		{
+			strategy: dockerCompose.StrategyLastWin,
+			configA: `services:
+  app:
+    environment:
+    - LOG_LEVEL=info
+    image: example.local/app:1.0.0
+  db:
+    image: postgres:15
+`,
+			configB: `services:
+  app:
+    environment:
+    - LOG_LEVEL=debug
+    image: example.local/app:2.0.0
+    x-dcmerge:
+      strategy: existing-win
+      fields:
+        image: last-win
+  db:
+    image: postgres:16
+`,
+			expectedConfig: `services:
+  app:
+    environment:
+    - LOG_LEVEL=info
+    image: example.local/app:2.0.0
+  db:
+    image: postgres:16
+`,
+		},
+	}
+
+	for i, testCase := range testCases {
+		merger := dockerCompose.NewMerger()
+		for _, config := range []string{testCase.configA, testCase.configB} {
+			document := dockerCompose.NewDocument(dockerCompose.NewConfig())
+			require.NoError(yaml.Unmarshal([]byte(config), document), "TestCase %v", i)
+			require.NoError(merger.Merge(document, testCase.strategy), "TestCase %v", i)
+		}
+
+		expectedConfig := dockerCompose.NewConfig()
+		require.NoError(yaml.Unmarshal([]byte(testCase.expectedConfig), expectedConfig), "TestCase %v", i)
+
+		expectedYAML, err := yaml.Marshal(expectedConfig)
+		require.NoError(err, "TestCase %v", i)
+		actualYAML, err := yaml.Marshal(merger.Result().Document.Config)
+		require.NoError(err, "TestCase %v", i)
+		require.Equal(string(expectedYAML), string(actualYAML), "TestCase %v", i)
+	}
+
+	err := yaml.Unmarshal([]byte(`x-dcmerge:
+  strategy: first-win
+`), dockerCompose.NewDocument(dockerCompose.NewConfig()))
+	require.ErrorIs(err, dockerCompose.ErrUnknownStrategy)
+}
@@ -0,0 +1,287 @@
+package dockerCompose
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Origin is the location of a value in a source.
+type Origin struct {
+	Source string `json:"source"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+	Value  string `json:"value"`
+
+	// Overridden contains the origins of all values, which were overridden by this value in the order of the merge.
+	Overridden []*Origin `json:"overridden,omitempty"`
+}
+
+// Clone returns a deep copy of the origin.
+func (o *Origin) Clone() *Origin {
+	if o == nil {
+		return nil
+	}
+
+	return &Origin{
+		Source:     o.Source,
+		Line:       o.Line,
+		Column:     o.Column,
+		Value:      o.Value,
+		Overridden: CloneSlice(o.Overridden),
+	}
+}
+
+// String returns the location of the origin in the form source:line:column.
+func (o *Origin) String() string {
+	if o.Line <= 0 {
+		return o.Source
+	}
+	return fmt.Sprintf("%s:%v:%v", o.Source, o.Line, o.Column)
+}
+
+// Provenance maps the paths of all leaf values of a config to their origin. The segments of a path are separated by
+// dots. Items of environment and labels are identified by their name, ports by their source, volumes by their
+// destination and items of other lists by their value.
+type Provenance map[string]*Origin
+
+// Clone returns a deep copy of the provenance.
+func (p Provenance) Clone() Provenance {
+	return Provenance(CloneStringMap(map[string]*Origin(p)))
+}
+
+// Lookup returns the origins of the passed path and all paths below in alphabetical order.
+func (p Provenance) Lookup(path string) []string {
+	paths := make([]string, 0)
+	for leafPath := range p {
+		if leafPath == path || strings.HasPrefix(leafPath, path+pathSeparator) || len(path) <= 0 {
+			paths = append(paths, leafPath)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// newProvenance returns the origins of all leaf values of the config, which was decoded from the passed node. Leaf
+// values without a node of their own, for example the context of the short syntax of build, get the location of the
+// nearest parent node.
+func newProvenance(node *yaml.Node, c *Config) Provenance {
+	positions := make(map[string]*yaml.Node)
+	walkNodePaths(node, make([]string, 0), func(path []string, keyNode *yaml.Node, valueNode *yaml.Node) {
+		if valueNode.Kind == yaml.ScalarNode || keyNode == nil {
+			positions[strings.Join(path, pathSeparator)] = valueNode
+			return
+		}
+		positions[strings.Join(path, pathSeparator)] = keyNode
+	})
+
+	provenance := make(Provenance)
+	for path, value := range leafValues(c) {
+		origin := &Origin{Value: value}
+		for segments := strings.Split(path, pathSeparator); len(segments) > 0; segments = segments[:len(segments)-1] {
+			if positionNode, present := positions[strings.Join(segments, pathSeparator)]; present {
+				origin.Line = positionNode.Line
+				origin.Column = positionNode.Column
+				break
+			}
+		}
+		provenance[path] = origin
+	}
+
+	return provenance
+}
+
+// recordOrigin records the origin of the value of the passed path, which was applied by the merge of the source. The
+// origin of the previous value is kept as overridden origin, when the path existed before.
+func (d *Document) recordOrigin(path string, value string, previousValue string, existed bool, source *Document) {
+	if d.provenance == nil {
+		d.provenance = make(Provenance)
+	}
+
+	origin := &Origin{Source: source.source, Value: value}
+	if sourceOrigin, present := source.Provenance()[path]; present {
+		origin.Source = sourceOrigin.Source
+		origin.Line = sourceOrigin.Line
+		origin.Column = sourceOrigin.Column
+		origin.Overridden = CloneSlice(sourceOrigin.Overridden)
+	}
+
+	if existed {
+		previousOrigin, present := d.provenance[path]
+		if !present {
+			previousOrigin = &Origin{Value: previousValue}
+		}
+
+		overridden := append(CloneSlice(previousOrigin.Overridden), &Origin{
+			Source: previousOrigin.Source,
+			Line:   previousOrigin.Line,
+			Column: previousOrigin.Column,
+			Value:  previousOrigin.Value,
+		})
+		origin.Overridden = append(overridden, origin.Overridden...)
+	}
+
+	d.provenance[path] = origin
+}
+
+// removeOrigin removes the origin of the value of the passed path, which was removed by a merge.
+func (d *Document) removeOrigin(path string) {
+	delete(d.provenance, path)
+}
+
+// AnnotateProvenance adds the origin of each leaf value as line comment to the passed node of a config.
+func AnnotateProvenance(node *yaml.Node, provenance Provenance) {
+	walkNodePaths(node, make([]string, 0), func(path []string, keyNode *yaml.Node, valueNode *yaml.Node) {
+		origin, present := provenance[strings.Join(path, pathSeparator)]
+		switch {
+		case !present:
+			return
+		case valueNode.Kind == yaml.ScalarNode || keyNode == nil:
+			valueNode.LineComment = origin.String()
+		default:
+			keyNode.LineComment = origin.String()
+		}
+	})
+}
+
+// walkNodePaths calls the function for each value of the passed node with its path. The key node is nil for items of
+// a sequence. The paths are built by the same rules like the paths of the provenance.
+func walkNodePaths(node *yaml.Node, path []string, fn func(path []string, keyNode *yaml.Node, valueNode *yaml.Node)) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			walkNodePaths(child, path, fn)
+		}
+	case yaml.AliasNode:
+		walkNodePaths(node.Alias, path, fn)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			if keyNode.Value == "<<" {
+				walkNodePaths(valueNode, path, fn)
+				continue
+			}
+
+			valuePath := appendPath(path, keyNode.Value)
+			fn(valuePath, keyNode, valueNode)
+			walkNodePaths(valueNode, valuePath, fn)
+		}
+	case yaml.SequenceNode:
+		name := ""
+		if len(path) > 0 {
+			name = path[len(path)-1]
+		}
+
+		if _, replaced := replacedLists[name]; replaced {
+			return
+		}
+
+		keyFunc, keyed := keyedLists[name]
+		for i, itemNode := range node.Content {
+			switch {
+			case itemNode.Kind == yaml.ScalarNode && keyed:
+				fn(appendPath(path, keyFunc(itemNode.Value).String()), nil, itemNode)
+			case itemNode.Kind == yaml.ScalarNode:
+				fn(appendPath(path, itemNode.Value), nil, itemNode)
+			default:
+				itemPath := appendPath(path, strconv.Itoa(i))
+				fn(itemPath, nil, itemNode)
+				walkNodePaths(itemNode, itemPath, fn)
+			}
+		}
+	}
+}
+
+// leafValues returns the readable representation of all leaf values of the passed value by their path.
+func leafValues(v interface{}) map[string]string {
+	return leafValuesAt(reflect.ValueOf(v), make([]string, 0))
+}
+
+// leafValuesAt returns the readable representation of all leaf values of the passed value of the path by their path.
+// An invalid value has no leaf values.
+func leafValuesAt(v reflect.Value, path []string) map[string]string {
+	values := make(map[string]string)
+	if !v.IsValid() {
+		return values
+	}
+
+	walkLeaves(v, path, func(path []string, value reflect.Value) {
+		values[strings.Join(path, pathSeparator)] = formatValue(value)
+	})
+	return values
+}
+
+// itemPath returns the path of an item of the list of the passed path by the same rules like walkLeaves.
+func itemPath(path []string, item string) string {
+	name := ""
+	if len(path) > 0 {
+		name = path[len(path)-1]
+	}
+
+	if keyFunc, keyed := keyedLists[name]; keyed {
+		item = keyFunc(item).String()
+	}
+	return strings.Join(appendPath(path, item), pathSeparator)
+}
+
+// walkLeaves calls the function for each leaf value of the passed value, which is not zero.
+func walkLeaves(v reflect.Value, path []string, fn func(path []string, value reflect.Value)) {
+	if isZero(v) {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		walkLeaves(v.Elem(), path, fn)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			structField := v.Type().Field(i)
+			if !structField.IsExported() {
+				continue
+			}
+
+			name, inline := yamlFieldName(structField)
+			switch {
+			case inline || isHelperField(structField):
+				walkLeaves(v.Field(i), path, fn)
+			case len(name) > 0:
+				walkLeaves(v.Field(i), appendPath(path, name), fn)
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			walkLeaves(v.MapIndex(key), appendPath(path, fmt.Sprint(key.Interface())), fn)
+		}
+	case reflect.Slice:
+		name := ""
+		if len(path) > 0 {
+			name = path[len(path)-1]
+		}
+
+		keyFunc, keyed := keyedLists[name]
+		_, replaced := replacedLists[name]
+
+		switch {
+		case replaced:
+			fn(path, v)
+		case v.Type().Elem().Kind() == reflect.String && keyed:
+			for i := 0; i < v.Len(); i++ {
+				fn(appendPath(path, keyFunc(v.Index(i).String()).String()), v.Index(i))
+			}
+		case v.Type().Elem().Kind() == reflect.String:
+			for i := 0; i < v.Len(); i++ {
+				fn(appendPath(path, v.Index(i).String()), v.Index(i))
+			}
+		default:
+			for i := 0; i < v.Len(); i++ {
+				walkLeaves(v.Index(i), appendPath(path, strconv.Itoa(i)), fn)
+			}
+		}
+	default:
+		fn(path, v)
+	}
+}
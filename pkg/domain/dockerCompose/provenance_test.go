@@ -0,0 +1,189 @@
+package dockerCompose_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMerger_Provenance(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		sources            []string
+		strategy           dockerCompose.Strategy
+		expectedProvenance dockerCompose.Provenance
+	}{
+		{
+			sources: []string{
+				`services:
+  app:
+    environment:
+    - LOG_LEVEL=info
+    image: example.local/app:1.0.0
+`,
+				`services:
+  app:
+    environment:
+    - LOG_LEVEL=debug
+    - TZ=UTC
+`,
+			},
+			strategy: dockerCompose.StrategyLastWin,
+			expectedProvenance: dockerCompose.Provenance{
+				"services.app.environment.LOG_LEVEL": &dockerCompose.Origin{
+					Source: "source-1", Line: 4, Column: 7, Value: "LOG_LEVEL=debug",
+					Overridden: []*dockerCompose.Origin{
+						{Source: "source-0", Line: 4, Column: 7, Value: "LOG_LEVEL=info"},
+					},
+				},
+				"services.app.environment.TZ": &dockerCompose.Origin{Source: "source-1", Line: 5, Column: 7, Value: "TZ=UTC"},
+				"services.app.image":          &dockerCompose.Origin{Source: "source-0", Line: 5, Column: 12, Value: "example.local/app:1.0.0"},
+			},
+		},
+		{
+			sources: []string{
+				`services:
+  app:
+    environment:
+    - LOG_LEVEL=info
+`,
+				`services:
+  app:
+    environment:
+    - LOG_LEVEL=debug
+`,
+			},
+			strategy: dockerCompose.StrategyExistingWin,
+			expectedProvenance: dockerCompose.Provenance{
+				"services.app.environment.LOG_LEVEL": &dockerCompose.Origin{Source: "source-0", Line: 4, Column: 7, Value: "LOG_LEVEL=info"},
+			},
+		},
+		{
+			sources: []string{
+				`x-defaults: &defaults
+  image: example.local/app:1.0.0
+services:
+  app:
+    <<: *defaults
+    build: ./app
+`,
+				`services:
+  app:
+    image: example.local/app:1.1.0
+`,
+				`services:
+  app:
+    image: example.local/app:1.2.0
+`,
+			},
+			strategy: dockerCompose.StrategyLastWin,
+			expectedProvenance: dockerCompose.Provenance{
+				"services.app.build.context": &dockerCompose.Origin{Source: "source-0", Line: 6, Column: 12, Value: "./app"},
+				"services.app.image": &dockerCompose.Origin{
+					Source: "source-2", Line: 3, Column: 12, Value: "example.local/app:1.2.0",
+					Overridden: []*dockerCompose.Origin{
+						{Source: "source-0", Line: 2, Column: 10, Value: "example.local/app:1.0.0"},
+						{Source: "source-1", Line: 3, Column: 12, Value: "example.local/app:1.1.0"},
+					},
+				},
+				"x-defaults.image": &dockerCompose.Origin{Source: "source-0", Line: 2, Column: 10, Value: "example.local/app:1.0.0"},
+			},
+		},
+		{
+			sources: []string{
+				`services:
+  app:
+    volumes:
+    - /data
+    - /cache
+`,
+				`services:
+  app:
+    volumes:
+    - ./data:/data
+`,
+			},
+			strategy: dockerCompose.StrategyLastWin,
+			expectedProvenance: dockerCompose.Provenance{
+				"services.app.volumes./cache": &dockerCompose.Origin{Source: "source-0", Line: 5, Column: 7, Value: "/cache"},
+				"services.app.volumes./data": &dockerCompose.Origin{
+					Source: "source-1", Line: 4, Column: 7, Value: "./data:/data",
+					Overridden: []*dockerCompose.Origin{
+						{Source: "source-0", Line: 4, Column: 7, Value: "/data"},
+					},
+				},
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		merger := dockerCompose.NewMerger()
+		merger.TrackChanges()
+		for j, source := range testCase.sources {
+			document := dockerCompose.NewDocument(dockerCompose.NewConfig())
+			require.NoError(yaml.Unmarshal([]byte(source), document), "TestCase %v", i)
+			document.SetSource(fmt.Sprintf("source-%v", j))
+			require.NoError(merger.Merge(document, testCase.strategy), "TestCase %v", i)
+		}
+		require.Equal(testCase.expectedProvenance, merger.Result().Document.Provenance(), "TestCase %v", i)
+	}
+}
+
+func TestDocument_Provenance(t *testing.T) {
+	require := require.New(t)
+
+	document := dockerCompose.NewDocument(dockerCompose.NewConfig())
+	require.NoError(yaml.Unmarshal([]byte(`services:
+  app:
+    image: example.local/app:1.0.0
+`), document))
+	document.SetSource("source-0")
+
+	// The origins are determined on request from the current config.
+	document.Config.Services["app"].Image = "example.local/app:1.1.0"
+
+	expectedProvenance := dockerCompose.Provenance{
+		"services.app.image": &dockerCompose.Origin{Source: "source-0", Line: 3, Column: 12, Value: "example.local/app:1.1.0"},
+	}
+	require.Equal(expectedProvenance, document.Provenance())
+
+	// Once determined, the origins are kept.
+	document.Config.Services["app"].Image = "example.local/app:1.2.0"
+	require.Equal(expectedProvenance, document.Provenance())
+
+	require.Nil(dockerCompose.NewDocument(dockerCompose.NewConfig()).Provenance())
+}
+
+func TestAnnotateProvenance(t *testing.T) {
+	require := require.New(t)
+
+	document := dockerCompose.NewDocument(dockerCompose.NewConfig())
+	require.NoError(yaml.Unmarshal([]byte(`services:
+  app:
+    environment:
+    - LOG_LEVEL=info
+    image: example.local/app:1.0.0
+`), document))
+	document.SetSource("docker-compose.yaml")
+
+	node := new(yaml.Node)
+	require.NoError(node.Encode(document.Config))
+	dockerCompose.AnnotateProvenance(node, document.Provenance())
+
+	b := new(bytes.Buffer)
+	yamlEncoder := yaml.NewEncoder(b)
+	yamlEncoder.SetIndent(2)
+	require.NoError(yamlEncoder.Encode(node))
+
+	require.Equal(`services:
+  app:
+    environment:
+      - LOG_LEVEL=info # docker-compose.yaml:4:7
+    image: example.local/app:1.0.0 # docker-compose.yaml:5:12
+`, b.String())
+}
@@ -0,0 +1,147 @@
+package dockerCompose
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+var ErrRemoteBase error = errors.New("relative paths of a remote docker-compose file can not be rebased")
+
+// RebasePaths rewrites all relative paths of the config to refer to the passed directory instead of the base of the
+// config. Rebased are the sources of bind mounts and the build context, env files of services and files of secrets.
+// The base of the config is set to the directory afterwards.
+func (c *Config) RebasePaths(directory string) error {
+	if len(c.base) <= 0 {
+		return nil
+	}
+
+	baseURL, err := url.Parse(c.base)
+	if err != nil {
+		return err
+	}
+
+	if baseURL.Scheme != "" && baseURL.Scheme != "file" {
+		return ErrRemoteBase
+	}
+
+	from, err := filepath.Abs(baseURL.Path)
+	if err != nil {
+		return err
+	}
+
+	to, err := filepath.Abs(directory)
+	if err != nil {
+		return err
+	}
+
+	for _, secret := range c.Secrets {
+		if secret == nil {
+			continue
+		}
+
+		secret.File, err = rebasePath(secret.File, from, to)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, service := range c.Services {
+		err = service.rebasePaths(from, to)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.base = to
+
+	return nil
+}
+
+// rebasePaths rewrites the relative paths of the service, which refer to the directory from, to refer to the directory
+// to.
+func (s *Service) rebasePaths(from string, to string) error {
+	if s == nil {
+		return nil
+	}
+
+	var err error
+
+	if s.Build != nil && !isRemoteBuildContext(s.Build.Context) {
+		s.Build.Context, err = rebasePath(s.Build.Context, from, to)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range s.EnvFile {
+		s.EnvFile[i], err = rebasePath(s.EnvFile[i], from, to)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, volume := range s.Volumes {
+		src, dest, perm, err := splitStringInVolume(volume)
+		if err != nil {
+			return err
+		}
+
+		// Only sources starting with a dot are relative bind mounts. Other sources are named volumes.
+		if !strings.HasPrefix(src, ".") {
+			continue
+		}
+
+		src, err = rebasePath(src, from, to)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case len(perm) <= 0:
+			s.Volumes[i] = fmt.Sprintf("%s%s%s", src, volumeDelimiter, dest)
+		default:
+			s.Volumes[i] = fmt.Sprintf("%s%s%s%s%s", src, volumeDelimiter, dest, volumeDelimiter, perm)
+		}
+	}
+
+	return nil
+}
+
+// isRemoteBuildContext returns true if the build context is an URL or a git repository.
+func isRemoteBuildContext(context string) bool {
+	return strings.Contains(context, "://") || strings.HasPrefix(context, "git@")
+}
+
+// rebasePath returns the relative path, which refers to the directory from, relative to the directory to. Absolute
+// paths, paths of the home directory and paths containing variable expressions are returned unchanged. The returned path
+// starts always with a dot, otherwise it would be interpreted as named volume.
+func rebasePath(path string, from string, to string) (string, error) {
+	switch {
+	case len(path) <= 0:
+		fallthrough
+	case filepath.IsAbs(path):
+		fallthrough
+	case strings.HasPrefix(path, "~"):
+		fallthrough
+	case strings.HasPrefix(path, "$"):
+		return path, nil
+	}
+
+	rebasedPath, err := filepath.Rel(to, filepath.Join(from, path))
+	if err != nil {
+		return "", err
+	}
+
+	rebasedPath = filepath.ToSlash(rebasedPath)
+	switch {
+	case rebasedPath == "." || rebasedPath == "..":
+		return rebasedPath, nil
+	case strings.HasPrefix(rebasedPath, "../"):
+		return rebasedPath, nil
+	default:
+		return "./" + rebasedPath, nil
+	}
+}
@@ -0,0 +1,106 @@
+package dockerCompose_test
+
+import (
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig_RebasePaths(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		base           string
+		directory      string
+		config         string
+		expectedConfig string
+		expectedError  error
+	}{
+		{
+			base:      "/project/integration-test",
+			directory: "/project",
+			config: `secrets:
+  password:
+    file: ./secrets/password.txt
+services:
+  app:
+    build: ./app
+    env_file: .env.test
+    volumes:
+    - ./data:/data:ro
+    - ../config:/etc/app
+    - /srv/log:/var/log
+    - cache:/var/cache
+    - ${DATA_DIR}:/srv
+`,
+			expectedConfig: `secrets:
+  password:
+    file: ./integration-test/secrets/password.txt
+services:
+  app:
+    build: ./integration-test/app
+    env_file:
+    - ./integration-test/.env.test
+    volumes:
+    - ./integration-test/data:/data:ro
+    - ./config:/etc/app
+    - /srv/log:/var/log
+    - cache:/var/cache
+    - ${DATA_DIR}:/srv
+`,
+		},
+		{
+			base:      "/project",
+			directory: "/project/output",
+			config: `services:
+  app:
+    build:
+      context: .
+      dockerfile: Dockerfile.test
+    volumes:
+    - .:/src
+  git:
+    build: https://github.com/docker/app.git#main
+`,
+			expectedConfig: `services:
+  app:
+    build:
+      context: ..
+      dockerfile: Dockerfile.test
+    volumes:
+    - ..:/src
+  git:
+    build: https://github.com/docker/app.git#main
+`,
+		},
+		{
+			base:      "https://git.example.local/user/repo/raw/branch/master",
+			directory: "/project",
+			config: `services:
+  app:
+    build: ./app
+`,
+			expectedError: dockerCompose.ErrRemoteBase,
+		},
+	}
+
+	for i, testCase := range testCases {
+		config := dockerCompose.NewConfig()
+		require.NoError(yaml.Unmarshal([]byte(testCase.config), config), "TestCase %v", i)
+		config.SetBase(testCase.base)
+
+		err := config.RebasePaths(testCase.directory)
+		if testCase.expectedError != nil {
+			require.ErrorIs(err, testCase.expectedError, "TestCase %v", i)
+			continue
+		}
+		require.NoError(err, "TestCase %v", i)
+		require.Equal(testCase.directory, config.Base(), "TestCase %v", i)
+
+		expectedConfig := dockerCompose.NewConfig()
+		require.NoError(yaml.Unmarshal([]byte(testCase.expectedConfig), expectedConfig), "TestCase %v", i)
+		require.True(expectedConfig.Equal(config), "TestCase %v", i)
+	}
+}
@@ -0,0 +1,208 @@
+package dockerCompose
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReferenceKind is the kind of the top-level attribute, which is referenced by a service.
+type ReferenceKind string
+
+const (
+	ReferenceKindConfig  ReferenceKind = "config"
+	ReferenceKindNetwork ReferenceKind = "network"
+	ReferenceKindSecret  ReferenceKind = "secret"
+	ReferenceKindService ReferenceKind = "service"
+	ReferenceKindVolume  ReferenceKind = "volume"
+)
+
+const (
+	// defaultNetwork is the network, which is created for each project without declaration.
+	defaultNetwork string = "default"
+
+	// networkModeServicePrefix is the prefix of a network mode, which shares the network stack of another service.
+	networkModeServicePrefix string = "service:"
+)
+
+// DanglingReference is a reference of a service to a config, network, secret, service or volume, which is not
+// declared.
+type DanglingReference struct {
+	Path string        `json:"path"`
+	Kind ReferenceKind `json:"kind"`
+	Name string        `json:"name"`
+}
+
+// DanglingReferences is a list of references to undeclared attributes.
+type DanglingReferences []*DanglingReference
+
+// Clone returns a deep copy of the dangling references.
+func (dr DanglingReferences) Clone() DanglingReferences {
+	return DanglingReferences(CloneSlice([]*DanglingReference(dr)))
+}
+
+// Clone returns a copy of the dangling reference.
+func (dr *DanglingReference) Clone() *DanglingReference {
+	return clonePointer(dr)
+}
+
+// String returns a readable report of all dangling references.
+func (dr DanglingReferences) String() string {
+	sb := new(strings.Builder)
+	for _, danglingReference := range dr {
+		fmt.Fprintf(sb, "%s: %s %s is not declared\n", danglingReference.Path, danglingReference.Kind, danglingReference.Name)
+	}
+	return sb.String()
+}
+
+// DanglingReferences returns all references of the services to configs, networks, secrets, services and volumes,
+// which are not declared. Volumes are only checked for named volumes, bind mounts and variable expressions are
+// skipped. The implicit network default is always declared.
+func (c *Config) DanglingReferences() DanglingReferences {
+	danglingReferences := make(DanglingReferences, 0)
+	if c == nil {
+		return danglingReferences
+	}
+
+	serviceNames := make([]string, 0, len(c.Services))
+	for serviceName := range c.Services {
+		serviceNames = append(serviceNames, serviceName)
+	}
+	sort.Strings(serviceNames)
+
+	for _, serviceName := range serviceNames {
+		service := c.Services[serviceName]
+		if service == nil {
+			continue
+		}
+
+		addDanglingReference := func(kind ReferenceKind, name string, attributePath ...string) {
+			danglingReferences = append(danglingReferences, &DanglingReference{
+				Path: strings.Join(append([]string{"services", serviceName}, attributePath...), pathSeparator),
+				Kind: kind,
+				Name: name,
+			})
+		}
+
+		if service.DependsOnContainer != nil {
+			for _, dependency := range sortedKeys(service.DependsOnContainer.DependsOn) {
+				if !c.ExistsService(dependency) {
+					addDanglingReference(ReferenceKindService, dependency, "depends_on", dependency)
+				}
+			}
+		}
+
+		if dependency, ok := strings.CutPrefix(service.NetworkMode, networkModeServicePrefix); ok && !c.ExistsService(dependency) {
+			addDanglingReference(ReferenceKindService, dependency, "network_mode")
+		}
+
+		for _, network := range sortedKeys(service.Networks) {
+			if network != defaultNetwork && !c.ExistsNetwork(network) {
+				addDanglingReference(ReferenceKindNetwork, network, "networks", network)
+			}
+		}
+
+		for _, volume := range service.Volumes {
+			if name, ok := namedVolume(volume); ok && !c.ExistsVolume(name) {
+				addDanglingReference(ReferenceKindVolume, name, "volumes", name)
+			}
+		}
+
+		for _, secret := range service.Secrets {
+			if !c.ExistsSecret(secret) {
+				addDanglingReference(ReferenceKindSecret, secret, "secrets", secret)
+			}
+		}
+
+		for _, config := range service.Configs {
+			if !c.ExistsConfig(config) {
+				addDanglingReference(ReferenceKindConfig, config, "configs", config)
+			}
+		}
+	}
+
+	return danglingReferences
+}
+
+// FixDanglingReferences fixes all dangling references and returns them. Missing configs, networks, secrets and volumes
+// are declared, configs and secrets as external, because their source is unknown. References to missing services,
+// for example of services excluded by the merge, are removed.
+func (c *Config) FixDanglingReferences() DanglingReferences {
+	danglingReferences := c.DanglingReferences()
+	for _, danglingReference := range danglingReferences {
+		switch danglingReference.Kind {
+		case ReferenceKindConfig:
+			if c.Configs == nil {
+				c.Configs = make(map[string]*ConfigObject)
+			}
+			c.Configs[danglingReference.Name] = &ConfigObject{External: true}
+		case ReferenceKindNetwork:
+			if c.Networks == nil {
+				c.Networks = make(map[string]*Network)
+			}
+			c.Networks[danglingReference.Name] = &Network{}
+		case ReferenceKindSecret:
+			if c.Secrets == nil {
+				c.Secrets = make(map[string]*Secret)
+			}
+			c.Secrets[danglingReference.Name] = &Secret{External: true}
+		case ReferenceKindService:
+			for _, service := range c.Services {
+				service.removeServiceReference(danglingReference.Name)
+			}
+		case ReferenceKindVolume:
+			if c.Volumes == nil {
+				c.Volumes = make(map[string]*Volume)
+			}
+			c.Volumes[danglingReference.Name] = &Volume{}
+		}
+	}
+
+	return danglingReferences
+}
+
+// removeServiceReference removes the dependency to the passed service and the network mode, which shares its network
+// stack.
+func (s *Service) removeServiceReference(name string) {
+	if s == nil {
+		return
+	}
+
+	if s.DependsOnContainer != nil {
+		delete(s.DependsOnContainer.DependsOn, name)
+		if len(s.DependsOnContainer.DependsOn) <= 0 {
+			s.DependsOnContainer = nil
+		}
+	}
+
+	if s.NetworkMode == networkModeServicePrefix+name {
+		s.NetworkMode = ""
+	}
+}
+
+// namedVolume returns the name of the volume, when the volume of a service refers to a named volume. Bind mounts,
+// anonymous volumes and variable expressions are not named volumes.
+func namedVolume(volume string) (string, bool) {
+	parts := splitOutsideExpressions(volume, volumeDelimiter)
+	source := parts[0]
+	switch {
+	case len(parts) < 2 || len(source) <= 0:
+		return "", false
+	case strings.ContainsAny(source[:1], "./~$"):
+		return "", false
+	case strings.Contains(source, "/") || strings.Contains(source, "\\"):
+		return "", false
+	default:
+		return source, true
+	}
+}
+
+// sortedKeys returns the keys of the mapping in ascending order.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
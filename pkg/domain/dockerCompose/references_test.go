@@ -0,0 +1,126 @@
+package dockerCompose_test
+
+import (
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig_DanglingReferences(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		s                          string
+		expectedDanglingReferences dockerCompose.DanglingReferences
+		expectedFixedConfig        string
+	}{
+		{
+			s: `services:
+  app:
+    configs:
+    - app
+    depends_on:
+    - db
+    image: app
+    networks:
+      backend: {}
+      default: {}
+    secrets:
+    - token
+    volumes:
+    - data:/var/lib/app
+    - ./config:/etc/app:ro
+    - ${DATA_DIR:-/srv}:/srv
+  db:
+    image: postgres:16
+configs:
+  app:
+    file: ./app.yml
+networks:
+  backend: {}
+secrets:
+  token:
+    file: ./token
+volumes:
+  data: {}
+`,
+			expectedDanglingReferences: dockerCompose.DanglingReferences{},
+		},
+		{
+			s: `services:
+  app:
+    configs:
+    - app
+    depends_on:
+      cache:
+        condition: service_healthy
+      db:
+        condition: service_started
+    image: app
+    network_mode: service:proxy
+    networks:
+      backend: {}
+    secrets:
+    - token
+    volumes:
+    - data:/var/lib/app
+  db:
+    image: postgres:16
+`,
+			expectedDanglingReferences: dockerCompose.DanglingReferences{
+				{Path: "services.app.depends_on.cache", Kind: dockerCompose.ReferenceKindService, Name: "cache"},
+				{Path: "services.app.network_mode", Kind: dockerCompose.ReferenceKindService, Name: "proxy"},
+				{Path: "services.app.networks.backend", Kind: dockerCompose.ReferenceKindNetwork, Name: "backend"},
+				{Path: "services.app.volumes.data", Kind: dockerCompose.ReferenceKindVolume, Name: "data"},
+				{Path: "services.app.secrets.token", Kind: dockerCompose.ReferenceKindSecret, Name: "token"},
+				{Path: "services.app.configs.app", Kind: dockerCompose.ReferenceKindConfig, Name: "app"},
+			},
+			expectedFixedConfig: `services:
+  app:
+    configs:
+    - app
+    depends_on:
+      db:
+        condition: service_started
+    image: app
+    networks:
+      backend: {}
+    secrets:
+    - token
+    volumes:
+    - data:/var/lib/app
+  db:
+    image: postgres:16
+configs:
+  app:
+    external: true
+networks:
+  backend: {}
+secrets:
+  token:
+    external: true
+volumes:
+  data: {}
+`,
+		},
+	}
+
+	for i, testCase := range testCases {
+		config := dockerCompose.NewConfig()
+		require.NoError(yaml.Unmarshal([]byte(testCase.s), config), "TestCase %v", i)
+
+		require.Equal(testCase.expectedDanglingReferences, config.DanglingReferences(), "TestCase %v", i)
+		require.Equal(testCase.expectedDanglingReferences, config.FixDanglingReferences(), "TestCase %v", i)
+		require.Empty(config.DanglingReferences(), "TestCase %v", i)
+
+		if len(testCase.expectedFixedConfig) <= 0 {
+			continue
+		}
+
+		expectedConfig := dockerCompose.NewConfig()
+		require.NoError(yaml.Unmarshal([]byte(testCase.expectedFixedConfig), expectedConfig), "TestCase %v", i)
+		require.True(expectedConfig.Equal(config), "TestCase %v", i)
+	}
+}
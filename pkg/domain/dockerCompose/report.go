@@ -0,0 +1,413 @@
+package dockerCompose
+
+import (
+	"reflect"
+	"strings"
+)
+
+// MergeStatus describes, how an object or attribute of a source was merged.
+type MergeStatus string
+
+const (
+	// MergeStatusAdded marks an object or attribute, which was not declared before.
+	MergeStatusAdded MergeStatus = "added"
+
+	// MergeStatusOverridden marks an object or attribute, which replaced a different value declared before. An object is
+	// overridden, when at least one of its attributes was added or overridden.
+	MergeStatusOverridden MergeStatus = "overridden"
+
+	// MergeStatusSkipped marks an object or attribute, which was ignored, because a different value was declared before.
+	MergeStatusSkipped MergeStatus = "skipped"
+
+	// MergeStatusUnchanged marks an object or attribute, which is equal to the value declared before.
+	MergeStatusUnchanged MergeStatus = "unchanged"
+)
+
+// reportedObjects contains the names of the top-level mappings, whose objects are reported individually.
+var reportedObjects = []string{"networks", "secrets", "services", "volumes"}
+
+// Report describes the changes of each merged source in the order of the merge.
+type Report struct {
+	Sources []*SourceReport `json:"sources"`
+}
+
+// SourceReport describes the changes of a single source. The attributes are identified by the same paths like the
+// provenance.
+type SourceReport struct {
+	Source     string                 `json:"source"`
+	Networks   map[string]MergeStatus `json:"networks,omitempty"`
+	Secrets    map[string]MergeStatus `json:"secrets,omitempty"`
+	Services   map[string]MergeStatus `json:"services,omitempty"`
+	Volumes    map[string]MergeStatus `json:"volumes,omitempty"`
+	Attributes map[string]MergeStatus `json:"attributes,omitempty"`
+}
+
+// Clone returns a deep copy of the report.
+func (r *Report) Clone() *Report {
+	if r == nil {
+		return nil
+	}
+
+	return &Report{
+		Sources: CloneSlice(r.Sources),
+	}
+}
+
+// Clone returns a deep copy of the source report.
+func (sr *SourceReport) Clone() *SourceReport {
+	if sr == nil {
+		return nil
+	}
+
+	return &SourceReport{
+		Source:     sr.Source,
+		Networks:   cloneStatusMap(sr.Networks),
+		Secrets:    cloneStatusMap(sr.Secrets),
+		Services:   cloneStatusMap(sr.Services),
+		Volumes:    cloneStatusMap(sr.Volumes),
+		Attributes: cloneStatusMap(sr.Attributes),
+	}
+}
+
+func cloneStatusMap(m map[string]MergeStatus) map[string]MergeStatus {
+	if m == nil {
+		return nil
+	}
+
+	clonedMap := make(map[string]MergeStatus, len(m))
+	for key, status := range m {
+		clonedMap[key] = status
+	}
+	return clonedMap
+}
+
+// mergeDecision is the decision of the merge engine about an attribute of a merged config.
+type mergeDecision int
+
+const (
+	// mergeDecisionApplied replaces the existing attribute by the attribute of the merged config.
+	mergeDecisionApplied mergeDecision = iota
+
+	// mergeDecisionSkipped keeps the existing attribute and ignores the attribute of the merged config.
+	mergeDecisionSkipped
+)
+
+// mergeTracker records the decisions of the merge engine, while a document is merged into another document. The
+// origins of the applied values are recorded in the provenance of the document merged into, the status of each
+// attribute of the merged document in the report of the merged document. The values of locked attributes declared by
+// the merged document are checked against the locked values.
+type mergeTracker struct {
+	target *Document
+	source *Document
+
+	// origins enables the recording of the origins of the applied values.
+	origins bool
+
+	// locks checks the values of the locked attributes. It's nil, when no attribute is locked.
+	locks *lockCheck
+
+	// attributes contains the status of each attribute of the merged document by its path. It's nil, when no report is
+	// recorded.
+	attributes map[string]MergeStatus
+
+	// addedObjects contains the paths of the reported objects, which were not declared before, for example services.db.
+	addedObjects map[string]struct{}
+}
+
+// record records the decision about the attribute of the passed path. Before is the attribute before the decision was
+// applied, an invalid value marks an attribute, which was not declared before. Src is the attribute of the merged
+// config.
+func (mt *mergeTracker) record(path []string, before reflect.Value, src reflect.Value, decision mergeDecision) {
+	if !mt.observes(path) {
+		return
+	}
+
+	beforeValues := leafValuesAt(before, path)
+	srcValues := leafValuesAt(src, path)
+
+	for leafPath, value := range srcValues {
+		mt.checkLock(leafPath, value)
+
+		previousValue, existed := beforeValues[leafPath]
+		switch {
+		case existed && previousValue == value:
+			mt.recordStatus(leafPath, MergeStatusUnchanged)
+		case decision == mergeDecisionSkipped:
+			mt.recordStatus(leafPath, MergeStatusSkipped)
+		case existed:
+			mt.recordStatus(leafPath, MergeStatusOverridden)
+			mt.recordOrigin(leafPath, value, previousValue, existed, mt.source)
+		default:
+			mt.recordStatus(leafPath, MergeStatusAdded)
+			mt.recordOrigin(leafPath, value, previousValue, existed, mt.source)
+		}
+	}
+
+	if decision == mergeDecisionSkipped {
+		return
+	}
+
+	for leafPath := range beforeValues {
+		if _, present := srcValues[leafPath]; !present {
+			mt.removeValue(leafPath)
+		}
+	}
+
+	if mt.attributes != nil && !before.IsValid() && src.IsValid() && isReportedObject(path) {
+		mt.addedObjects[strings.Join(path, pathSeparator)] = struct{}{}
+	}
+}
+
+// recordMerged records the merge of an attribute, which was partly applied, for example a nested mapping of an
+// extension field. The status of each value is determined by comparing the leaf values before and after the merge.
+func (mt *mergeTracker) recordMerged(path []string, beforeValues map[string]string, src reflect.Value, after reflect.Value) {
+	if !mt.observes(path) {
+		return
+	}
+
+	afterValues := leafValuesAt(after, path)
+
+	declaredObjects := make(map[string]struct{})
+	for leafPath := range beforeValues {
+		if objectPath, ok := objectPathOf(leafPath); ok {
+			declaredObjects[objectPath] = struct{}{}
+		}
+	}
+
+	for leafPath, value := range leafValuesAt(src, path) {
+		mt.checkLock(leafPath, value)
+
+		previousValue, existed := beforeValues[leafPath]
+		currentValue, exists := afterValues[leafPath]
+		switch {
+		case existed && previousValue == value:
+			mt.recordStatus(leafPath, MergeStatusUnchanged)
+		case !exists || currentValue != value:
+			mt.recordStatus(leafPath, MergeStatusSkipped)
+		case existed:
+			mt.recordStatus(leafPath, MergeStatusOverridden)
+		default:
+			mt.recordStatus(leafPath, MergeStatusAdded)
+
+			objectPath, ok := objectPathOf(leafPath)
+			if _, declared := declaredObjects[objectPath]; ok && !declared && mt.attributes != nil {
+				mt.addedObjects[objectPath] = struct{}{}
+			}
+		}
+	}
+
+	for leafPath, value := range afterValues {
+		previousValue, existed := beforeValues[leafPath]
+		if !existed || previousValue != value {
+			mt.recordOrigin(leafPath, value, previousValue, existed, mt.source)
+		}
+	}
+
+	for leafPath := range beforeValues {
+		if _, present := afterValues[leafPath]; !present {
+			mt.removeValue(leafPath)
+		}
+	}
+}
+
+// recordItem records the decision about an item of a list of the passed path. Matches are the existing items, which
+// are identified like the item, for example the environment variables of the same name.
+func (mt *mergeTracker) recordItem(path []string, item string, matches []string, applied bool) {
+	if !mt.observes(path) {
+		return
+	}
+
+	leafPath := itemPath(path, item)
+	mt.checkLock(leafPath, item)
+
+	var previousValue string
+	existed := false
+	for _, match := range matches {
+		if itemPath(path, match) == leafPath {
+			previousValue, existed = match, true
+			break
+		}
+	}
+
+	switch {
+	case existsInSlice(matches, item):
+		mt.recordStatus(leafPath, MergeStatusUnchanged)
+		return
+	case !applied:
+		mt.recordStatus(leafPath, MergeStatusSkipped)
+		return
+	case len(matches) > 0:
+		mt.recordStatus(leafPath, MergeStatusOverridden)
+	default:
+		mt.recordStatus(leafPath, MergeStatusAdded)
+	}
+
+	mt.recordOrigin(leafPath, item, previousValue, existed, mt.source)
+
+	// Replaced items, which are identified by another path, for example a port published on a specific host IP, are
+	// gone.
+	for _, match := range matches {
+		if matchPath := itemPath(path, match); matchPath != leafPath {
+			mt.removeValue(matchPath)
+		}
+	}
+}
+
+// removeItem records the removal of an item of a list of the passed path.
+func (mt *mergeTracker) removeItem(path []string, item string) {
+	if mt.observes(path) {
+		mt.removeValue(itemPath(path, item))
+	}
+}
+
+// observes returns true if the decisions about the attribute of the passed path are recorded. Without report and
+// provenance, only the decisions about locked attributes are recorded.
+func (mt *mergeTracker) observes(path []string) bool {
+	return mt.origins || mt.attributes != nil || (mt.locks != nil && mt.locks.relates(path))
+}
+
+func (mt *mergeTracker) recordOrigin(path string, value string, previousValue string, existed bool, source *Document) {
+	if mt.origins {
+		mt.target.recordOrigin(path, value, previousValue, existed, source)
+	}
+}
+
+// removeValue records the removal of the value of the passed path.
+func (mt *mergeTracker) removeValue(path string) {
+	mt.checkLock(path, "")
+	if mt.origins {
+		mt.target.removeOrigin(path)
+	}
+}
+
+func (mt *mergeTracker) checkLock(path string, value string) {
+	if mt.locks != nil {
+		mt.locks.check(path, value)
+	}
+}
+
+// lockViolations returns the values of the merged document, which differ from the locked values.
+func (mt *mergeTracker) lockViolations() LockViolations {
+	if mt.locks == nil {
+		return make(LockViolations, 0)
+	}
+	return mt.locks.lockViolations(mt.source.source)
+}
+
+func (mt *mergeTracker) recordStatus(path string, status MergeStatus) {
+	if mt.attributes != nil {
+		mt.attributes[path] = status
+	}
+}
+
+// sourceReport returns the report of the merged document. The status of an existing object is derived from the status
+// of its attributes.
+func (mt *mergeTracker) sourceReport() *SourceReport {
+	objectStatuses := make(map[string]MergeStatus)
+	for path, status := range mt.attributes {
+		objectPath, ok := objectPathOf(path)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case status == MergeStatusAdded || status == MergeStatusOverridden:
+			objectStatuses[objectPath] = MergeStatusOverridden
+		case status == MergeStatusSkipped && objectStatuses[objectPath] != MergeStatusOverridden:
+			objectStatuses[objectPath] = MergeStatusSkipped
+		}
+	}
+
+	sourceReport := &SourceReport{
+		Source:     mt.source.source,
+		Attributes: mt.attributes,
+	}
+
+	for _, kind := range reportedObjects {
+		objects := make(map[string]MergeStatus)
+
+		mapping, ok := childByName(reflect.ValueOf(mt.source.Config), kind)
+		if ok && mapping.Kind() == reflect.Map {
+			for _, key := range mapping.MapKeys() {
+				objectPath := kind + pathSeparator + key.String()
+
+				_, added := mt.addedObjects[objectPath]
+				status, present := objectStatuses[objectPath]
+				switch {
+				case added:
+					status = MergeStatusAdded
+				case !present:
+					status = MergeStatusUnchanged
+				}
+				objects[key.String()] = status
+			}
+		}
+
+		switch kind {
+		case "networks":
+			sourceReport.Networks = objects
+		case "secrets":
+			sourceReport.Secrets = objects
+		case "services":
+			sourceReport.Services = objects
+		case "volumes":
+			sourceReport.Volumes = objects
+		}
+	}
+
+	return sourceReport
+}
+
+// objectPathOf returns the path of the reported object, which contains the attribute of the passed path, for example
+// services.app of services.app.image.
+func objectPathOf(path string) (string, bool) {
+	segments := strings.SplitN(path, pathSeparator, 3)
+	if len(segments) < 3 || !isReportedObject(segments[:2]) {
+		return "", false
+	}
+	return segments[0] + pathSeparator + segments[1], true
+}
+
+// isReportedObject returns true if the path is the path of an object, which is reported individually.
+func isReportedObject(path []string) bool {
+	if len(path) != 2 {
+		return false
+	}
+
+	for _, kind := range reportedObjects {
+		if path[0] == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// newMergeTracker returns a tracker of the merge of the source into the target, which checks the locks of the target.
+// The origins of the applied values and the report are only recorded, when the changes are tracked. Without locks and
+// tracking, nil is returned.
+func newMergeTracker(target *Document, source *Document, trackChanges bool) *mergeTracker {
+	if !trackChanges && len(target.locks) <= 0 {
+		return nil
+	}
+
+	mergeTracker := &mergeTracker{
+		target:  target,
+		source:  source,
+		origins: trackChanges,
+	}
+
+	if trackChanges {
+		mergeTracker.attributes = make(map[string]MergeStatus)
+		mergeTracker.addedObjects = make(map[string]struct{})
+
+		// The origins must be determined before the config of the target is modified by the merge.
+		target.Provenance()
+		source.Provenance()
+	}
+
+	if len(target.locks) > 0 {
+		mergeTracker.locks = newLockCheck(target.Config, target.locks)
+	}
+
+	return mergeTracker
+}
@@ -0,0 +1,181 @@
+package dockerCompose_test
+
+import (
+	"fmt"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMerger_Report(t *testing.T) {
+	require := require.New(t)
+
+	base := `networks:
+  proxy: {}
+services:
+  app:
+    environment:
+    - LOG_LEVEL=info
+    image: example.local/app:1.0.0
+`
+
+	overlay := `networks:
+  proxy: {}
+services:
+  app:
+    environment:
+    - LOG_LEVEL=debug
+    - TZ=UTC
+    image: example.local/app:1.0.0
+  db:
+    image: example.local/db:1.0.0
+volumes:
+  data: {}
+`
+
+	testCases := []struct {
+		strategy       dockerCompose.Strategy
+		expectedReport *dockerCompose.SourceReport
+	}{
+		{
+			strategy: dockerCompose.StrategyDefault,
+			expectedReport: &dockerCompose.SourceReport{
+				Source:   "source-1",
+				Networks: map[string]dockerCompose.MergeStatus{"proxy": dockerCompose.MergeStatusUnchanged},
+				Secrets:  map[string]dockerCompose.MergeStatus{},
+				Services: map[string]dockerCompose.MergeStatus{
+					"app": dockerCompose.MergeStatusSkipped,
+					"db":  dockerCompose.MergeStatusAdded,
+				},
+				Volumes: map[string]dockerCompose.MergeStatus{"data": dockerCompose.MergeStatusAdded},
+				Attributes: map[string]dockerCompose.MergeStatus{
+					"services.app.environment.LOG_LEVEL": dockerCompose.MergeStatusSkipped,
+					"services.app.environment.TZ":        dockerCompose.MergeStatusSkipped,
+					"services.app.image":                 dockerCompose.MergeStatusUnchanged,
+					"services.db.image":                  dockerCompose.MergeStatusAdded,
+				},
+			},
+		},
+		{
+			strategy: dockerCompose.StrategyExistingWin,
+			expectedReport: &dockerCompose.SourceReport{
+				Source:   "source-1",
+				Networks: map[string]dockerCompose.MergeStatus{"proxy": dockerCompose.MergeStatusUnchanged},
+				Secrets:  map[string]dockerCompose.MergeStatus{},
+				Services: map[string]dockerCompose.MergeStatus{
+					"app": dockerCompose.MergeStatusOverridden,
+					"db":  dockerCompose.MergeStatusAdded,
+				},
+				Volumes: map[string]dockerCompose.MergeStatus{"data": dockerCompose.MergeStatusAdded},
+				Attributes: map[string]dockerCompose.MergeStatus{
+					"services.app.environment.LOG_LEVEL": dockerCompose.MergeStatusSkipped,
+					"services.app.environment.TZ":        dockerCompose.MergeStatusAdded,
+					"services.app.image":                 dockerCompose.MergeStatusUnchanged,
+					"services.db.image":                  dockerCompose.MergeStatusAdded,
+				},
+			},
+		},
+		{
+			strategy: dockerCompose.StrategyLastWin,
+			expectedReport: &dockerCompose.SourceReport{
+				Source:   "source-1",
+				Networks: map[string]dockerCompose.MergeStatus{"proxy": dockerCompose.MergeStatusUnchanged},
+				Secrets:  map[string]dockerCompose.MergeStatus{},
+				Services: map[string]dockerCompose.MergeStatus{
+					"app": dockerCompose.MergeStatusOverridden,
+					"db":  dockerCompose.MergeStatusAdded,
+				},
+				Volumes: map[string]dockerCompose.MergeStatus{"data": dockerCompose.MergeStatusAdded},
+				Attributes: map[string]dockerCompose.MergeStatus{
+					"services.app.environment.LOG_LEVEL": dockerCompose.MergeStatusOverridden,
+					"services.app.environment.TZ":        dockerCompose.MergeStatusAdded,
+					"services.app.image":                 dockerCompose.MergeStatusUnchanged,
+					"services.db.image":                  dockerCompose.MergeStatusAdded,
+				},
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		merger := dockerCompose.NewMerger()
+		merger.TrackChanges()
+		for j, source := range []string{base, overlay} {
+			document := dockerCompose.NewDocument(dockerCompose.NewConfig())
+			require.NoError(yaml.Unmarshal([]byte(source), document), "TestCase %v", i)
+			document.SetSource(fmt.Sprintf("source-%v", j))
+			require.NoError(merger.Merge(document, testCase.strategy), "TestCase %v", i)
+		}
+
+		report := merger.Result().Report
+		require.Len(report.Sources, 2, "TestCase %v", i)
+		require.Equal("source-0", report.Sources[0].Source, "TestCase %v", i)
+		require.Equal(testCase.expectedReport, report.Sources[1], "TestCase %v", i)
+	}
+}
+
+func TestMerger_ReportDecisions(t *testing.T) {
+	require := require.New(t)
+
+	base := `services:
+  app:
+    command: ["run", "--verbose"]
+    environment:
+    - LOG_LEVEL=info
+    image: example.local/app:1.0.0
+x-settings:
+  owner: team-a
+  tier: backend
+`
+
+	overlay := `services:
+  app:
+    command: !reset []
+    environment:
+    - LOG_LEVEL=info
+    image: example.local/app:2.0.0
+x-settings:
+  owner: team-b
+  region: eu
+`
+
+	documents := make([]*dockerCompose.Document, 0)
+	for i, source := range []string{base, overlay} {
+		document := dockerCompose.NewDocument(dockerCompose.NewConfig())
+		require.NoError(yaml.Unmarshal([]byte(source), document))
+		document.SetSource(fmt.Sprintf("source-%v", i))
+		documents = append(documents, document)
+	}
+
+	merger := dockerCompose.NewMerger()
+	for _, document := range documents {
+		require.NoError(merger.Merge(document.Clone(), dockerCompose.StrategyExistingWin))
+	}
+
+	result := merger.Result()
+	require.Empty(result.Report.Sources)
+	require.Nil(result.Document.Provenance())
+
+	merger = dockerCompose.NewMerger()
+	merger.TrackChanges()
+	for _, document := range documents {
+		require.NoError(merger.Merge(document.Clone(), dockerCompose.StrategyExistingWin))
+	}
+
+	result = merger.Result()
+	require.Len(result.Report.Sources, 2)
+	require.Equal(map[string]dockerCompose.MergeStatus{
+		"services.app.environment.LOG_LEVEL": dockerCompose.MergeStatusUnchanged,
+		"services.app.image":                 dockerCompose.MergeStatusSkipped,
+		"x-settings.owner":                   dockerCompose.MergeStatusSkipped,
+		"x-settings.region":                  dockerCompose.MergeStatusAdded,
+	}, result.Report.Sources[1].Attributes)
+	require.Equal(map[string]dockerCompose.MergeStatus{"app": dockerCompose.MergeStatusSkipped}, result.Report.Sources[1].Services)
+
+	provenance := result.Document.Provenance()
+	require.NotContains(provenance, "services.app.command")
+	require.Equal("source-0", provenance["services.app.image"].Source)
+	require.Equal("source-1", provenance["x-settings.region"].Source)
+	require.Equal("source-0", provenance["x-settings.tier"].Source)
+}
@@ -0,0 +1,307 @@
+package dockerCompose
+
+import (
+	// Embed the JSON schema of the compose specification
+	_ "embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// composeSchemaURL is the URL, by which the JSON schema of the compose specification is compiled.
+const composeSchemaURL string = "compose-spec.json"
+
+// composeSchema is the JSON schema of the compose specification. The schema is a copy of the schema of compose-go
+// v2.1.3, licensed under the Apache License 2.0 by The Compose Specification Authors.
+//
+//go:embed schema/compose-spec.json
+var composeSchema string
+
+var (
+	compileSchemaOnce sync.Once
+	compiledSchema    *jsonschema.Schema
+	compileSchemaErr  error
+)
+
+// regExpQuotedNames matches the quoted names of the message of a failed additionalProperties keyword.
+var regExpQuotedNames = regexp.MustCompile(`'((?:[^'\\]|\\.)*)'`)
+
+// regExpTypeMismatch matches the message of a failed type keyword.
+var regExpTypeMismatch = regexp.MustCompile(`^expected (.+), but got (.+)$`)
+
+// jsonPointerUnescaper unescapes a token of a JSON pointer defined by RFC 6901.
+var jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// SchemaError is an attribute of a docker-compose file, which violates the JSON schema of the compose specification.
+type SchemaError struct {
+	Source  string `json:"source"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// SchemaErrors is a list of attributes, which violate the JSON schema of the compose specification.
+type SchemaErrors []*SchemaError
+
+// Clone returns a deep copy of the schema errors.
+func (se SchemaErrors) Clone() SchemaErrors {
+	return SchemaErrors(CloneSlice([]*SchemaError(se)))
+}
+
+// Clone returns a copy of the schema error.
+func (se *SchemaError) Clone() *SchemaError {
+	return clonePointer(se)
+}
+
+// String returns a readable report of all schema errors.
+func (se SchemaErrors) String() string {
+	sb := new(strings.Builder)
+	for _, schemaError := range se {
+		fmt.Fprintf(sb, "%s:%v:%v: %s: %s\n", schemaError.Source, schemaError.Line, schemaError.Column, schemaError.Path, schemaError.Message)
+	}
+	return sb.String()
+}
+
+// ValidateSchema returns all attributes of the document, which violate the JSON schema of the compose
+// specification. Only the attributes of the docker-compose file, from which the document was decoded, are validated.
+// Documents, which were not decoded from a YAML document, have no schema errors.
+func (d *Document) ValidateSchema() (SchemaErrors, error) {
+	schemaErrors, err := ValidateSchema(d.node)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, schemaError := range schemaErrors {
+		schemaError.Source = d.source
+	}
+	return schemaErrors, nil
+}
+
+// ValidateSchema returns all attributes of the passed node of a docker-compose file, which violate the JSON schema of
+// the compose specification. The errors contain the line and column of the attributes. Unknown attributes like typos
+// are reported by the attribute itself, all other errors by the attribute of the invalid value.
+func ValidateSchema(node *yaml.Node) (SchemaErrors, error) {
+	schemaErrors := make(SchemaErrors, 0)
+	if node == nil || node.Kind == 0 {
+		return schemaErrors, nil
+	}
+
+	compileSchemaOnce.Do(func() {
+		compiler := jsonschema.NewCompiler()
+		compileSchemaErr = compiler.AddResource(composeSchemaURL, strings.NewReader(composeSchema))
+		if compileSchemaErr == nil {
+			compiledSchema, compileSchemaErr = compiler.Compile(composeSchemaURL)
+		}
+	})
+	if compileSchemaErr != nil {
+		return nil, fmt.Errorf("failed to compile the JSON schema of the compose specification: %w", compileSchemaErr)
+	}
+
+	var value interface{}
+	err := node.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+
+	err = compiledSchema.Validate(jsonValue(value))
+	validationError := new(jsonschema.ValidationError)
+	switch {
+	case err == nil:
+		return schemaErrors, nil
+	case !errors.As(err, &validationError):
+		return nil, err
+	}
+
+	known := make(map[string]struct{})
+	typeMismatches := make(map[string]*SchemaError)
+	for _, cause := range leafCauses(validationError) {
+		tokens := jsonPointerTokens(cause.InstanceLocation)
+
+		// The schema reports unknown attributes by their parent, they are reported by themselves instead.
+		names := []string{""}
+		message := cause.Message
+		if strings.HasSuffix(cause.KeywordLocation, "additionalProperties") && strings.HasPrefix(message, "additionalProperties") {
+			names = names[:0]
+			for _, matches := range regExpQuotedNames.FindAllStringSubmatch(message, -1) {
+				names = append(names, matches[1])
+			}
+			message = "additional property not allowed"
+		}
+
+		for _, name := range names {
+			path := tokens
+			if len(name) > 0 {
+				path = appendPath(tokens, name)
+			}
+
+			schemaError := &SchemaError{
+				Path:    strings.Join(path, pathSeparator),
+				Message: message,
+			}
+			if positionNode := nodeOfPath(node, path); positionNode != nil {
+				schemaError.Line, schemaError.Column = positionNode.Line, positionNode.Column
+			}
+
+			key := fmt.Sprintf("%v:%v:%s:%s", schemaError.Line, schemaError.Column, schemaError.Path, schemaError.Message)
+			if _, present := known[key]; present {
+				continue
+			}
+			known[key] = struct{}{}
+
+			// Alternatives of a oneOf keyword expect different types for the same attribute, which are reported as one
+			// error.
+			if matches := regExpTypeMismatch.FindStringSubmatch(message); matches != nil {
+				if typeMismatch, present := typeMismatches[schemaError.Path]; present {
+					typeMismatchMatches := regExpTypeMismatch.FindStringSubmatch(typeMismatch.Message)
+					if typeMismatchMatches[2] == matches[2] {
+						typeMismatch.Message = fmt.Sprintf("expected %s or %s, but got %s", typeMismatchMatches[1], matches[1], matches[2])
+						continue
+					}
+				}
+				typeMismatches[schemaError.Path] = schemaError
+			}
+
+			schemaErrors = append(schemaErrors, schemaError)
+		}
+	}
+
+	sort.SliceStable(schemaErrors, func(i, j int) bool {
+		if schemaErrors[i].Line != schemaErrors[j].Line {
+			return schemaErrors[i].Line < schemaErrors[j].Line
+		}
+		return schemaErrors[i].Column < schemaErrors[j].Column
+	})
+
+	return schemaErrors, nil
+}
+
+// leafCauses returns the validation errors without further causes, which are the most specific errors.
+func leafCauses(validationError *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(validationError.Causes) <= 0 {
+		return []*jsonschema.ValidationError{validationError}
+	}
+
+	causes := make([]*jsonschema.ValidationError, 0)
+	for _, cause := range validationError.Causes {
+		causes = append(causes, leafCauses(cause)...)
+	}
+	return causes
+}
+
+// jsonValue returns the decoded YAML value as JSON value. Mappings with keys, which are not strings, and timestamps
+// are converted into their string representation.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, entry := range v {
+			v[key] = jsonValue(entry)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, entry := range v {
+			m[fmt.Sprint(key)] = jsonValue(entry)
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = jsonValue(v[i])
+		}
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
+
+// jsonPointerTokens returns the unescaped tokens of the JSON pointer defined by RFC 6901.
+func jsonPointerTokens(pointer string) []string {
+	tokens := make([]string, 0)
+	if len(pointer) <= 0 {
+		return tokens
+	}
+
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		tokens = append(tokens, jsonPointerUnescaper.Replace(token))
+	}
+	return tokens
+}
+
+// nodeOfPath returns the node, which declares the attribute of the path. Entries of a mapping are declared by their
+// key, items of a sequence by themselves. Entries merged by a merge key are looked up in the merged mappings. When no
+// node is found, nil is returned.
+func nodeOfPath(node *yaml.Node, path []string) *yaml.Node {
+	declaringNode := node
+	for _, name := range path {
+		for node.Kind == yaml.DocumentNode || node.Kind == yaml.AliasNode {
+			switch {
+			case node.Kind == yaml.AliasNode:
+				node = node.Alias
+			case len(node.Content) > 0:
+				node = node.Content[0]
+			default:
+				return nil
+			}
+		}
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			keyNode, valueNode := mappingEntry(node, name)
+			if keyNode == nil {
+				return nil
+			}
+			declaringNode, node = keyNode, valueNode
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(name)
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return nil
+			}
+			declaringNode, node = node.Content[i], node.Content[i]
+		default:
+			return nil
+		}
+	}
+
+	return declaringNode
+}
+
+// mappingEntry returns the key and value node of the entry of the mapping. Entries declared directly take precedence
+// over entries merged by a merge key.
+func mappingEntry(node *yaml.Node, name string) (*yaml.Node, *yaml.Node) {
+	mergedNodes := make([]*yaml.Node, 0)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		switch {
+		case keyNode.Value == mergeKey && valueNode.Kind == yaml.SequenceNode:
+			mergedNodes = append(mergedNodes, valueNode.Content...)
+		case keyNode.Value == mergeKey:
+			mergedNodes = append(mergedNodes, valueNode)
+		case keyNode.Value == name:
+			return keyNode, valueNode
+		}
+	}
+
+	for _, mergedNode := range mergedNodes {
+		if mergedNode.Kind == yaml.AliasNode {
+			mergedNode = mergedNode.Alias
+		}
+
+		if mergedNode != nil && mergedNode.Kind == yaml.MappingNode {
+			if keyNode, valueNode := mappingEntry(mergedNode, name); keyNode != nil {
+				return keyNode, valueNode
+			}
+		}
+	}
+
+	return nil, nil
+}
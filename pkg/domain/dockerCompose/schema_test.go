@@ -0,0 +1,90 @@
+package dockerCompose_test
+
+import (
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDocument_ValidateSchema(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		s                    string
+		expectedSchemaErrors dockerCompose.SchemaErrors
+	}{
+		{
+			s: `services:
+  app:
+    environment:
+    - LOG_LEVEL=debug
+    image: app
+    ports:
+    - 8080:80
+x-defaults:
+  restart: always
+`,
+			expectedSchemaErrors: dockerCompose.SchemaErrors{},
+		},
+		{
+			s: `services:
+  app:
+    environments:
+    - LOG_LEVEL=debug
+    image: app
+  db:
+    image: postgres:16
+    restart: true
+networks:
+  backend:
+    drivr: bridge
+`,
+			expectedSchemaErrors: dockerCompose.SchemaErrors{
+				{Source: "docker-compose.yml", Line: 3, Column: 5, Path: "services.app.environments", Message: "additional property not allowed"},
+				{Source: "docker-compose.yml", Line: 8, Column: 5, Path: "services.db.restart", Message: "expected string, but got boolean"},
+				{Source: "docker-compose.yml", Line: 11, Column: 5, Path: "networks.backend.drivr", Message: "additional property not allowed"},
+			},
+		},
+		{
+			s: `x-defaults: &defaults
+  image: app
+  imag: app
+services:
+  app:
+    <<: *defaults
+`,
+			expectedSchemaErrors: dockerCompose.SchemaErrors{
+				{Source: "docker-compose.yml", Line: 3, Column: 3, Path: "services.app.imag", Message: "additional property not allowed"},
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		document := dockerCompose.NewDocument(dockerCompose.NewConfig())
+		require.NoError(yaml.Unmarshal([]byte(testCase.s), document), "TestCase %v", i)
+		document.SetSource("docker-compose.yml")
+
+		schemaErrors, err := document.ValidateSchema()
+		require.NoError(err, "TestCase %v", i)
+		require.Equal(testCase.expectedSchemaErrors, schemaErrors, "TestCase %v", i)
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	require := require.New(t)
+
+	node := new(yaml.Node)
+	require.NoError(yaml.Unmarshal([]byte(`services:
+  app:
+    environment: 5
+    image: app
+`), node))
+
+	schemaErrors, err := dockerCompose.ValidateSchema(node)
+	require.NoError(err)
+	require.Equal(dockerCompose.SchemaErrors{
+		{Line: 3, Column: 5, Path: "services.app.environment", Message: "expected object or array, but got number"},
+	}, schemaErrors)
+}
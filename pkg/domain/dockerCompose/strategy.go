@@ -0,0 +1,120 @@
+package dockerCompose
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrStrategyAlreadyRegistered error = errors.New("merge strategy already registered")
+	ErrUnknownStrategy           error = errors.New("unknown merge strategy")
+)
+
+// Strategy is the name of a merge strategy.
+type Strategy string
+
+const (
+	// StrategyDefault adds only missing networks, secrets, services and volumes.
+	StrategyDefault Strategy = "default"
+
+	// StrategyExistingWin protects existing attributes.
+	StrategyExistingWin Strategy = "existing-win"
+
+	// StrategyLastWin overwrites existing attributes.
+	StrategyLastWin Strategy = "last-win"
+
+	// StrategyComposeCompatible merges by the merge rules of the compose specification.
+	StrategyComposeCompatible Strategy = "compose-compatible"
+)
+
+// MergeStrategy merges a config into another config. Library users can implement their own strategy and register it
+// by RegisterStrategy to select it by name, for example by the flag --strategy of dcmerge. The !reset and !override
+// directives of the merged config are applied around the strategy by MergeWithStrategy, the strategy merges the config
+// without the reset attributes.
+type MergeStrategy interface {
+	// Merge merges the config src into the config dst.
+	Merge(dst *Config, src *Config)
+}
+
+// MergeStrategyFunc is an adapter to use an ordinary function as merge strategy.
+type MergeStrategyFunc func(dst *Config, src *Config)
+
+// Merge calls f(dst, src).
+func (f MergeStrategyFunc) Merge(dst *Config, src *Config) {
+	f(dst, src)
+}
+
+var (
+	strategiesMutex sync.RWMutex
+	strategies      = map[Strategy]MergeStrategy{
+		StrategyDefault:     MergeStrategyFunc((*Config).Merge),
+		StrategyExistingWin: MergeStrategyFunc((*Config).MergeExistingWin),
+		StrategyLastWin:     MergeStrategyFunc((*Config).MergeLastWin),
+
+		StrategyComposeCompatible: MergeStrategyFunc((*Config).MergeComposeCompatible),
+	}
+)
+
+// RegisterStrategy registers the merge strategy by the passed name. Names can't be registered twice, the builtin
+// strategies default, existing-win, last-win and compose-compatible are always registered.
+func RegisterStrategy(name Strategy, mergeStrategy MergeStrategy) error {
+	strategiesMutex.Lock()
+	defer strategiesMutex.Unlock()
+
+	if _, present := strategies[name]; present {
+		return fmt.Errorf("%w: %s", ErrStrategyAlreadyRegistered, name)
+	}
+
+	strategies[name] = mergeStrategy
+	return nil
+}
+
+// LookupStrategy returns the merge strategy registered by the passed name.
+func LookupStrategy(name Strategy) (MergeStrategy, error) {
+	strategiesMutex.RLock()
+	defer strategiesMutex.RUnlock()
+
+	mergeStrategy, present := strategies[name]
+	if !present {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStrategy, name)
+	}
+
+	return mergeStrategy, nil
+}
+
+// Strategies returns the sorted names of all registered merge strategies.
+func Strategies() []Strategy {
+	strategiesMutex.RLock()
+	defer strategiesMutex.RUnlock()
+
+	names := make([]Strategy, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	return names
+}
+
+// MergeWithStrategy merges the passed config by the merge strategy registered by the passed name. Like by the builtin
+// strategies, attributes tagged by !reset are removed before and attributes tagged by !override are replaced after the
+// merge of a registered strategy.
+func (c *Config) MergeWithStrategy(config *Config, name Strategy) error {
+	mergeStrategy, err := LookupStrategy(name)
+	if err != nil {
+		return err
+	}
+
+	if isBuiltinStrategy(name) || c == nil || config == nil {
+		mergeStrategy.Merge(c, config)
+		return nil
+	}
+
+	mc := &mergeContext{fallback: name}
+	c.applyResets(config, mc)
+	mergeStrategy.Merge(c, config)
+	c.applyOverrides(config, mc)
+	return nil
+}
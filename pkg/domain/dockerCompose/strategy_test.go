@@ -0,0 +1,106 @@
+package dockerCompose_test
+
+import (
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRegisterStrategy(t *testing.T) {
+	require := require.New(t)
+
+	// union adds missing list items and overwrites scalars
+	union := dockerCompose.MergeStrategyFunc(func(dst *dockerCompose.Config, src *dockerCompose.Config) {
+		for name, service := range src.Services {
+			existingService, present := dst.Services[name]
+			if !present {
+				dst.Services[name] = service.Clone()
+				continue
+			}
+
+			for _, environment := range service.Environments {
+				if !existingService.ExistsEnvironment(environment) {
+					existingService.Environments = append(existingService.Environments, environment)
+				}
+			}
+
+			if len(service.Image) > 0 {
+				existingService.Image = service.Image
+			}
+		}
+	})
+
+	require.NoError(dockerCompose.RegisterStrategy("union", union))
+	require.ErrorIs(dockerCompose.RegisterStrategy("union", union), dockerCompose.ErrStrategyAlreadyRegistered)
+	require.ErrorIs(dockerCompose.RegisterStrategy(dockerCompose.StrategyLastWin, union), dockerCompose.ErrStrategyAlreadyRegistered)
+	require.Contains(dockerCompose.Strategies(), dockerCompose.Strategy("union"))
+
+	config := &dockerCompose.Config{
+		Services: map[string]*dockerCompose.Service{
+			"app": {Environments: []string{"A=1"}, Image: "example.local/app:1.0.0"},
+		},
+	}
+
+	err := config.MergeWithStrategy(&dockerCompose.Config{
+		Services: map[string]*dockerCompose.Service{
+			"app": {Environments: []string{"B=2"}, Image: "example.local/app:2.0.0"},
+		},
+	}, "union")
+	require.NoError(err)
+	require.True(config.Equal(&dockerCompose.Config{
+		Services: map[string]*dockerCompose.Service{
+			"app": {Environments: []string{"A=1", "B=2"}, Image: "example.local/app:2.0.0"},
+		},
+	}))
+
+	// The !reset and !override directives are applied around the registered strategy.
+	config.Services["app"].Labels = []string{"team=a"}
+	overlay := dockerCompose.NewConfig()
+	require.NoError(yaml.Unmarshal([]byte(`services:
+  app:
+    environment: !override
+    - C=3
+    labels: !reset []
+`), overlay))
+	require.NoError(config.MergeWithStrategy(overlay, "union"))
+	require.Equal([]string{"C=3"}, config.Services["app"].Environments)
+	require.Empty(config.Services["app"].Labels)
+	require.Equal("example.local/app:2.0.0", config.Services["app"].Image)
+
+	err = config.MergeWithStrategy(dockerCompose.NewConfig(), "first-win")
+	require.ErrorIs(err, dockerCompose.ErrUnknownStrategy)
+
+	policy := dockerCompose.NewPolicy()
+	require.NoError(yaml.Unmarshal([]byte(`services.*.image: last-win`), policy))
+	require.NoError(config.MergeWithPolicy(dockerCompose.NewConfig(), dockerCompose.NewPolicy(), "union"))
+	require.ErrorIs(config.MergeWithPolicy(dockerCompose.NewConfig(), policy, "union"), dockerCompose.ErrUnsupportedStrategy)
+}
+
+func TestLookupStrategy(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		strategy       dockerCompose.Strategy
+		merge          func(c *dockerCompose.Config, config *dockerCompose.Config)
+		expectedConfig *dockerCompose.Config
+	}{
+		{strategy: dockerCompose.StrategyDefault, merge: (*dockerCompose.Config).Merge},
+		{strategy: dockerCompose.StrategyExistingWin, merge: (*dockerCompose.Config).MergeExistingWin},
+		{strategy: dockerCompose.StrategyLastWin, merge: (*dockerCompose.Config).MergeLastWin},
+	}
+
+	for i, testCase := range testCases {
+		mergeStrategy, err := dockerCompose.LookupStrategy(testCase.strategy)
+		require.NoError(err, "TestCase %v", i)
+
+		configA := newBenchmarkConfig(5, 0)
+		configB := newBenchmarkConfig(5, 0)
+		overlay := newBenchmarkConfig(5, 2)
+
+		mergeStrategy.Merge(configA, overlay)
+		testCase.merge(configB, overlay)
+		require.True(configB.Equal(configA), "TestCase %v", i)
+	}
+}
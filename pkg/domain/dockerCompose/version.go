@@ -0,0 +1,265 @@
+package dockerCompose
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrMalformedVersion     error = errors.New("malformed version")
+	ErrUnknownVersionPolicy error = errors.New("unknown version policy")
+	ErrVersionMismatch      error = errors.New("versions disagree")
+)
+
+// VersionPolicy declares, how the versions of multiple docker-compose files are merged. The compose specification
+// marks the version as obsolete.
+type VersionPolicy string
+
+const (
+	// VersionPolicyStrategy merges the version like any other attribute by the merge strategy.
+	VersionPolicyStrategy VersionPolicy = ""
+
+	// VersionPolicyHighest keeps the highest version of all docker-compose files.
+	VersionPolicyHighest VersionPolicy = "highest"
+
+	// VersionPolicyDrop drops the version.
+	VersionPolicyDrop VersionPolicy = "drop"
+
+	// VersionPolicyFail fails when the docker-compose files declare different versions.
+	VersionPolicyFail VersionPolicy = "fail"
+)
+
+// ParseVersionPolicy returns the version policy of the passed name. An error is returned, when the version policy is
+// unknown. The version policy strategy is applied by the merge itself and not supported.
+func ParseVersionPolicy(name string) (VersionPolicy, error) {
+	switch versionPolicy := VersionPolicy(name); versionPolicy {
+	case VersionPolicyHighest, VersionPolicyDrop, VersionPolicyFail:
+		return versionPolicy, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownVersionPolicy, name)
+	}
+}
+
+// MergeVersions returns the version of the merged docker-compose files by the version policy. Configs without version
+// are skipped. The names are the names of the sources of the configs in the same order. The version policy strategy
+// is applied by the merge itself and not supported. An error is returned, when a compared version is malformed.
+func MergeVersions(names []string, configs []*Config, versionPolicy VersionPolicy) (string, error) {
+	_, err := ParseVersionPolicy(string(versionPolicy))
+	if err != nil {
+		return "", err
+	}
+
+	if versionPolicy == VersionPolicyDrop {
+		return "", nil
+	}
+
+	version := ""
+	versionSource := ""
+	for i, config := range configs {
+		if config == nil || len(config.Version) <= 0 {
+			continue
+		}
+
+		// The first version is compared with itself to validate it.
+		if len(version) <= 0 {
+			version = config.Version
+			versionSource = names[i]
+		}
+
+		result, err := compareVersions(config.Version, version)
+		switch {
+		case err != nil:
+			return "", fmt.Errorf("%s: %w", names[i], err)
+		case versionPolicy == VersionPolicyFail && result != 0:
+			return "", fmt.Errorf("%w: %s declares %s, %s declares %s", ErrVersionMismatch, versionSource, version, names[i], config.Version)
+		case result <= 0:
+			continue
+		}
+
+		version = config.Version
+		versionSource = names[i]
+	}
+
+	return version, nil
+}
+
+// compareVersions compares the versions segment by segment numerically. Missing segments are zero, for example 3 is
+// equal to 3.0. The result is negative, when a is lower than b, and positive, when a is higher than b. An error is
+// returned, when a version is malformed.
+func compareVersions(a string, b string) (int, error) {
+	segmentsA, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+
+	segmentsB, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < len(segmentsA) || i < len(segmentsB); i++ {
+		var segmentA, segmentB int
+		if i < len(segmentsA) {
+			segmentA = segmentsA[i]
+		}
+		if i < len(segmentsB) {
+			segmentB = segmentsB[i]
+		}
+
+		if segmentA != segmentB {
+			return segmentA - segmentB, nil
+		}
+	}
+	return 0, nil
+}
+
+// parseVersion returns the numeric segments of the version, for example [3 8] of 3.8. An error is returned, when a
+// segment is not a number.
+func parseVersion(version string) ([]int, error) {
+	segments := strings.Split(version, ".")
+	numbers := make([]int, 0, len(segments))
+	for _, segment := range segments {
+		number, err := strconv.ParseUint(segment, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrMalformedVersion, version)
+		}
+		numbers = append(numbers, int(number))
+	}
+	return numbers, nil
+}
+
+// VersionIssue is an attribute, which is not supported by the declared version of a docker-compose file.
+type VersionIssue struct {
+	Source  string `json:"source"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+// VersionIssues is a list of attributes, which are not supported by the declared version.
+type VersionIssues []*VersionIssue
+
+// Clone returns a deep copy of the version issues.
+func (vi VersionIssues) Clone() VersionIssues {
+	return VersionIssues(CloneSlice([]*VersionIssue(vi)))
+}
+
+// Clone returns a copy of the version issue.
+func (vi *VersionIssue) Clone() *VersionIssue {
+	return clonePointer(vi)
+}
+
+// String returns a readable report of all version issues.
+func (vi VersionIssues) String() string {
+	sb := new(strings.Builder)
+	for _, issue := range vi {
+		fmt.Fprintf(sb, "%s:%v:%v: %s is not supported by version %s\n", issue.Source, issue.Line, issue.Column, issue.Path, issue.Version)
+	}
+	return sb.String()
+}
+
+// VersionIssues returns all attributes of the document, which are not supported by its declared version. Only the
+// attributes of the docker-compose file, from which the document was decoded, are validated. An error is returned,
+// when the declared version is malformed.
+func (d *Document) VersionIssues() (VersionIssues, error) {
+	if d.versionErr != nil {
+		return nil, fmt.Errorf("%s: %w", d.source, d.versionErr)
+	}
+
+	versionIssues := d.versionIssues.Clone()
+	for _, versionIssue := range versionIssues {
+		versionIssue.Source = d.source
+	}
+	return versionIssues, nil
+}
+
+// versionRange is the range of versions, which support an attribute. The lowest version is inclusive, the highest
+// version exclusive. An empty version is unbounded.
+type versionRange struct {
+	pattern []string
+	lowest  string
+	highest string
+}
+
+// supportedVersions contains the attributes, which are only supported by a range of versions of the legacy
+// docker-compose file formats.
+var supportedVersions = []*versionRange{
+	{pattern: []string{"configs"}, lowest: "3.3"},
+	{pattern: []string{"secrets"}, lowest: "3.1"},
+	{pattern: []string{"services", "*", "blkio_config"}, highest: "3"},
+	{pattern: []string{"services", "*", "configs"}, lowest: "3.3"},
+	{pattern: []string{"services", "*", "cpu_count"}, highest: "3"},
+	{pattern: []string{"services", "*", "cpu_percent"}, highest: "3"},
+	{pattern: []string{"services", "*", "cpu_quota"}, highest: "3"},
+	{pattern: []string{"services", "*", "cpu_shares"}, highest: "3"},
+	{pattern: []string{"services", "*", "cpus"}, highest: "3"},
+	{pattern: []string{"services", "*", "cpuset"}, highest: "3"},
+	{pattern: []string{"services", "*", "deploy"}, lowest: "3"},
+	{pattern: []string{"services", "*", "extends"}, highest: "3"},
+	{pattern: []string{"services", "*", "mem_limit"}, highest: "3"},
+	{pattern: []string{"services", "*", "mem_reservation"}, highest: "3"},
+	{pattern: []string{"services", "*", "mem_swappiness"}, highest: "3"},
+	{pattern: []string{"services", "*", "memswap_limit"}, highest: "3"},
+	{pattern: []string{"services", "*", "oom_kill_disable"}, highest: "3"},
+	{pattern: []string{"services", "*", "secrets"}, lowest: "3.1"},
+	{pattern: []string{"services", "*", "volume_driver"}, highest: "3"},
+	{pattern: []string{"services", "*", "volumes_from"}, highest: "3"},
+}
+
+// supports returns true if the version is in the range. An error is returned, when the version is malformed.
+func (vr *versionRange) supports(version string) (bool, error) {
+	if len(vr.lowest) > 0 {
+		result, err := compareVersions(version, vr.lowest)
+		if err != nil || result < 0 {
+			return false, err
+		}
+	}
+
+	if len(vr.highest) > 0 {
+		result, err := compareVersions(version, vr.highest)
+		if err != nil || result >= 0 {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// validateVersion returns all attributes of the passed node, which are not supported by the version. Without version,
+// the node is a docker-compose file of the compose specification and nothing is validated. An error is returned, when
+// the version is malformed.
+func validateVersion(node *yaml.Node, version string) (VersionIssues, error) {
+	versionIssues := make(VersionIssues, 0)
+	if len(version) <= 0 {
+		return versionIssues, nil
+	}
+
+	_, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	walkNodePaths(node, make([]string, 0), func(path []string, keyNode *yaml.Node, valueNode *yaml.Node) {
+		for _, supportedVersion := range supportedVersions {
+			if keyNode == nil || len(supportedVersion.pattern) != len(path) || !matchesPattern(supportedVersion.pattern, path) {
+				continue
+			}
+
+			if supported, _ := supportedVersion.supports(version); !supported {
+				versionIssues = append(versionIssues, &VersionIssue{
+					Line:    keyNode.Line,
+					Column:  keyNode.Column,
+					Path:    strings.Join(path, pathSeparator),
+					Version: version,
+				})
+			}
+		}
+	})
+
+	return versionIssues, nil
+}
@@ -0,0 +1,132 @@
+package dockerCompose_test
+
+import (
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeVersions(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		versions        []string
+		versionPolicy   dockerCompose.VersionPolicy
+		expectedVersion string
+		expectedErr     error
+	}{
+		{versions: []string{"3.8", "", "3.10", "3"}, versionPolicy: dockerCompose.VersionPolicyHighest, expectedVersion: "3.10"},
+		{versions: []string{"2.4", "3"}, versionPolicy: dockerCompose.VersionPolicyDrop, expectedVersion: ""},
+		{versions: []string{"3", "", "3.0"}, versionPolicy: dockerCompose.VersionPolicyFail, expectedVersion: "3"},
+		{versions: []string{"3.8", "3.9"}, versionPolicy: dockerCompose.VersionPolicyFail, expectedErr: dockerCompose.ErrVersionMismatch},
+		{versions: []string{"3.8"}, versionPolicy: "lowest", expectedErr: dockerCompose.ErrUnknownVersionPolicy},
+		{versions: []string{"3.8", "3.x"}, versionPolicy: dockerCompose.VersionPolicyHighest, expectedErr: dockerCompose.ErrMalformedVersion},
+		{versions: []string{"3.x"}, versionPolicy: dockerCompose.VersionPolicyFail, expectedErr: dockerCompose.ErrMalformedVersion},
+		{versions: []string{"3.x", "3.8"}, versionPolicy: dockerCompose.VersionPolicyDrop, expectedVersion: ""},
+	}
+
+	for i, testCase := range testCases {
+		names := make([]string, 0)
+		configs := make([]*dockerCompose.Config, 0)
+		for j, version := range testCase.versions {
+			names = append(names, string(rune('a'+j))+".yml")
+			configs = append(configs, &dockerCompose.Config{Version: version})
+		}
+
+		version, err := dockerCompose.MergeVersions(names, configs, testCase.versionPolicy)
+		if testCase.expectedErr != nil {
+			require.ErrorIs(err, testCase.expectedErr, "TestCase %v", i)
+			continue
+		}
+		require.NoError(err, "TestCase %v", i)
+		require.Equal(testCase.expectedVersion, version, "TestCase %v", i)
+	}
+}
+
+func TestDocument_VersionIssues(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		s                     string
+		expectedVersionIssues dockerCompose.VersionIssues
+		expectedErr           error
+	}{
+		{
+			s: `services:
+  app:
+    deploy:
+      replicas: 2
+    mem_limit: 512m
+`,
+			expectedVersionIssues: dockerCompose.VersionIssues{},
+		},
+		{
+			s: `version: "2.4"
+services:
+  app:
+    deploy:
+      replicas: 2
+    mem_limit: 512m
+`,
+			expectedVersionIssues: dockerCompose.VersionIssues{
+				{Source: "docker-compose.yml", Line: 4, Column: 5, Path: "services.app.deploy", Version: "2.4"},
+			},
+		},
+		{
+			s: `version: "3.8"
+services:
+  app:
+    deploy:
+      replicas: 2
+    mem_limit: 512m
+    secrets:
+    - token
+secrets:
+  token:
+    file: ./token
+`,
+			expectedVersionIssues: dockerCompose.VersionIssues{
+				{Source: "docker-compose.yml", Line: 6, Column: 5, Path: "services.app.mem_limit", Version: "3.8"},
+			},
+		},
+		{
+			s: `version: "3"
+services:
+  app:
+    secrets:
+    - token
+secrets:
+  token:
+    file: ./token
+`,
+			expectedVersionIssues: dockerCompose.VersionIssues{
+				{Source: "docker-compose.yml", Line: 4, Column: 5, Path: "services.app.secrets", Version: "3"},
+				{Source: "docker-compose.yml", Line: 6, Column: 1, Path: "secrets", Version: "3"},
+			},
+		},
+		{
+			s: `version: "3.x"
+services:
+  app:
+    image: example.local/app:1.0.0
+`,
+			expectedErr: dockerCompose.ErrMalformedVersion,
+		},
+	}
+
+	for i, testCase := range testCases {
+		document := dockerCompose.NewDocument(dockerCompose.NewConfig())
+		require.NoError(yaml.Unmarshal([]byte(testCase.s), document), "TestCase %v", i)
+		document.SetSource("docker-compose.yml")
+
+		versionIssues, err := document.VersionIssues()
+		if testCase.expectedErr != nil {
+			require.ErrorIs(err, testCase.expectedErr, "TestCase %v", i)
+			continue
+		}
+		require.NoError(err, "TestCase %v", i)
+		require.Equal(testCase.expectedVersionIssues, versionIssues, "TestCase %v", i)
+	}
+}
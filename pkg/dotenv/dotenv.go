@@ -0,0 +1,219 @@
+package dotenv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/interpolation"
+)
+
+var (
+	ErrInvalidLine    error = errors.New("invalid line")
+	ErrUnclosedQuotes error = errors.New("unclosed quotes")
+)
+
+// Read returns the variables of the passed env file.
+func Read(name string) (map[string]string, error) {
+	return ReadWithLookup(name, nil)
+}
+
+// ReadWithLookup returns the variables of the passed env file. Variables, which are not declared before in the same
+// file, are looked up by the passed function, for example in the environment of the process.
+func ReadWithLookup(name string, lookup interpolation.LookupFunc) (map[string]string, error) {
+	// #nosec G304
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	variables, err := ParseWithLookup(file, lookup)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return variables, nil
+}
+
+// Parse returns the variables of an env file. Each variable is declared in the form KEY=VALUE and can be prefixed by
+// export. Empty lines and comments starting with # are ignored.
+//
+// Values can be quoted. Single quoted values are taken literally. Double quoted values support the escape sequences
+// \n, \r, \t, \" and \\. Quoted values can span multiple lines. Unquoted and double quoted values are interpolated
+// with the variables declared before in the same file.
+func Parse(r io.Reader) (map[string]string, error) {
+	return ParseWithLookup(r, nil)
+}
+
+// ParseWithLookup returns the variables of an env file like Parse. Variables, which are not declared before in the same
+// file, are looked up by the passed function, which matches docker compose resolving them from the environment of the
+// process. A nil function looks up no further variables.
+func ParseWithLookup(r io.Reader, lookup interpolation.LookupFunc) (map[string]string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{
+		s:          strings.ReplaceAll(string(b), "\r\n", "\n"),
+		lineNumber: 1,
+		lookup:     lookup,
+		variables:  make(map[string]string),
+	}
+
+	err = p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.variables, nil
+}
+
+type parser struct {
+	s          string
+	pos        int
+	lineNumber int
+	lookup     interpolation.LookupFunc
+	variables  map[string]string
+}
+
+func (p *parser) parse() error {
+	for {
+		p.skipWhitespaces()
+		if p.pos >= len(p.s) {
+			return nil
+		}
+
+		if p.s[p.pos] == '#' {
+			p.skipLine()
+			continue
+		}
+
+		lineNumber := p.lineNumber
+		line := p.line()
+
+		key, _, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if exportKey, isExport := strings.CutPrefix(key, "export"); isExport && len(exportKey) > 0 &&
+			(exportKey[0] == ' ' || exportKey[0] == '\t') {
+			key = strings.TrimSpace(exportKey)
+		}
+
+		if !found || len(key) <= 0 || strings.ContainsAny(key, " \t") {
+			return fmt.Errorf("%w %v: %s", ErrInvalidLine, lineNumber, line)
+		}
+
+		p.pos += strings.Index(line, "=") + 1
+		p.skipBlanks()
+
+		value, err := p.value()
+		if err != nil {
+			return fmt.Errorf("%w %v: %s", err, lineNumber, line)
+		}
+
+		p.variables[key] = value
+	}
+}
+
+// value returns the value of the variable at the current position and moves the position to the next line.
+func (p *parser) value() (string, error) {
+	if p.pos >= len(p.s) {
+		return "", nil
+	}
+
+	quote := p.s[p.pos]
+	if quote != '"' && quote != '\'' {
+		value := p.line()
+		p.skipLine()
+
+		for i := 0; i < len(value); i++ {
+			if value[i] == '#' && (i == 0 || value[i-1] == ' ' || value[i-1] == '\t') {
+				value = value[:i]
+				break
+			}
+		}
+
+		return interpolation.Interpolate(strings.TrimSpace(value), p.lookupVariable)
+	}
+
+	end := p.pos + 1
+	for ; end < len(p.s) && p.s[end] != quote; end++ {
+		if quote == '"' && p.s[end] == '\\' {
+			end++
+		}
+	}
+
+	if end >= len(p.s) {
+		return "", ErrUnclosedQuotes
+	}
+
+	value := p.s[p.pos+1 : end]
+	p.lineNumber += strings.Count(value, "\n")
+	p.pos = end + 1
+
+	rest := strings.TrimSpace(p.line())
+	p.skipLine()
+	if len(rest) > 0 && !strings.HasPrefix(rest, "#") {
+		return "", ErrInvalidLine
+	}
+
+	if quote == '\'' {
+		return value, nil
+	}
+
+	value = strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(value)
+
+	return interpolation.Interpolate(value, p.lookupVariable)
+}
+
+// lookupVariable returns the value of a variable declared before in the same file. Other variables are looked up by the
+// lookup function of the parser.
+func (p *parser) lookupVariable(name string) (string, bool) {
+	if value, present := p.variables[name]; present {
+		return value, true
+	}
+
+	if p.lookup == nil {
+		return "", false
+	}
+
+	return p.lookup(name)
+}
+
+// line returns the rest of the current line without moving the position.
+func (p *parser) line() string {
+	end := strings.IndexByte(p.s[p.pos:], '\n')
+	if end < 0 {
+		return p.s[p.pos:]
+	}
+	return p.s[p.pos : p.pos+end]
+}
+
+// skipLine moves the position to the beginning of the next line.
+func (p *parser) skipLine() {
+	p.pos += len(p.line())
+	if p.pos < len(p.s) {
+		p.pos++
+		p.lineNumber++
+	}
+}
+
+// skipBlanks moves the position behind all spaces and tabs.
+func (p *parser) skipBlanks() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipWhitespaces moves the position behind all spaces, tabs and line breaks.
+func (p *parser) skipWhitespaces() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\n", p.s[p.pos]) >= 0 {
+		if p.s[p.pos] == '\n' {
+			p.lineNumber++
+		}
+		p.pos++
+	}
+}
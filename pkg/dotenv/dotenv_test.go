@@ -0,0 +1,115 @@
+package dotenv_test
+
+import (
+	"strings"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/dotenv"
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/interpolation"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		s                 string
+		expectedVariables map[string]string
+		expectedError     error
+	}{
+		{
+			s:                 "",
+			expectedVariables: map[string]string{},
+		},
+		{
+			s: `# comment
+TAG=1.0.0
+
+ PORT = 8080
+EMPTY=
+URL=postgres://db:5432/app?sslmode=disable
+`,
+			expectedVariables: map[string]string{
+				"EMPTY": "",
+				"PORT":  "8080",
+				"TAG":   "1.0.0",
+				"URL":   "postgres://db:5432/app?sslmode=disable",
+			},
+		},
+		{
+			s: `export TAG=1.0.0
+SINGLE='${TAG} # no comment'
+DOUBLE="image:${TAG}\tlatest" # comment
+UNQUOTED=image:$TAG # comment
+HASH=a#b
+MULTILINE="-----BEGIN KEY-----
+abc\"def
+-----END KEY-----"
+ESCAPED=$$TAG
+`,
+			expectedVariables: map[string]string{
+				"DOUBLE":    "image:1.0.0\tlatest",
+				"ESCAPED":   "$TAG",
+				"HASH":      "a#b",
+				"MULTILINE": "-----BEGIN KEY-----\nabc\"def\n-----END KEY-----",
+				"SINGLE":    "${TAG} # no comment",
+				"TAG":       "1.0.0",
+				"UNQUOTED":  "image:1.0.0",
+			},
+		},
+		{
+			s:             "TAG",
+			expectedError: dotenv.ErrInvalidLine,
+		},
+		{
+			s:             "TAG=\"1.0.0",
+			expectedError: dotenv.ErrUnclosedQuotes,
+		},
+		{
+			s:             "TAG='1.0.0' latest",
+			expectedError: dotenv.ErrInvalidLine,
+		},
+		{
+			s:             "export TAG VERSION=1.0.0",
+			expectedError: dotenv.ErrInvalidLine,
+		},
+		{
+			s:             "=1.0.0",
+			expectedError: dotenv.ErrInvalidLine,
+		},
+	}
+
+	for i, testCase := range testCases {
+		variables, err := dotenv.Parse(strings.NewReader(testCase.s))
+		if testCase.expectedError != nil {
+			require.ErrorIs(err, testCase.expectedError, "TestCase %v", i)
+			continue
+		}
+		require.NoError(err, "TestCase %v", i)
+		require.Equal(testCase.expectedVariables, variables, "TestCase %v", i)
+	}
+}
+
+func TestParseWithLookup(t *testing.T) {
+	require := require.New(t)
+
+	environment := map[string]string{
+		"HOME": "/home/user",
+		"TAG":  "2.0.0",
+	}
+
+	variables, err := dotenv.ParseWithLookup(strings.NewReader(`TAG=1.0.0
+IMAGE=example.local/app:${TAG}
+DATA_DIR=${HOME}/data
+CACHE_DIR="${XDG_CACHE_HOME:-/tmp}/app"
+LITERAL='${HOME}'
+`), interpolation.LookupMap(environment))
+	require.NoError(err)
+	require.Equal(map[string]string{
+		"CACHE_DIR": "/tmp/app",
+		"DATA_DIR":  "/home/user/data",
+		"IMAGE":     "example.local/app:1.0.0",
+		"LITERAL":   "${HOME}",
+		"TAG":       "1.0.0",
+	}, variables)
+}
@@ -0,0 +1,105 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+)
+
+var (
+	ErrExtendsCycle          error = errors.New("cyclic extends")
+	ErrExtendsServiceMissing error = errors.New("extended service not found")
+)
+
+// resolveExtends replaces all services of the passed docker-compose config, which extend another service, by the fully
+// expanded service. The dockerComposeURL is the location of the config and is required to resolve the referenced
+// docker-compose files.
+func resolveExtends(config *dockerCompose.Config, dockerComposeURL *url.URL, options *Options) error {
+	serviceNames := make([]string, 0, len(config.Services))
+	for serviceName := range config.Services {
+		serviceNames = append(serviceNames, serviceName)
+	}
+	sort.Strings(serviceNames)
+
+	for _, serviceName := range serviceNames {
+		_, err := resolveServiceExtends(config, dockerComposeURL, serviceName, make([]string, 0), options)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveServiceExtends returns the expanded service of the passed config. The chain contains all services which are
+// currently resolved and is used to detect cycles.
+func resolveServiceExtends(config *dockerCompose.Config, dockerComposeURL *url.URL, serviceName string, chain []string, options *Options) (*dockerCompose.Service, error) {
+	service, present := config.Services[serviceName]
+	switch {
+	case !present:
+		return nil, fmt.Errorf("%w: %s in %s", ErrExtendsServiceMissing, serviceName, dockerComposeURL)
+	case service == nil || service.Extends == nil:
+		return service, nil
+	}
+
+	reference := fmt.Sprintf("%s#%s", dockerComposeURL, serviceName)
+	for _, r := range chain {
+		if r == reference {
+			return nil, fmt.Errorf("%w: %s -> %s", ErrExtendsCycle, strings.Join(chain, " -> "), reference)
+		}
+	}
+	chain = append(chain, reference)
+
+	baseConfig := config
+	baseURL := dockerComposeURL
+	if len(service.Extends.File) > 0 {
+		var err error
+		baseURL, err = resolveReference(dockerComposeURL, service.Extends.File)
+		if err != nil {
+			return nil, err
+		}
+
+		baseDocument, err := fetch(baseURL, "", options)
+		if err != nil {
+			return nil, err
+		}
+
+		err = resolveIncludes(baseDocument, baseURL, make([]string, 0), options)
+		if err != nil {
+			return nil, err
+		}
+		baseConfig = baseDocument.Config
+	}
+
+	baseService, err := resolveServiceExtends(baseConfig, baseURL, service.Extends.Service, chain, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Relative paths of a base service of another file refer to the directory of that file. They are rebased to refer to
+	// the directory of the extending docker-compose file instead. Paths of remote files are kept as declared.
+	if baseConfig != config && baseService != nil && !isRemote(dockerComposeURL) {
+		rebaseConfig := dockerCompose.NewConfig()
+		rebaseConfig.SetBase(baseConfig.Base())
+		rebaseConfig.Services[service.Extends.Service] = baseService.Clone()
+
+		err = rebaseConfig.RebasePaths(config.Base())
+		if err != nil && !errors.Is(err, dockerCompose.ErrRemoteBase) {
+			return nil, err
+		}
+		baseService = rebaseConfig.Services[service.Extends.Service]
+	}
+
+	// The attributes of the extending service overwrite the attributes of the base service. Sequences and mappings are
+	// merged by the last-win merge, which matches the extends merge rules of the compose specification. The base service
+	// can be extended by multiple services and is therefore not modified.
+	expandedService := config.ExtendedService(serviceName, baseService)
+
+	config.Services[serviceName] = expandedService
+
+	return expandedService, nil
+}
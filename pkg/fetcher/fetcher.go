@@ -3,49 +3,217 @@ package fetcher
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/dotenv"
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/interpolation"
 	"gopkg.in/yaml.v3"
 )
 
+// Options configures how docker-compose files are fetched.
+type Options struct {
+	// Environment contains the variables, which are used to interpolate the docker-compose files.
+	Environment map[string]string
+
+	// NoInterpolate keeps variable expressions verbatim.
+	NoInterpolate bool
+
+	// ProjectDirectory contains the .env file. When empty, the directory of the first local docker-compose file is used.
+	ProjectDirectory string
+
+	// RebaseDirectory is the directory, which relative paths of local docker-compose files are rewritten to refer to.
+	// When empty, relative paths are kept as declared.
+	RebaseDirectory string
+}
+
+// NewOptions returns the default options. The variables of the process environment are used for the interpolation.
+func NewOptions() *Options {
+	environment := make(map[string]string)
+	for _, variable := range os.Environ() {
+		key, value, _ := strings.Cut(variable, "=")
+		environment[key] = value
+	}
+
+	return &Options{
+		Environment: environment,
+	}
+}
+
+// Fetch returns the docker-compose configs of the passed URLs with the default options.
 func Fetch(urls ...string) ([]*dockerCompose.Config, error) {
-	dockerComposeConfigs := make([]*dockerCompose.Config, 0)
+	return FetchWithOptions(NewOptions(), urls...)
+}
+
+// FetchWithOptions returns the docker-compose configs of the passed URLs. References to other docker-compose files
+// are resolved.
+func FetchWithOptions(options *Options, urls ...string) ([]*dockerCompose.Config, error) {
+	documents, err := FetchDocuments(options, urls...)
+	if err != nil {
+		return nil, err
+	}
+
+	dockerComposeConfigs := make([]*dockerCompose.Config, 0, len(documents))
+	for _, document := range documents {
+		dockerComposeConfigs = append(dockerComposeConfigs, document.Config)
+	}
 
+	return dockerComposeConfigs, nil
+}
+
+// FetchDocuments returns the docker-compose documents of the passed URLs. References to other docker-compose files
+// are resolved. Besides the config, a document contains the origins of all values, the anchors, the inline merge
+// directives and locks as well as the diagnostics of its docker-compose file.
+func FetchDocuments(options *Options, urls ...string) ([]*dockerCompose.Document, error) {
+	dockerComposeURLs := make([]*url.URL, 0, len(urls))
 	for _, rawURL := range urls {
 		dockerComposeURL, err := url.Parse(rawURL)
 		if err != nil {
 			return nil, err
 		}
+		dockerComposeURLs = append(dockerComposeURLs, dockerComposeURL)
+	}
 
-		switch dockerComposeURL.Scheme {
-		case "http", "https":
-			dockerComposeConfig, err := getDockerComposeViaHTTP(dockerComposeURL.String())
-			if err != nil {
-				return nil, err
+	options, err := withDotEnv(options, dockerComposeURLs)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make([]*dockerCompose.Document, 0)
+
+	for _, dockerComposeURL := range dockerComposeURLs {
+		document, err := fetch(dockerComposeURL, "", options)
+		if err != nil {
+			return nil, err
+		}
+
+		err = resolveIncludes(document, dockerComposeURL, make([]string, 0), options)
+		if err != nil {
+			return nil, err
+		}
+
+		err = resolveExtends(document.Config, dockerComposeURL, options)
+		if err != nil {
+			return nil, err
+		}
+
+		documents = append(documents, document)
+	}
+
+	return documents, nil
+}
+
+var ErrPathIsDir error = errors.New("path is a directory")
+
+// dotEnvFilename is the name of the env file, which is read from the project directory.
+const dotEnvFilename string = ".env"
+
+// withDotEnv returns a copy of the options, whose environment is completed by the variables of the .env file of the
+// project directory. Variables of the environment take precedence over the variables of the .env file, which matches
+// the precedence rules of docker compose. Variable expressions of the .env file refer to the variables declared before
+// in the .env file or to the variables of the environment.
+func withDotEnv(options *Options, dockerComposeURLs []*url.URL) (*Options, error) {
+	projectDirectory := options.ProjectDirectory
+	if len(projectDirectory) <= 0 {
+		for _, dockerComposeURL := range dockerComposeURLs {
+			if dockerComposeURL.Scheme == "" || dockerComposeURL.Scheme == "file" {
+				projectDirectory = filepath.Dir(dockerComposeURL.Path)
+				break
 			}
+		}
+	}
+
+	optionsWithDotEnv := *options
+	optionsWithDotEnv.ProjectDirectory = projectDirectory
+
+	if len(projectDirectory) <= 0 {
+		return &optionsWithDotEnv, nil
+	}
+
+	variables, err := dotenv.ReadWithLookup(filepath.Join(projectDirectory, dotEnvFilename), interpolation.LookupMap(options.Environment))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return &optionsWithDotEnv, nil
+	case err != nil:
+		return nil, err
+	}
+
+	optionsWithDotEnv.Environment = make(map[string]string, len(options.Environment)+len(variables))
+	for key, value := range variables {
+		optionsWithDotEnv.Environment[key] = value
+	}
+	for key, value := range options.Environment {
+		optionsWithDotEnv.Environment[key] = value
+	}
+
+	return &optionsWithDotEnv, nil
+}
+
+// fetch returns the docker-compose document of the passed URL without resolving any references to other
+// docker-compose files. Relative paths of the config refer to the passed base, which is the directory of the
+// docker-compose file when empty.
+func fetch(dockerComposeURL *url.URL, base string, options *Options) (*dockerCompose.Document, error) {
+	var (
+		document *dockerCompose.Document
+		err      error
+	)
+
+	switch dockerComposeURL.Scheme {
+	case "http", "https":
+		document, err = getDockerComposeViaHTTP(dockerComposeURL.String(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(base) <= 0 {
+			base = directoryOf(dockerComposeURL)
+		}
+		document.Config.SetBase(base)
+	case "file":
+		fallthrough
+	default:
+		document, err = readDockerComposeFromFile(dockerComposeURL.Path, options)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(base) <= 0 {
+			base = directoryOf(dockerComposeURL)
+		}
+		document.Config.SetBase(base)
 
-			dockerComposeConfigs = append(dockerComposeConfigs, dockerComposeConfig)
-		case "file":
-			fallthrough
-		default:
-			dockerComposeConfig, err := readDockerComposeFromFile(dockerComposeURL.Path)
+		// Relative paths are rebased immediately, because they refer to the directory of this docker-compose file. After
+		// resolving includes and extends, the paths of multiple docker-compose files are mixed.
+		if len(options.RebaseDirectory) > 0 {
+			err = document.Config.RebasePaths(options.RebaseDirectory)
 			if err != nil {
 				return nil, err
 			}
-
-			dockerComposeConfigs = append(dockerComposeConfigs, dockerComposeConfig)
 		}
 	}
 
-	return dockerComposeConfigs, nil
+	document.SetSource(dockerComposeURL.String())
+
+	return document, nil
 }
 
-var ErrPathIsDir error = errors.New("path is a directory")
+func getDockerComposeViaHTTP(url string, options *Options) (*dockerCompose.Document, error) {
+	body, err := getViaHTTP(url)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = body.Close() }()
 
-func getDockerComposeViaHTTP(url string) (*dockerCompose.Config, error) {
+	return decode(body, url, options)
+}
+
+// getViaHTTP returns the body of the response of the passed URL. The body must be closed by the caller.
+func getViaHTTP(url string) (io.ReadCloser, error) {
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
@@ -55,24 +223,16 @@ func getDockerComposeViaHTTP(url string) (*dockerCompose.Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
 		return nil, fmt.Errorf("received unexpected HTTP-Statuscode %v", resp.StatusCode)
 	}
 
-	dockerCompose := dockerCompose.NewConfig()
-
-	yamlDecoder := yaml.NewDecoder(resp.Body)
-	err = yamlDecoder.Decode(&dockerCompose)
-	if err != nil {
-		return nil, err
-	}
-
-	return dockerCompose, nil
+	return resp.Body, nil
 }
 
-func readDockerComposeFromFile(name string) (*dockerCompose.Config, error) {
+func readDockerComposeFromFile(name string, options *Options) (*dockerCompose.Document, error) {
 	fileStat, err := os.Stat(name)
 	switch {
 	case err != nil:
@@ -88,13 +248,72 @@ func readDockerComposeFromFile(name string) (*dockerCompose.Config, error) {
 	}
 	defer func() { _ = file.Close() }()
 
-	dockerCompose := dockerCompose.NewConfig()
+	return decode(file, name, options)
+}
+
+// decode returns the docker-compose document of the reader. Variable expressions are interpolated before the document
+// is decoded, unless the interpolation is disabled. Interpolation errors are prefixed by the name of the docker-compose
+// file.
+func decode(r io.Reader, name string, options *Options) (*dockerCompose.Document, error) {
+	node := new(yaml.Node)
+
+	yamlDecoder := yaml.NewDecoder(r)
+	err := yamlDecoder.Decode(node)
+	if err != nil {
+		return nil, err
+	}
+
+	if !options.NoInterpolate {
+		err = interpolation.InterpolateNode(node, interpolation.LookupMap(options.Environment))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%w", name, err)
+		}
+	}
+
+	document := dockerCompose.NewDocument(dockerCompose.NewConfig())
+
+	err = node.Decode(document)
+	if err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// directoryOf returns the directory of the docker-compose file of the passed URL. The directory of a remote
+// docker-compose file is returned as URL with a trailing slash.
+func directoryOf(dockerComposeURL *url.URL) string {
+	if isRemote(dockerComposeURL) {
+		return dockerComposeURL.ResolveReference(&url.URL{Path: "./"}).String()
+	}
+	return filepath.Dir(dockerComposeURL.Path)
+}
 
-	yamlDecoder := yaml.NewDecoder(file)
-	err = yamlDecoder.Decode(&dockerCompose)
+// isRemote returns true if the URL refers to a remote resource, which is fetched via HTTP.
+func isRemote(u *url.URL) bool {
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// resolveReference returns the URL of the passed reference relative to the URL of the referencing docker-compose file.
+// References of local docker-compose files are resolved relative to the directory of the file, references of remote
+// docker-compose files relative to the URL.
+func resolveReference(baseURL *url.URL, reference string) (*url.URL, error) {
+	referenceURL, err := url.Parse(reference)
 	if err != nil {
 		return nil, err
 	}
 
-	return dockerCompose, nil
+	switch {
+	case referenceURL.IsAbs() && referenceURL.Scheme != "file":
+		return referenceURL, nil
+	case isRemote(baseURL):
+		return baseURL.ResolveReference(referenceURL), nil
+	case filepath.IsAbs(referenceURL.Path):
+		return referenceURL, nil
+	default:
+		return &url.URL{
+			Scheme: baseURL.Scheme,
+			Path:   filepath.Join(filepath.Dir(baseURL.Path), referenceURL.Path),
+		}, nil
+	}
 }
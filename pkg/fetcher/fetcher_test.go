@@ -0,0 +1,249 @@
+package fetcher_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/fetcher"
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/interpolation"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func readExpectedResult(t *testing.T, name string) *dockerCompose.Config {
+	b, err := os.ReadFile(name)
+	require.NoError(t, err)
+
+	expectedDockerComposeConfig := dockerCompose.NewConfig()
+	require.NoError(t, yaml.Unmarshal(b, expectedDockerComposeConfig))
+
+	return expectedDockerComposeConfig
+}
+
+func TestFetch_Extends(t *testing.T) {
+	require := require.New(t)
+
+	expectedDockerComposeConfig := readExpectedResult(t, "test/assets/extends/expectedResult.yml")
+
+	dockerComposeConfigs, err := fetcher.Fetch("test/assets/extends/docker-compose.yml")
+	require.NoError(err)
+	require.Len(dockerComposeConfigs, 1)
+	require.True(expectedDockerComposeConfig.Equal(dockerComposeConfigs[0]))
+
+	httpServer := httptest.NewServer(http.FileServer(http.Dir("test/assets")))
+	defer httpServer.Close()
+
+	dockerComposeConfigs, err = fetcher.Fetch(httpServer.URL + "/extends/docker-compose.yml")
+	require.NoError(err)
+	require.Len(dockerComposeConfigs, 1)
+	require.True(expectedDockerComposeConfig.Equal(dockerComposeConfigs[0]))
+}
+
+func TestFetch_ExtendsRebase(t *testing.T) {
+	require := require.New(t)
+
+	expectedDockerComposeConfig := readExpectedResult(t, "test/assets/extendsRebase/expectedResult.yml")
+
+	dockerComposeConfigs, err := fetcher.Fetch("test/assets/extendsRebase/docker-compose.yml")
+	require.NoError(err)
+	require.Len(dockerComposeConfigs, 1)
+	require.True(expectedDockerComposeConfig.Equal(dockerComposeConfigs[0]))
+
+	// Paths of remote files are kept as declared.
+	httpServer := httptest.NewServer(http.FileServer(http.Dir("test/assets")))
+	defer httpServer.Close()
+
+	dockerComposeConfigs, err = fetcher.Fetch(httpServer.URL + "/extendsRebase/docker-compose.yml")
+	require.NoError(err)
+	require.Len(dockerComposeConfigs, 1)
+	require.Equal("./app", dockerComposeConfigs[0].Services["app"].Build.Context)
+	require.Equal([]string{"./app.env"}, dockerComposeConfigs[0].Services["app"].EnvFile)
+	require.Equal([]string{"LOG_LEVEL=debug"}, dockerComposeConfigs[0].Services["app"].Environments)
+	require.Empty(dockerComposeConfigs[0].Services["app"].CapabilitiesDrop)
+}
+
+func TestFetch_ExtendsCycle(t *testing.T) {
+	require := require.New(t)
+
+	_, err := fetcher.Fetch("test/assets/extendsCycle/docker-compose-a.yml")
+	require.ErrorIs(err, fetcher.ErrExtendsCycle)
+}
+
+func TestFetch_Include(t *testing.T) {
+	require := require.New(t)
+
+	expectedDockerComposeConfig := readExpectedResult(t, "test/assets/include/expectedResult.yml")
+
+	dockerComposeConfigs, err := fetcher.Fetch("test/assets/include/docker-compose.yml")
+	require.NoError(err)
+	require.Len(dockerComposeConfigs, 1)
+	require.True(expectedDockerComposeConfig.Equal(dockerComposeConfigs[0]))
+
+	httpServer := httptest.NewServer(http.FileServer(http.Dir("test/assets")))
+	defer httpServer.Close()
+
+	dockerComposeConfigs, err = fetcher.Fetch(httpServer.URL + "/include/docker-compose.yml")
+	require.NoError(err)
+	require.Len(dockerComposeConfigs, 1)
+	require.True(expectedDockerComposeConfig.Equal(dockerComposeConfigs[0]))
+}
+
+func TestFetch_IncludeConflict(t *testing.T) {
+	require := require.New(t)
+
+	_, err := fetcher.Fetch("test/assets/includeConflict/docker-compose.yml")
+	require.ErrorIs(err, fetcher.ErrIncludeConflict)
+
+	_, err = fetcher.Fetch("test/assets/includeConflict/docker-compose-configs.yml")
+	require.ErrorIs(err, fetcher.ErrIncludeConflict)
+}
+
+func TestFetchWithOptions_IncludeEnvFile(t *testing.T) {
+	require := require.New(t)
+
+	expectedDockerComposeConfig := readExpectedResult(t, "test/assets/includeEnvFile/expectedResult.yml")
+
+	options := &fetcher.Options{
+		Environment: map[string]string{},
+	}
+
+	dockerComposeConfigs, err := fetcher.FetchWithOptions(options, "test/assets/includeEnvFile/docker-compose.yml")
+	require.NoError(err)
+	require.Len(dockerComposeConfigs, 1)
+	require.True(expectedDockerComposeConfig.Equal(dockerComposeConfigs[0]))
+
+	// Variables of the environment take precedence over the variables of the env files of the includes.
+	options.Environment["POSTGRES_VERSION"] = "17"
+
+	dockerComposeConfigs, err = fetcher.FetchWithOptions(options, "test/assets/includeEnvFile/docker-compose.yml")
+	require.NoError(err)
+	require.Len(dockerComposeConfigs, 1)
+	require.Equal("postgres:17", dockerComposeConfigs[0].Services["db"].Image)
+}
+
+func TestFetch_IncludeCycle(t *testing.T) {
+	require := require.New(t)
+
+	_, err := fetcher.Fetch("test/assets/includeCycle/docker-compose-a.yml")
+	require.ErrorIs(err, fetcher.ErrIncludeCycle)
+}
+
+func TestFetchWithOptions_Interpolation(t *testing.T) {
+	require := require.New(t)
+
+	expectedDockerComposeConfig := readExpectedResult(t, "test/assets/interpolation/expectedResult.yml")
+
+	options := &fetcher.Options{
+		Environment: map[string]string{
+			"DATA_DIR":    "/srv/data",
+			"DB_PASSWORD": "secret",
+			"TAG":         "1.0.0",
+		},
+	}
+
+	dockerComposeConfigs, err := fetcher.FetchWithOptions(options, "test/assets/interpolation/docker-compose.yml")
+	require.NoError(err)
+	require.Len(dockerComposeConfigs, 1)
+	require.True(expectedDockerComposeConfig.Equal(dockerComposeConfigs[0]))
+
+	delete(options.Environment, "DB_PASSWORD")
+
+	_, err = fetcher.FetchWithOptions(options, "test/assets/interpolation/docker-compose.yml")
+	require.ErrorIs(err, interpolation.ErrRequiredVariable)
+	require.ErrorContains(err, "test/assets/interpolation/docker-compose.yml:4:7: ")
+}
+
+func TestFetchWithOptions_NoInterpolation(t *testing.T) {
+	require := require.New(t)
+
+	expectedDockerComposeConfig := readExpectedResult(t, "test/assets/interpolation/docker-compose.yml")
+
+	options := &fetcher.Options{
+		NoInterpolate: true,
+	}
+
+	dockerComposeConfigs, err := fetcher.FetchWithOptions(options, "test/assets/interpolation/docker-compose.yml")
+	require.NoError(err)
+	require.Len(dockerComposeConfigs, 1)
+	require.True(expectedDockerComposeConfig.Equal(dockerComposeConfigs[0]))
+}
+
+func TestFetchWithOptions_DotEnv(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		options             *fetcher.Options
+		expectedImage       string
+		expectedEnvironment string
+	}{
+		{
+			options:             &fetcher.Options{},
+			expectedImage:       "example.local/app:1.0.0",
+			expectedEnvironment: "LOG_LEVEL=info",
+		},
+		{
+			options: &fetcher.Options{
+				Environment: map[string]string{
+					"TAG": "3.0.0",
+				},
+			},
+			expectedImage:       "example.local/app:3.0.0",
+			expectedEnvironment: "LOG_LEVEL=info",
+		},
+		{
+			options: &fetcher.Options{
+				ProjectDirectory: "test/assets/dotenv/project",
+			},
+			expectedImage:       "example.local/app:2.0.0",
+			expectedEnvironment: "LOG_LEVEL=debug",
+		},
+	}
+
+	for i, testCase := range testCases {
+		dockerComposeConfigs, err := fetcher.FetchWithOptions(testCase.options, "test/assets/dotenv/docker-compose.yml")
+		require.NoError(err, "TestCase %v", i)
+		require.Len(dockerComposeConfigs, 1, "TestCase %v", i)
+		require.Equal(testCase.expectedImage, dockerComposeConfigs[0].Services["app"].Image, "TestCase %v", i)
+		require.Equal([]string{testCase.expectedEnvironment}, dockerComposeConfigs[0].Services["app"].Environments, "TestCase %v", i)
+	}
+}
+
+func TestFetchWithOptions_RebasePaths(t *testing.T) {
+	require := require.New(t)
+
+	expectedDockerComposeConfig := readExpectedResult(t, "test/assets/rebase/expectedResult.yml")
+
+	options := &fetcher.Options{
+		RebaseDirectory: "test/assets/rebase",
+	}
+
+	dockerComposeConfigs, err := fetcher.FetchWithOptions(options, "test/assets/rebase/integration-test/docker-compose.yml")
+	require.NoError(err)
+	require.Len(dockerComposeConfigs, 1)
+	require.True(expectedDockerComposeConfig.Equal(dockerComposeConfigs[0]))
+
+	expectedBase, err := filepath.Abs("test/assets/rebase")
+	require.NoError(err)
+	require.Equal(expectedBase, dockerComposeConfigs[0].Base())
+}
+
+func TestFetch_Base(t *testing.T) {
+	require := require.New(t)
+
+	dockerComposeConfigs, err := fetcher.Fetch("test/assets/rebase/integration-test/docker-compose.yml")
+	require.NoError(err)
+	require.Len(dockerComposeConfigs, 1)
+	require.Equal("test/assets/rebase/integration-test", dockerComposeConfigs[0].Base())
+
+	httpServer := httptest.NewServer(http.FileServer(http.Dir("test/assets")))
+	defer httpServer.Close()
+
+	dockerComposeConfigs, err = fetcher.Fetch(httpServer.URL + "/rebase/integration-test/docker-compose.yml")
+	require.NoError(err)
+	require.Len(dockerComposeConfigs, 1)
+	require.Equal(httpServer.URL+"/rebase/integration-test/", dockerComposeConfigs[0].Base())
+}
@@ -0,0 +1,254 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/domain/dockerCompose"
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/dotenv"
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/interpolation"
+)
+
+var (
+	ErrIncludeConflict error = errors.New("included resource conflicts with an existing resource")
+	ErrIncludeCycle    error = errors.New("cyclic include")
+)
+
+// resolveIncludes adds recursively the resources of all included docker-compose files to the passed document. The
+// chain contains the URLs of all docker-compose files which are currently included and is used to detect cycles.
+func resolveIncludes(document *dockerCompose.Document, dockerComposeURL *url.URL, chain []string, options *Options) error {
+	for _, r := range chain {
+		if r == dockerComposeURL.String() {
+			return fmt.Errorf("%w: %s -> %s", ErrIncludeCycle, strings.Join(chain, " -> "), dockerComposeURL)
+		}
+	}
+	chain = append(chain, dockerComposeURL.String())
+
+	includes := document.Config.Include
+	document.Config.Include = nil
+
+	for _, include := range includes {
+		if include == nil {
+			continue
+		}
+
+		includedDocument, err := fetchInclude(include, dockerComposeURL, chain, options)
+		if err != nil {
+			return err
+		}
+
+		// Relative paths of the included files refer to the project directory of the include. They are rebased to refer
+		// to the directory of the including docker-compose file instead. Paths of remote files are kept as declared.
+		if !isRemote(dockerComposeURL) {
+			err = includedDocument.Config.RebasePaths(document.Config.Base())
+			if err != nil && !errors.Is(err, dockerCompose.ErrRemoteBase) {
+				return err
+			}
+		}
+
+		err = addIncludedResources(document, includedDocument)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchInclude returns the document of an include. When the include declares multiple paths, the later docker-compose
+// files overwrite the earlier ones like override files. Relative paths of all included docker-compose files refer to
+// the project directory of the include, which is the directory of the first docker-compose file by default.
+func fetchInclude(include *dockerCompose.Include, dockerComposeURL *url.URL, chain []string, options *Options) (*dockerCompose.Document, error) {
+	if len(include.Path) <= 0 {
+		return dockerCompose.NewDocument(dockerCompose.NewConfig()), nil
+	}
+
+	projectDirectory, err := projectDirectoryOf(include, dockerComposeURL)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err = withIncludeEnvironment(include, dockerComposeURL, projectDirectory, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// The changes are tracked to keep the origins of the included values for the provenance of the including document.
+	var base string
+	merger := dockerCompose.NewMerger()
+	merger.TrackChanges()
+
+	for _, path := range include.Path {
+		includedURL, err := resolveReference(dockerComposeURL, path)
+		if err != nil {
+			return nil, err
+		}
+
+		pathDocument, err := fetch(includedURL, projectDirectory, options)
+		if err != nil {
+			return nil, err
+		}
+
+		err = resolveIncludes(pathDocument, includedURL, chain, options)
+		if err != nil {
+			return nil, err
+		}
+
+		err = resolveExtends(pathDocument.Config, includedURL, options)
+		if err != nil {
+			return nil, err
+		}
+
+		err = merger.Merge(pathDocument, dockerCompose.StrategyLastWin)
+		if err != nil {
+			return nil, err
+		}
+
+		// The relative paths of all included docker-compose files refer to the same base.
+		base = pathDocument.Config.Base()
+	}
+
+	includedDocument := merger.Result().Document
+	includedDocument.Config.SetBase(base)
+
+	return includedDocument, nil
+}
+
+// projectDirectoryOf returns the project directory of the include. The project directory is resolved relative to the
+// including docker-compose file. Without project directory, the directory of the first included docker-compose file is
+// returned.
+func projectDirectoryOf(include *dockerCompose.Include, dockerComposeURL *url.URL) (string, error) {
+	if len(include.ProjectDirectory) <= 0 {
+		includedURL, err := resolveReference(dockerComposeURL, include.Path[0])
+		if err != nil {
+			return "", err
+		}
+		return directoryOf(includedURL), nil
+	}
+
+	projectURL, err := resolveReference(dockerComposeURL, include.ProjectDirectory)
+	switch {
+	case err != nil:
+		return "", err
+	case isRemote(projectURL):
+		return strings.TrimSuffix(projectURL.String(), "/") + "/", nil
+	default:
+		return projectURL.Path, nil
+	}
+}
+
+// withIncludeEnvironment returns a copy of the options, whose environment is completed by the variables of the env
+// files of the include. The env files are resolved relative to the including docker-compose file. Without env files,
+// the .env file of the project directory is read, if present. Like docker compose, the variables of the environment
+// take precedence over the variables of the env files.
+func withIncludeEnvironment(include *dockerCompose.Include, dockerComposeURL *url.URL, projectDirectory string, options *Options) (*Options, error) {
+	envFileURLs := make([]*url.URL, 0, len(include.EnvFile))
+	for _, envFile := range include.EnvFile {
+		envFileURL, err := resolveReference(dockerComposeURL, envFile)
+		if err != nil {
+			return nil, err
+		}
+		envFileURLs = append(envFileURLs, envFileURL)
+	}
+
+	if len(envFileURLs) <= 0 {
+		projectURL, err := url.Parse(projectDirectory)
+		if err != nil {
+			return nil, err
+		}
+
+		envFileURL := &url.URL{Path: filepath.Join(projectDirectory, dotEnvFilename)}
+		_, err = os.Stat(envFileURL.Path)
+		switch {
+		case isRemote(projectURL) || errors.Is(err, os.ErrNotExist):
+			return options, nil
+		case err != nil:
+			return nil, err
+		}
+		envFileURLs = append(envFileURLs, envFileURL)
+	}
+
+	optionsWithEnvFiles := *options
+	optionsWithEnvFiles.Environment = make(map[string]string)
+	for _, envFileURL := range envFileURLs {
+		variables, err := readEnvFile(envFileURL, interpolation.LookupMap(options.Environment))
+		if err != nil {
+			return nil, err
+		}
+
+		for key, value := range variables {
+			optionsWithEnvFiles.Environment[key] = value
+		}
+	}
+
+	for key, value := range options.Environment {
+		optionsWithEnvFiles.Environment[key] = value
+	}
+
+	return &optionsWithEnvFiles, nil
+}
+
+// readEnvFile returns the variables of the env file of the passed URL. Variable expressions of the env file refer to
+// the variables declared before in the env file or to the variables of the lookup function.
+func readEnvFile(envFileURL *url.URL, lookup interpolation.LookupFunc) (map[string]string, error) {
+	if !isRemote(envFileURL) {
+		return dotenv.ReadWithLookup(envFileURL.Path, lookup)
+	}
+
+	body, err := getViaHTTP(envFileURL.String())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = body.Close() }()
+
+	variables, err := dotenv.ParseWithLookup(body, lookup)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", envFileURL, err)
+	}
+
+	return variables, nil
+}
+
+// addIncludedResources adds the configs, networks, secrets, services and volumes of the included document to the
+// passed document. An error is returned, when a resource is already declared with a different definition.
+func addIncludedResources(document *dockerCompose.Document, includedDocument *dockerCompose.Document) error {
+	config, includedConfig := document.Config, includedDocument.Config
+
+	for name, configObject := range includedConfig.Configs {
+		if config.ExistsConfig(name) && !config.Configs[name].Equal(configObject) {
+			return fmt.Errorf("%w: config %s", ErrIncludeConflict, name)
+		}
+	}
+
+	for name, network := range includedConfig.Networks {
+		if config.ExistsNetwork(name) && !config.Networks[name].Equal(network) {
+			return fmt.Errorf("%w: network %s", ErrIncludeConflict, name)
+		}
+	}
+
+	for name, secret := range includedConfig.Secrets {
+		if config.ExistsSecret(name) && !config.Secrets[name].Equal(secret) {
+			return fmt.Errorf("%w: secret %s", ErrIncludeConflict, name)
+		}
+	}
+
+	for name, service := range includedConfig.Services {
+		if config.ExistsService(name) && !config.Services[name].Equal(service) {
+			return fmt.Errorf("%w: service %s", ErrIncludeConflict, name)
+		}
+	}
+
+	for name, volume := range includedConfig.Volumes {
+		if config.ExistsVolume(name) && !config.Volumes[name].Equal(volume) {
+			return fmt.Errorf("%w: volume %s", ErrIncludeConflict, name)
+		}
+	}
+
+	document.Include(includedDocument)
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package interpolation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrInvalidExpression error = errors.New("invalid interpolation expression")
+	ErrRequiredVariable  error = errors.New("required variable is missing a value")
+)
+
+// LookupFunc returns the value of a variable and true if the variable is set.
+type LookupFunc func(name string) (string, bool)
+
+// LookupMap returns a LookupFunc, which looks up the variables in the passed map.
+func LookupMap(variables map[string]string) LookupFunc {
+	return func(name string) (string, bool) {
+		value, present := variables[name]
+		return value, present
+	}
+}
+
+// Interpolate replaces all variable expressions of the passed string by the values of the variables. All operators of
+// the compose specification are supported:
+//
+//	$VAR, ${VAR}          value of VAR, empty if unset
+//	${VAR:-default}       default if VAR is unset or empty
+//	${VAR-default}        default if VAR is unset
+//	${VAR:?message}       error if VAR is unset or empty
+//	${VAR?message}        error if VAR is unset
+//	${VAR:+replacement}   replacement if VAR is set and not empty
+//	${VAR+replacement}    replacement if VAR is set
+//	$$                    literal $
+//
+// Defaults, messages and replacements can contain further expressions.
+func Interpolate(s string, lookup LookupFunc) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	sb := new(strings.Builder)
+
+	for i := 0; i < len(s); {
+		if s[i] != '$' || i+1 >= len(s) {
+			sb.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		switch {
+		case s[i+1] == '$':
+			sb.WriteByte('$')
+			i += 2
+		case s[i+1] == '{':
+			end, err := closingBrace(s, i+2)
+			if err != nil {
+				return "", err
+			}
+
+			value, err := expand(s[i+2:end], lookup)
+			if err != nil {
+				return "", err
+			}
+
+			sb.WriteString(value)
+			i = end + 1
+		case isNameStart(s[i+1]):
+			j := i + 1
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+
+			value, _ := lookup(s[i+1 : j])
+			sb.WriteString(value)
+			i = j
+		default:
+			sb.WriteByte('$')
+			i++
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// Escape returns the string with each $ escaped as $$, so that interpolating the returned string results in the
+// passed string again.
+func Escape(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+// EscapeNode escapes the $ of all scalar values of the passed node like Escape. Keys of mappings are not escaped, like
+// they are not interpolated. An interpolated node is escaped before it is written, so that literal $ are not
+// interpolated again by docker compose.
+func EscapeNode(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		node.Value = Escape(node.Value)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			EscapeNode(node.Content[i+1])
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			EscapeNode(child)
+		}
+	}
+}
+
+// InterpolateNode interpolates all scalar values of the passed node. Keys of mappings are not interpolated. Plain
+// scalars are resolved again after the interpolation, therefore an interpolated value like `true` or `1.5` is decoded
+// as boolean or number. Errors are prefixed by the line and column of the scalar.
+func InterpolateNode(node *yaml.Node, lookup LookupFunc) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		value, err := Interpolate(node.Value, lookup)
+		if err != nil {
+			return fmt.Errorf("%v:%v: %w", node.Line, node.Column, err)
+		}
+
+		if value != node.Value {
+			node.Value = value
+			if node.Tag == "!!str" && node.Style == 0 {
+				node.Tag = ""
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			err := InterpolateNode(node.Content[i+1], lookup)
+			if err != nil {
+				return err
+			}
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			err := InterpolateNode(child, lookup)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Alias nodes are skipped, because their anchor is already interpolated.
+	return nil
+}
+
+// closingBrace returns the index of the brace, which closes the expression starting at the passed index.
+func closingBrace(s string, start int) (int, error) {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%w: missing closing brace: %s", ErrInvalidExpression, s)
+}
+
+// expand returns the value of a braced expression without the surrounding braces.
+func expand(expression string, lookup LookupFunc) (string, error) {
+	i := 0
+	for i < len(expression) && isNameChar(expression[i]) {
+		i++
+	}
+
+	name := expression[:i]
+	if len(name) <= 0 || !isNameStart(name[0]) {
+		return "", fmt.Errorf("%w: ${%s}", ErrInvalidExpression, expression)
+	}
+
+	value, present := lookup(name)
+
+	operator := expression[i:]
+	if len(operator) <= 0 {
+		return value, nil
+	}
+
+	colon := operator[0] == ':'
+	if colon {
+		operator = operator[1:]
+	}
+
+	if len(operator) <= 0 {
+		return "", fmt.Errorf("%w: ${%s}", ErrInvalidExpression, expression)
+	}
+
+	argument := operator[1:]
+
+	// With a colon, an empty value is treated like an unset variable.
+	set := present && (!colon || len(value) > 0)
+
+	switch operator[0] {
+	case '-':
+		if set {
+			return value, nil
+		}
+		return Interpolate(argument, lookup)
+	case '?':
+		if set {
+			return value, nil
+		}
+
+		message, err := Interpolate(argument, lookup)
+		if err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%w: %s: %s", ErrRequiredVariable, name, message)
+	case '+':
+		if set {
+			return Interpolate(argument, lookup)
+		}
+		return "", nil
+	default:
+		return "", fmt.Errorf("%w: ${%s}", ErrInvalidExpression, expression)
+	}
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,139 @@
+package interpolation_test
+
+import (
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/interpolation"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestInterpolate(t *testing.T) {
+	require := require.New(t)
+
+	lookup := interpolation.LookupMap(map[string]string{
+		"EMPTY": "",
+		"PORT":  "8080",
+		"TAG":   "1.0.0",
+	})
+
+	testCases := []struct {
+		s             string
+		expectedValue string
+		expectedError error
+	}{
+		{s: "library/app:latest", expectedValue: "library/app:latest"},
+		{s: "library/app:$TAG", expectedValue: "library/app:1.0.0"},
+		{s: "library/app:${TAG}", expectedValue: "library/app:1.0.0"},
+		{s: "library/app:${UNSET}", expectedValue: "library/app:"},
+		{s: "${EMPTY:-default}", expectedValue: "default"},
+		{s: "${EMPTY-default}", expectedValue: ""},
+		{s: "${UNSET-default}", expectedValue: "default"},
+		{s: "${UNSET:-${PORT}}:80", expectedValue: "8080:80"},
+		{s: "${UNSET:-${EMPTY:-80}}", expectedValue: "80"},
+		{s: "${TAG:?required}", expectedValue: "1.0.0"},
+		{s: "${EMPTY?required}", expectedValue: ""},
+		{s: "${EMPTY:?required}", expectedError: interpolation.ErrRequiredVariable},
+		{s: "${UNSET?required}", expectedError: interpolation.ErrRequiredVariable},
+		{s: "${TAG:+set}", expectedValue: "set"},
+		{s: "${EMPTY:+set}", expectedValue: ""},
+		{s: "${EMPTY+set}", expectedValue: "set"},
+		{s: "${UNSET+set}", expectedValue: ""},
+		{s: "$$TAG and $${TAG}", expectedValue: "$TAG and ${TAG}"},
+		{s: "costs 5$", expectedValue: "costs 5$"},
+		{s: "${TAG", expectedError: interpolation.ErrInvalidExpression},
+		{s: "${}", expectedError: interpolation.ErrInvalidExpression},
+		{s: "${TAG*}", expectedError: interpolation.ErrInvalidExpression},
+	}
+
+	for i, testCase := range testCases {
+		value, err := interpolation.Interpolate(testCase.s, lookup)
+		if testCase.expectedError != nil {
+			require.ErrorIs(err, testCase.expectedError, "TestCase %v", i)
+			continue
+		}
+		require.NoError(err, "TestCase %v", i)
+		require.Equal(testCase.expectedValue, value, "TestCase %v", i)
+	}
+}
+
+func TestInterpolateNode(t *testing.T) {
+	require := require.New(t)
+
+	lookup := interpolation.LookupMap(map[string]string{
+		"EXTERNAL": "true",
+		"NAME":     "app",
+	})
+
+	node := new(yaml.Node)
+	require.NoError(yaml.Unmarshal([]byte(`${NAME}:
+  external: ${EXTERNAL}
+  labels:
+  - $$NAME
+  - ${NAME}
+  quoted: !!str ${EXTERNAL}
+`), node))
+	require.NoError(interpolation.InterpolateNode(node, lookup))
+
+	var v map[string]map[string]interface{}
+	require.NoError(node.Decode(&v))
+	require.Equal(map[string]map[string]interface{}{
+		"${NAME}": {
+			"external": true,
+			"labels":   []interface{}{"$NAME", "app"},
+			"quoted":   "true",
+		},
+	}, v)
+}
+
+func TestInterpolateNode_Error(t *testing.T) {
+	require := require.New(t)
+
+	node := new(yaml.Node)
+	require.NoError(yaml.Unmarshal([]byte(`services:
+  app:
+    image: app:${TAG:?missing tag}
+`), node))
+
+	err := interpolation.InterpolateNode(node, interpolation.LookupMap(map[string]string{}))
+	require.ErrorIs(err, interpolation.ErrRequiredVariable)
+	require.EqualError(err, "3:12: required variable is missing a value: TAG: missing tag")
+}
+
+func TestEscapeNode(t *testing.T) {
+	require := require.New(t)
+
+	lookup := interpolation.LookupMap(map[string]string{
+		"HOME": "/root",
+		"NAME": "app",
+	})
+
+	node := new(yaml.Node)
+	require.NoError(yaml.Unmarshal([]byte(`command: echo $$HOME ${NAME}
+environment:
+- PW=$${SECRET}
+- PRICE=5$
+`), node))
+	require.NoError(interpolation.InterpolateNode(node, lookup))
+
+	var interpolated map[string]interface{}
+	require.NoError(node.Decode(&interpolated))
+
+	interpolation.EscapeNode(node)
+
+	b, err := yaml.Marshal(node)
+	require.NoError(err)
+	require.Equal(`command: echo $$HOME app
+environment:
+    - PW=$${SECRET}
+    - PRICE=5$$
+`, string(b))
+
+	roundTrip := new(yaml.Node)
+	require.NoError(yaml.Unmarshal(b, roundTrip))
+	require.NoError(interpolation.InterpolateNode(roundTrip, lookup))
+
+	var v map[string]interface{}
+	require.NoError(roundTrip.Decode(&v))
+	require.Equal(interpolated, v)
+}
@@ -0,0 +1,149 @@
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines around each change of a hunk.
+const contextLines int = 3
+
+// operation is a single line of an edit script, which keeps, removes or adds a line.
+type operation struct {
+	kind byte
+	line string
+}
+
+// Unified returns the difference between the texts from and to in the unified format. The names are the names of the
+// texts in the header. When both texts are equal, an empty string is returned.
+//
+//	// Example
+//	s := Unified("a.yml", "b.yml", "image: nginx\n", "image: httpd\n")
+//	// Output:
+//	// --- a.yml
+//	// +++ b.yml
+//	// @@ -1,1 +1,1 @@
+//	// -image: nginx
+//	// +image: httpd
+func Unified(fromName string, toName string, from string, to string) string {
+	if from == to {
+		return ""
+	}
+
+	operations := editScript(splitLines(from), splitLines(to))
+
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "--- %s\n", fromName)
+	fmt.Fprintf(sb, "+++ %s\n", toName)
+
+	fromLine, toLine := 0, 0
+	for i := 0; i < len(operations); {
+		if operations[i].kind == ' ' {
+			fromLine, toLine, i = fromLine+1, toLine+1, i+1
+			continue
+		}
+
+		// The hunk starts with the context in front of the change and ends, when the following change is more than two
+		// contexts away.
+		start := i
+		for start > 0 && i-start < contextLines && operations[start-1].kind == ' ' {
+			start--
+		}
+
+		lastChange := i
+		for end := i; end < len(operations) && end-lastChange <= 2*contextLines+1; end++ {
+			if operations[end].kind != ' ' {
+				lastChange = end
+			}
+		}
+
+		end := lastChange + 1 + contextLines
+		if end > len(operations) {
+			end = len(operations)
+		}
+
+		hunkFromLine, hunkToLine := fromLine-(i-start), toLine-(i-start)
+		hunkFromLines, hunkToLines := 0, 0
+		for _, operation := range operations[start:end] {
+			if operation.kind != '+' {
+				hunkFromLines++
+			}
+			if operation.kind != '-' {
+				hunkToLines++
+			}
+		}
+
+		fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(hunkFromLine, hunkFromLines), hunkRange(hunkToLine, hunkToLines))
+		for _, operation := range operations[start:end] {
+			fmt.Fprintf(sb, "%c%s\n", operation.kind, operation.line)
+		}
+
+		for _, operation := range operations[i:end] {
+			if operation.kind != '+' {
+				fromLine++
+			}
+			if operation.kind != '-' {
+				toLine++
+			}
+		}
+		i = end
+	}
+
+	return sb.String()
+}
+
+// hunkRange returns the range of a hunk. The line is zero based. An empty range refers to the line in front of it.
+func hunkRange(line int, lines int) string {
+	if lines <= 0 {
+		return fmt.Sprintf("%v,%v", line, lines)
+	}
+	return fmt.Sprintf("%v,%v", line+1, lines)
+}
+
+// splitLines splits the text into its lines. A trailing line break does not start a new line.
+func splitLines(s string) []string {
+	if len(s) <= 0 {
+		return make([]string, 0)
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// editScript returns the shortest edit script, which transforms the lines from into the lines to, based on their
+// longest common subsequence.
+func editScript(from []string, to []string) []operation {
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			switch {
+			case from[i] == to[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	operations := make([]operation, 0, len(from)+len(to))
+	i, j := 0, 0
+	for i < len(from) || j < len(to) {
+		switch {
+		case i < len(from) && j < len(to) && from[i] == to[j]:
+			operations = append(operations, operation{kind: ' ', line: from[i]})
+			i, j = i+1, j+1
+		case j >= len(to) || (i < len(from) && lcs[i+1][j] >= lcs[i][j+1]):
+			operations = append(operations, operation{kind: '-', line: from[i]})
+			i++
+		default:
+			operations = append(operations, operation{kind: '+', line: to[j]})
+			j++
+		}
+	}
+
+	return operations
+}
@@ -0,0 +1,78 @@
+package textdiff_test
+
+import (
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/dcmerge/pkg/textdiff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnified(t *testing.T) {
+	require := require.New(t)
+
+	testCases := []struct {
+		from           string
+		to             string
+		expectedResult string
+	}{
+		{
+			from:           "a\nb\n",
+			to:             "a\nb\n",
+			expectedResult: "",
+		},
+		{
+			from: "",
+			to:   "a\n",
+			expectedResult: `--- from
++++ to
+@@ -0,0 +1,1 @@
++a
+`,
+		},
+		{
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n",
+			to:   "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n15\n16\nseventeen\n",
+			expectedResult: `--- from
++++ to
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -11,6 +11,6 @@
+ 11
+ 12
+ 13
+-14
+ 15
+ 16
++seventeen
+`,
+		},
+		{
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n",
+			to:   "one\n2\n3\n4\n5\n6\n7\neight\n",
+			expectedResult: `--- from
++++ to
+@@ -1,8 +1,8 @@
+-1
++one
+ 2
+ 3
+ 4
+ 5
+ 6
+ 7
+-8
++eight
+`,
+		},
+	}
+
+	for i, testCase := range testCases {
+		require.Equal(testCase.expectedResult, textdiff.Unified("from", "to", testCase.from, testCase.to), "TestCase %v", i)
+	}
+}